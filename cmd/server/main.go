@@ -8,6 +8,7 @@ import (
 
 	"os"
 
+	"github.com/DoDuy2004/slack-clone-backend/internal/authz"
 	"github.com/DoDuy2004/slack-clone-backend/internal/config"
 	"github.com/DoDuy2004/slack-clone-backend/internal/database"
 	"github.com/DoDuy2004/slack-clone-backend/internal/handler"
@@ -16,7 +17,10 @@ import (
 	"github.com/DoDuy2004/slack-clone-backend/internal/service"
 	"github.com/DoDuy2004/slack-clone-backend/internal/websocket"
 	"github.com/DoDuy2004/slack-clone-backend/pkg/jwt"
+	"github.com/DoDuy2004/slack-clone-backend/pkg/mailer"
+	"github.com/DoDuy2004/slack-clone-backend/pkg/push"
 	"github.com/DoDuy2004/slack-clone-backend/pkg/storage"
+	"github.com/DoDuy2004/slack-clone-backend/pkg/webpush"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
@@ -73,6 +77,8 @@ func main() {
 	dmRepo := repository.NewDMRepository(db)
 	reactionRepo := repository.NewReactionRepository(db)
 	attachmentRepo := repository.NewAttachmentRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	roleRepo := repository.NewRoleRepository(db)
 
 	// Initialize Storage
 	uploadDir := "./uploads"
@@ -87,26 +93,112 @@ func main() {
 	go hub.Run()
 
 	// Initialize services
+	authorizer := authz.New(workspaceRepo, roleRepo)
 	authService := service.NewAuthService(userRepo, jwtManager)
-	workspaceService := service.NewWorkspaceService(workspaceRepo)
-	channelService := service.NewChannelService(channelRepo, workspaceRepo)
-	messageService := service.NewMessageService(messageRepo, channelRepo, workspaceRepo, dmRepo, attachmentRepo, userRepo)
-	dmService := service.NewDMService(dmRepo, workspaceRepo, userRepo)
-	reactionService := service.NewReactionService(reactionRepo, messageRepo, channelRepo, dmRepo, workspaceRepo)
+	workspaceService := service.NewWorkspaceService(workspaceRepo, channelRepo, userRepo, auditRepo, authorizer, hub)
+	go workspaceService.RunGuestExpiry(time.Minute)
+	roleService := service.NewRoleService(roleRepo, workspaceRepo, auditRepo, authorizer)
+	channelService := service.NewChannelService(channelRepo, workspaceRepo, userRepo, messageRepo, authorizer, hub)
+	threadRepo := repository.NewThreadRepository(db)
+	mentionRepo := repository.NewMentionRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+	notificationPreferenceRepo := repository.NewNotificationPreferenceRepository(db)
+	dndRepo := repository.NewDNDRepository(db)
+	dndService := service.NewDNDService(dndRepo, hub)
+
+	// Initialize Web Push; without configured keys pushes only work until restart
+	var vapidKeys *webpush.VAPIDKeys
+	if cfg.VAPIDPrivateKey != "" {
+		vapidKeys, err = webpush.ParseVAPIDKeys(cfg.VAPIDPrivateKey, cfg.VAPIDPublicKey)
+	} else {
+		log.Println("VAPID keys not configured, generating temporary keys")
+		vapidKeys, err = webpush.GenerateVAPIDKeys()
+	}
+	if err != nil {
+		log.Fatal("Failed to load VAPID keys:", err)
+	}
+	pushRepo := repository.NewPushRepository(db)
+	pushService := service.NewPushService(pushRepo, webpush.NewSender(vapidKeys, cfg.VAPIDSubject, nil), hub)
+	go pushService.RunQueue(15 * time.Second)
+
+	// Initialize mobile push; platforms without credentials are skipped
+	var pushProviders []push.Provider
+	if cfg.APNsKeyFile != "" {
+		apnsKey, err := os.ReadFile(cfg.APNsKeyFile)
+		if err != nil {
+			log.Fatal("Failed to read APNs key:", err)
+		}
+		apns, err := push.NewAPNsProvider(push.APNsConfig{
+			KeyPEM:     string(apnsKey),
+			KeyID:      cfg.APNsKeyID,
+			TeamID:     cfg.APNsTeamID,
+			Topic:      cfg.APNsTopic,
+			Production: cfg.APNsProduction,
+		}, nil)
+		if err != nil {
+			log.Fatal("Failed to initialize APNs:", err)
+		}
+		pushProviders = append(pushProviders, apns)
+	}
+	if cfg.FCMCredentialsFile != "" {
+		fcmCredentials, err := os.ReadFile(cfg.FCMCredentialsFile)
+		if err != nil {
+			log.Fatal("Failed to read FCM credentials:", err)
+		}
+		fcm, err := push.NewFCMProvider(push.FCMConfig{CredentialsJSON: string(fcmCredentials)}, nil)
+		if err != nil {
+			log.Fatal("Failed to initialize FCM:", err)
+		}
+		pushProviders = append(pushProviders, fcm)
+	}
+	deviceRepo := repository.NewDeviceRepository(db)
+	mobilePushService := service.NewMobilePushService(deviceRepo, push.NewRedisQueue(redisClient.Client, "push:mobile"), pushProviders, hub)
+	hub.AddListener(mobilePushService.HandleEvent)
+	go mobilePushService.RunQueue(5 * time.Second)
+
+	notificationService := service.NewNotificationService(notificationRepo, notificationPreferenceRepo, workspaceRepo, channelRepo, dmRepo, dndService, pushService, hub)
+	go notificationService.RunHeldDelivery(time.Minute)
+	messageService := service.NewMessageService(messageRepo, channelRepo, workspaceRepo, dmRepo, attachmentRepo, userRepo, threadRepo, mentionRepo, authorizer, hub, notificationService, dndService)
+	dmService := service.NewDMService(dmRepo, workspaceRepo, userRepo, channelRepo)
+	reactionService := service.NewReactionService(reactionRepo, messageRepo, channelRepo, dmRepo, workspaceRepo, messageService, notificationService)
 	fileService := service.NewFileService(attachmentRepo, storageService)
 	readService := service.NewReadReceiptService(channelRepo, dmRepo, hub)
 	searchService := service.NewSearchService(messageRepo, workspaceRepo)
 	userService := service.NewUserService(userRepo)
+	// Initialize mailer; without SMTP settings mail is only logged
+	var mail mailer.Mailer = mailer.NewLogMailer()
+	if cfg.SMTPHost != "" {
+		mail = mailer.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom)
+	}
+
 	inviteRepo := repository.NewInviteRepository(db)
-	inviteService := service.NewInviteService(inviteRepo, workspaceRepo)
+	inviteService := service.NewInviteService(inviteRepo, workspaceRepo, channelRepo, userRepo, authorizer, notificationService, mail, cfg.AppURL, hub)
+
+	presenceService := service.NewPresenceService(userRepo, dndService, hub)
+
+	savedItemRepo := repository.NewSavedItemRepository(db)
+	savedItemService := service.NewSavedItemService(savedItemRepo, workspaceRepo, messageService, hub)
+	go savedItemService.RunReminders(30 * time.Second)
 
-	presenceService := service.NewPresenceService(userRepo, hub)
+	threadService := service.NewThreadService(threadRepo, workspaceRepo, messageService, dndService, hub)
+
+	sidebarRepo := repository.NewSidebarRepository(db)
+	sidebarService := service.NewSidebarService(sidebarRepo, channelRepo, dmRepo, workspaceRepo, hub)
+
+	digestRepo := repository.NewDigestRepository(db)
+	digestService := service.NewDigestService(digestRepo, mail, cfg.AppURL, cfg.DigestIdleAfter)
+	go digestService.RunDigests(cfg.DigestInterval)
+
+	teardownRepo := repository.NewTeardownRepository(db)
+	teardownService := service.NewTeardownService(teardownRepo, workspaceRepo, storageService, hub)
+	go teardownService.RunTeardown(5 * time.Minute)
 
 	// Initialize handlers
 	authHandler := handler.NewAuthHandler(authService, cfg)
-	workspaceHandler := handler.NewWorkspaceHandler(workspaceService)
+	workspaceHandler := handler.NewWorkspaceHandler(workspaceService, teardownService)
+	roleHandler := handler.NewRoleHandler(roleService)
 	channelHandler := handler.NewChannelHandler(channelService)
-	messageHandler := handler.NewMessageHandler(messageService, hub) // Inject hub
+	messageHandler := handler.NewMessageHandler(messageService, threadService, hub) // Inject hub
 	dmHandler := handler.NewDMHandler(dmService)
 	reactionHandler := handler.NewReactionHandler(reactionService, messageService, hub)
 	fileHandler := handler.NewFileHandler(fileService)
@@ -114,6 +206,14 @@ func main() {
 	searchHandler := handler.NewSearchHandler(searchService)
 	userHandler := handler.NewUserHandler(userService)
 	inviteHandler := handler.NewInviteHandler(inviteService)
+	savedItemHandler := handler.NewSavedItemHandler(savedItemService)
+	threadHandler := handler.NewThreadHandler(threadService)
+	sidebarHandler := handler.NewSidebarHandler(sidebarService)
+	notificationHandler := handler.NewNotificationHandler(notificationService)
+	dndHandler := handler.NewDNDHandler(dndService)
+	digestHandler := handler.NewDigestHandler(digestService)
+	pushHandler := handler.NewPushHandler(pushService)
+	deviceHandler := handler.NewDeviceHandler(mobilePushService)
 	wsHandler := websocket.NewHandler(hub, jwtManager, presenceService)
 
 	// Create Gin router
@@ -152,6 +252,9 @@ func main() {
 			auth.POST("/logout", authHandler.Logout)
 		}
 
+		// Web Push key, needed before the browser subscribes
+		api.GET("/push/vapid-public-key", pushHandler.VAPIDPublicKey)
+
 		// Protected routes (require authentication)
 		protected := api.Group("")
 		protected.Use(middleware.AuthMiddleware(jwtManager))
@@ -168,6 +271,18 @@ func main() {
 				users.PUT("/me", func(c *gin.Context) {
 					c.JSON(http.StatusOK, gin.H{"message": "Update user - TODO"})
 				})
+				users.GET("/profile", userHandler.GetProfile)
+				users.PUT("/profile", userHandler.UpdateProfile)
+
+				// Do Not Disturb
+				users.GET("/dnd", dndHandler.Get)
+				users.PUT("/dnd", dndHandler.Update)
+				users.POST("/dnd/snooze", dndHandler.Snooze)
+				users.DELETE("/dnd/snooze", dndHandler.EndSnooze)
+
+				// Email digest
+				users.GET("/email-digest", digestHandler.GetSettings)
+				users.PUT("/email-digest", digestHandler.UpdateSettings)
 			}
 
 			// Workspace routes
@@ -175,17 +290,56 @@ func main() {
 			{
 				workspaces.GET("", workspaceHandler.List)
 				workspaces.POST("", workspaceHandler.Create)
+				workspaces.GET("/deleted", workspaceHandler.ListDeleted)
 				workspaces.GET("/:id", workspaceHandler.Get)
 				workspaces.PUT("/:id", workspaceHandler.Update)
 				workspaces.DELETE("/:id", workspaceHandler.Delete)
+				workspaces.POST("/:id/restore", workspaceHandler.Restore)
+				workspaces.GET("/:id/deletion", workspaceHandler.GetDeletion)
 
 				// Channel routes within a workspace
-				workspaces.GET("/:workspace_id/channels", channelHandler.ListByWorkspace)
-				workspaces.POST("/:workspace_id/channels", channelHandler.Create)
+				workspaces.GET("/:id/channels", channelHandler.ListByWorkspace)
+				workspaces.GET("/:id/channels/browse", channelHandler.Browse)
+				workspaces.POST("/:id/channels", channelHandler.Create)
 
 				// DM routes within a workspace
-				workspaces.GET("/:workspace_id/dms", dmHandler.List)
-				workspaces.POST("/:workspace_id/dms", dmHandler.GetOrCreate)
+				workspaces.GET("/:id/dms", dmHandler.List)
+				workspaces.POST("/:id/dms", dmHandler.GetOrCreate)
+
+				// Members and roles
+				workspaces.GET("/:id/members", workspaceHandler.ListMembers)
+				workspaces.PUT("/:id/members/:user_id/role", workspaceHandler.UpdateMemberRole)
+				workspaces.DELETE("/:id/members/:user_id", workspaceHandler.RemoveMember)
+				workspaces.PUT("/:id/members/:user_id/guest-expiry", workspaceHandler.SetGuestExpiry)
+				workspaces.PUT("/:id/members/:user_id/custom-role", roleHandler.Assign)
+				workspaces.POST("/:id/transfer-ownership", workspaceHandler.TransferOwnership)
+				workspaces.GET("/:id/audit-log", workspaceHandler.ListAuditLog)
+				workspaces.GET("/:id/roles", roleHandler.List)
+				workspaces.POST("/:id/roles", roleHandler.Create)
+				workspaces.PUT("/:id/roles/:role_id", roleHandler.Update)
+				workspaces.DELETE("/:id/roles/:role_id", roleHandler.Delete)
+
+				// Invites
+				workspaces.POST("/:id/invites", inviteHandler.Create)
+				workspaces.GET("/:id/invites", inviteHandler.List)
+				workspaces.DELETE("/:id/invites/:invite_id", inviteHandler.Revoke)
+				workspaces.GET("/:id/invites/:invite_id/redemptions", inviteHandler.ListRedemptions)
+
+				// Settings
+				workspaces.GET("/:id/settings", workspaceHandler.GetSettings)
+				workspaces.PUT("/:id/settings", workspaceHandler.UpdateSettings)
+				workspaces.GET("/:id/notification-settings", notificationHandler.GetSettings)
+				workspaces.PUT("/:id/notification-settings", notificationHandler.UpdateSettings)
+
+				// Search, threads, saved items and sidebar
+				workspaces.GET("/:id/search", searchHandler.SearchInWorkspace)
+				workspaces.GET("/:id/threads", threadHandler.Inbox)
+				workspaces.GET("/:id/saved", savedItemHandler.List)
+				workspaces.GET("/:id/sidebar", sidebarHandler.Get)
+				workspaces.POST("/:id/sidebar/sections", sidebarHandler.CreateSection)
+				workspaces.PUT("/:id/sidebar/sections/order", sidebarHandler.ReorderSections)
+				workspaces.POST("/:id/sidebar/starred", sidebarHandler.Star)
+				workspaces.DELETE("/:id/sidebar/starred", sidebarHandler.Unstar)
 			}
 
 			// Individual channel routes
@@ -194,10 +348,28 @@ func main() {
 				channels.GET("/:id", channelHandler.Get)
 				channels.PUT("/:id", channelHandler.Update)
 				channels.DELETE("/:id", channelHandler.Delete)
+				channels.POST("/:id/archive", channelHandler.Archive)
+				channels.POST("/:id/unarchive", channelHandler.Unarchive)
+				channels.POST("/:id/convert", channelHandler.Convert)
+				channels.PUT("/:id/topic", channelHandler.SetTopic)
+				channels.PUT("/:id/posting-policy", channelHandler.SetPostingPolicy)
+				channels.GET("/:id/changes", channelHandler.ListChanges)
+
+				// Membership routes
+				channels.POST("/:id/join", channelHandler.Join)
+				channels.POST("/:id/leave", channelHandler.Leave)
+				channels.GET("/:id/members", channelHandler.ListMembers)
+				channels.POST("/:id/members", channelHandler.AddMembers)
+				channels.DELETE("/:id/members/:user_id", channelHandler.RemoveMember)
 
 				// Message routes within a channel
 				channels.GET("/:id/messages", messageHandler.ListByChannel)
 				channels.POST("/:id/messages", messageHandler.SendChannel)
+				channels.POST("/:id/read", readHandler.MarkChannelAsRead)
+
+				// Notification preferences
+				channels.GET("/:id/notifications", notificationHandler.GetChannelPreference)
+				channels.PUT("/:id/notifications", notificationHandler.UpdateChannelPreference)
 			}
 
 			// Individual DM routes
@@ -205,38 +377,70 @@ func main() {
 			{
 				dms.GET("/:id/messages", messageHandler.ListByDM)
 				dms.POST("/:id/messages", messageHandler.SendDM)
+				dms.POST("/:id/read", readHandler.MarkDMAsRead)
+				dms.GET("/:id/notifications", notificationHandler.GetDMPreference)
+				dms.PUT("/:id/notifications", notificationHandler.UpdateDMPreference)
 			}
 
 			// Individual message actions
 			messages := protected.Group("/messages")
 			{
 				messages.GET("/:id/thread", messageHandler.GetThread)
+				messages.POST("/:id/thread/follow", threadHandler.Follow)
+				messages.DELETE("/:id/thread/follow", threadHandler.Unfollow)
+				messages.POST("/:id/thread/read", threadHandler.MarkAsRead)
 				messages.PUT("/:id", messageHandler.Update)
 				messages.DELETE("/:id", messageHandler.Delete)
 
 				// Reaction routes
 				messages.POST("/:id/reactions", reactionHandler.Add)
 				messages.DELETE("/:id/reactions/:emoji", reactionHandler.Remove)
+
+				// Saved item routes
+				messages.POST("/:id/save", savedItemHandler.Save)
+				messages.DELETE("/:id/save", savedItemHandler.Unsave)
 			}
-		}
-	}
 
-	// File routes
-	router.POST("/api/files/upload", middleware.AuthMiddleware(jwtManager), fileHandler.Upload)
-	router.Static("/uploads", "./uploads")
+			// File routes
+			protected.POST("/files/upload", fileHandler.Upload)
 
-	// Read Receipt routes
-	router.POST("/api/channels/:id/read", middleware.AuthMiddleware(jwtManager), readHandler.MarkChannelAsRead)
-	router.POST("/api/dms/:id/read", middleware.AuthMiddleware(jwtManager), readHandler.MarkDMAsRead)
-	router.GET("/api/workspaces/:id/search", middleware.AuthMiddleware(jwtManager), searchHandler.SearchInWorkspace)
+			// Invite links
+			protected.POST("/invites/:code/join", inviteHandler.Join)
 
-	// User routes
-	router.GET("/api/users/profile", middleware.AuthMiddleware(jwtManager), userHandler.GetProfile)
-	router.PUT("/api/users/profile", middleware.AuthMiddleware(jwtManager), userHandler.UpdateProfile)
+			// Saved items
+			protected.PUT("/saved/:id", savedItemHandler.Update)
 
-	// Invite routes
-	router.POST("/api/workspaces/:id/invites", middleware.AuthMiddleware(jwtManager), inviteHandler.Create)
-	router.POST("/api/invites/:code/join", middleware.AuthMiddleware(jwtManager), inviteHandler.Join)
+			// Sidebar sections
+			sections := protected.Group("/sidebar/sections")
+			{
+				sections.PUT("/:id", sidebarHandler.UpdateSection)
+				sections.DELETE("/:id", sidebarHandler.DeleteSection)
+				sections.POST("/:id/items", sidebarHandler.PlaceItem)
+				sections.DELETE("/:id/items/:item_id", sidebarHandler.RemoveItem)
+			}
+
+			// Notification routes
+			notifications := protected.Group("/notifications")
+			{
+				notifications.GET("", notificationHandler.List)
+				notifications.GET("/unread-count", notificationHandler.UnreadCount)
+				notifications.POST("/read", notificationHandler.MarkAllAsRead)
+				notifications.POST("/:id/read", notificationHandler.MarkAsRead)
+			}
+
+			// Web Push subscriptions and mobile devices
+			push := protected.Group("/push")
+			{
+				push.POST("/subscriptions", pushHandler.Subscribe)
+				push.DELETE("/subscriptions", pushHandler.Unsubscribe)
+				push.GET("/devices", deviceHandler.List)
+				push.POST("/devices", deviceHandler.Register)
+				push.DELETE("/devices", deviceHandler.Unregister)
+			}
+		}
+	}
+
+	router.Static("/uploads", "./uploads")
 
 	// WebRTC signaling endpoint
 	router.GET("/webrtc/signaling", func(c *gin.Context) {
@@ -0,0 +1,195 @@
+// Package authz decides what workspace members may do. Every member has a
+// built-in role (owner, admin, member or one of the guest roles) and may also
+// hold one custom role defined by the workspace, which grants extra
+// permissions on top. Guests never gain permissions from custom roles.
+package authz
+
+import (
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+	"github.com/DoDuy2004/slack-clone-backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Permission names an action that can be granted to a role
+type Permission string
+
+// Workspace permissions
+const (
+	WorkspaceManageSettings Permission = "workspace.manage_settings"
+	WorkspaceViewAuditLog   Permission = "workspace.view_audit_log"
+	MemberManageRoles       Permission = "member.manage_roles" // assign roles and define custom roles
+	MemberRemove            Permission = "member.remove"
+	MemberManageGuests      Permission = "member.manage_guests" // invite guests, add them to channels and change their expiry
+	InviteCreate            Permission = "invite.create"
+)
+
+// Channel permissions. Creators can always update and archive their own
+// channels; these permissions cover everyone else's.
+const (
+	ChannelCreate         Permission = "channel.create"
+	ChannelUpdate         Permission = "channel.update"
+	ChannelArchive        Permission = "channel.archive"
+	ChannelDelete         Permission = "channel.delete"
+	ChannelConvert        Permission = "channel.convert"
+	ChannelManageDefaults Permission = "channel.manage_defaults"
+	ChannelManagePosting  Permission = "channel.manage_posting"
+	ChannelViewHistory    Permission = "channel.view_history"
+	ChannelRemoveMembers  Permission = "channel.remove_members"
+	ChannelPostRestricted Permission = "channel.post_restricted" // post regardless of posting policies
+)
+
+// Message permissions
+const (
+	MessageDeleteAny       Permission = "message.delete_any"
+	MessageMentionEveryone Permission = "message.mention_everyone" // @everyone, and @channel/@here whatever the workspace policy
+	MessageSendUrgent      Permission = "message.send_urgent"      // when urgent messages are limited to admins
+)
+
+// Built-in roles. Guests only see the channels they were added to; a
+// single-channel guest is limited to one.
+const (
+	RoleOwner              = "owner"
+	RoleAdmin              = "admin"
+	RoleMember             = "member"
+	RoleMultiChannelGuest  = "multi_channel_guest"
+	RoleSingleChannelGuest = "single_channel_guest"
+)
+
+// All lists every permission; the owner holds all of them
+var All = []Permission{
+	WorkspaceManageSettings, WorkspaceViewAuditLog, MemberManageRoles, MemberRemove, MemberManageGuests, InviteCreate,
+	ChannelCreate, ChannelUpdate, ChannelArchive, ChannelDelete, ChannelConvert, ChannelManageDefaults,
+	ChannelManagePosting, ChannelViewHistory, ChannelRemoveMembers, ChannelPostRestricted,
+	MessageDeleteAny, MessageMentionEveryone, MessageSendUrgent,
+}
+
+var builtinRoles = map[string][]Permission{
+	RoleOwner: All,
+	RoleAdmin: {
+		WorkspaceManageSettings, WorkspaceViewAuditLog, MemberRemove, MemberManageGuests, InviteCreate,
+		ChannelCreate, ChannelUpdate, ChannelArchive, ChannelConvert, ChannelManageDefaults,
+		ChannelManagePosting, ChannelViewHistory, ChannelRemoveMembers, ChannelPostRestricted,
+		MessageDeleteAny, MessageMentionEveryone, MessageSendUrgent,
+	},
+	RoleMember:             {ChannelCreate},
+	RoleMultiChannelGuest:  {},
+	RoleSingleChannelGuest: {},
+}
+
+// BuiltinPermissions returns the permissions of a built-in role
+func BuiltinPermissions(role string) []Permission {
+	return builtinRoles[role]
+}
+
+// BuiltinRoles lists the built-in role names, most privileged first
+func BuiltinRoles() []string {
+	return []string{RoleOwner, RoleAdmin, RoleMember, RoleMultiChannelGuest, RoleSingleChannelGuest}
+}
+
+// IsGuest reports whether role is one of the guest roles
+func IsGuest(role string) bool {
+	return role == RoleMultiChannelGuest || role == RoleSingleChannelGuest
+}
+
+// Valid reports whether p is a known permission
+func Valid(p Permission) bool {
+	for _, known := range All {
+		if p == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Assignable reports whether a custom role may grant p. Managing roles stays
+// with the built-in roles so a custom role can't be used to escalate, and
+// deleting channels stays with the owner.
+func Assignable(p Permission) bool {
+	return Valid(p) && p != MemberManageRoles && p != ChannelDelete
+}
+
+// Grant is what a member may do in a workspace. A nil Grant, for someone who
+// isn't a member, allows nothing.
+type Grant struct {
+	Member      *models.WorkspaceMember
+	permissions map[Permission]bool
+}
+
+// NewGrant combines the member's built-in role with their custom role's
+// permissions.
+func NewGrant(member *models.WorkspaceMember, custom []string) *Grant {
+	g := &Grant{Member: member, permissions: make(map[Permission]bool)}
+	for _, p := range builtinRoles[member.Role] {
+		g.permissions[p] = true
+	}
+	if IsGuest(member.Role) {
+		return g
+	}
+	for _, p := range custom {
+		if Assignable(Permission(p)) {
+			g.permissions[Permission(p)] = true
+		}
+	}
+	return g
+}
+
+// Has reports whether the grant includes p
+func (g *Grant) Has(p Permission) bool {
+	return g != nil && g.permissions[p]
+}
+
+// IsOwner reports whether the grant belongs to the workspace owner
+func (g *Grant) IsOwner() bool {
+	return g != nil && g.Member.Role == RoleOwner
+}
+
+// IsGuest reports whether the grant belongs to a guest
+func (g *Grant) IsGuest() bool {
+	return g != nil && IsGuest(g.Member.Role)
+}
+
+// Authorizer loads members' grants
+type Authorizer struct {
+	workspaceRepo repository.WorkspaceRepository
+	roleRepo      repository.RoleRepository
+}
+
+func New(workspaceRepo repository.WorkspaceRepository, roleRepo repository.RoleRepository) *Authorizer {
+	return &Authorizer{
+		workspaceRepo: workspaceRepo,
+		roleRepo:      roleRepo,
+	}
+}
+
+// Grant returns the user's grant in the workspace, or nil if they aren't a
+// member.
+func (a *Authorizer) Grant(workspaceID, userID uuid.UUID) (*Grant, error) {
+	member, err := a.workspaceRepo.GetMember(workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, nil
+	}
+
+	var custom []string
+	if member.CustomRoleID != nil {
+		role, err := a.roleRepo.FindByID(*member.CustomRoleID)
+		if err != nil {
+			return nil, err
+		}
+		if role != nil && role.WorkspaceID == workspaceID {
+			custom = role.Permissions
+		}
+	}
+	return NewGrant(member, custom), nil
+}
+
+// Can reports whether the user holds p in the workspace
+func (a *Authorizer) Can(workspaceID, userID uuid.UUID, p Permission) (bool, error) {
+	grant, err := a.Grant(workspaceID, userID)
+	if err != nil {
+		return false, err
+	}
+	return grant.Has(p), nil
+}
@@ -0,0 +1,50 @@
+package authz
+
+import (
+	"testing"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuiltinGrants(t *testing.T) {
+	owner := NewGrant(&models.WorkspaceMember{UserID: uuid.New(), Role: RoleOwner}, nil)
+	admin := NewGrant(&models.WorkspaceMember{UserID: uuid.New(), Role: RoleAdmin}, nil)
+	member := NewGrant(&models.WorkspaceMember{UserID: uuid.New(), Role: RoleMember}, nil)
+
+	for _, p := range All {
+		assert.True(t, owner.Has(p), p)
+	}
+	assert.False(t, admin.Has(ChannelDelete), "deleting channels stays owner-only")
+	assert.True(t, admin.Has(ChannelArchive))
+	assert.False(t, admin.Has(MemberManageRoles))
+	assert.True(t, member.Has(ChannelCreate))
+	assert.False(t, member.Has(ChannelArchive))
+	assert.True(t, owner.IsOwner())
+	assert.False(t, admin.IsOwner())
+
+	var nobody *Grant
+	assert.False(t, nobody.Has(ChannelCreate))
+	assert.False(t, nobody.IsOwner())
+}
+
+func TestCustomRoleGrant(t *testing.T) {
+	member := &models.WorkspaceMember{UserID: uuid.New(), Role: RoleMember}
+	grant := NewGrant(member, []string{string(ChannelArchive), string(MemberManageRoles), string(ChannelDelete), "made.up"})
+
+	assert.True(t, grant.Has(ChannelCreate), "custom roles add to the built-in role")
+	assert.True(t, grant.Has(ChannelArchive))
+	assert.False(t, grant.Has(MemberManageRoles), "custom roles can't grant role management")
+	assert.False(t, grant.Has(ChannelDelete), "custom roles can't grant channel deletion")
+	assert.False(t, grant.Has(Permission("made.up")))
+}
+
+func TestGuestGrant(t *testing.T) {
+	guest := &models.WorkspaceMember{UserID: uuid.New(), Role: RoleMultiChannelGuest}
+	grant := NewGrant(guest, []string{string(ChannelCreate), string(InviteCreate)})
+
+	assert.True(t, grant.IsGuest())
+	assert.False(t, grant.Has(ChannelCreate), "guests don't gain permissions from custom roles")
+	assert.False(t, grant.Has(InviteCreate))
+}
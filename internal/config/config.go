@@ -56,6 +56,31 @@ type Config struct {
 	// Cookie
 	CookieSecure   bool
 	CookieSameSite string
+
+	// Mail
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	MailFrom     string
+	AppURL       string
+
+	// Email digest
+	DigestInterval  time.Duration
+	DigestIdleAfter time.Duration
+
+	// Web Push
+	VAPIDPublicKey  string
+	VAPIDPrivateKey string
+	VAPIDSubject    string
+
+	// Mobile push
+	APNsKeyFile        string
+	APNsKeyID          string
+	APNsTeamID         string
+	APNsTopic          string
+	APNsProduction     bool
+	FCMCredentialsFile string
 }
 
 func Load() (*Config, error) {
@@ -95,6 +120,27 @@ func Load() (*Config, error) {
 
 		CookieSecure:   getEnv("COOKIE_SECURE", "false") == "true",
 		CookieSameSite: getEnv("COOKIE_SAME_SITE", "lax"),
+
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getEnv("SMTP_PORT", "587"),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		MailFrom:     getEnv("MAIL_FROM", "no-reply@localhost"),
+		AppURL:       getEnv("APP_URL", "http://localhost:3000"),
+
+		DigestInterval:  parseDuration(getEnv("DIGEST_INTERVAL", "15m")),
+		DigestIdleAfter: parseDuration(getEnv("DIGEST_IDLE_AFTER", "1h")),
+
+		VAPIDPublicKey:  getEnv("VAPID_PUBLIC_KEY", ""),
+		VAPIDPrivateKey: getEnv("VAPID_PRIVATE_KEY", ""),
+		VAPIDSubject:    getEnv("VAPID_SUBJECT", "mailto:admin@localhost"),
+
+		APNsKeyFile:        getEnv("APNS_KEY_FILE", ""),
+		APNsKeyID:          getEnv("APNS_KEY_ID", ""),
+		APNsTeamID:         getEnv("APNS_TEAM_ID", ""),
+		APNsTopic:          getEnv("APNS_TOPIC", ""),
+		APNsProduction:     getEnv("APNS_PRODUCTION", "false") == "true",
+		FCMCredentialsFile: getEnv("FCM_CREDENTIALS_FILE", ""),
 	}
 
 	// Parse allowed origins
@@ -2,7 +2,9 @@ package handler
 
 import (
 	"net/http"
+	"strconv"
 
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
 	"github.com/DoDuy2004/slack-clone-backend/internal/models/dto"
 	"github.com/DoDuy2004/slack-clone-backend/internal/service"
 	"github.com/gin-gonic/gin"
@@ -23,7 +25,7 @@ func (h *ChannelHandler) Create(c *gin.Context) {
 	userIDStr, _ := c.Get("user_id")
 	userID := userIDStr.(uuid.UUID)
 
-	workspaceIDStr := c.Param("workspace_id")
+	workspaceIDStr := c.Param("id")
 	workspaceID, err := uuid.Parse(workspaceIDStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
@@ -81,14 +83,15 @@ func (h *ChannelHandler) ListByWorkspace(c *gin.Context) {
 	userIDStr, _ := c.Get("user_id")
 	userID := userIDStr.(uuid.UUID)
 
-	workspaceIDStr := c.Param("workspace_id")
+	workspaceIDStr := c.Param("id")
 	workspaceID, err := uuid.Parse(workspaceIDStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
 		return
 	}
 
-	channels, err := h.channelService.ListWorkspaceChannels(workspaceID, userID)
+	includeArchived := c.Query("include_archived") == "true"
+	channels, err := h.channelService.ListWorkspaceChannels(workspaceID, userID, includeArchived)
 	if err != nil {
 		if err == service.ErrUnauthorized {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
@@ -101,6 +104,43 @@ func (h *ChannelHandler) ListByWorkspace(c *gin.Context) {
 	c.JSON(http.StatusOK, channels)
 }
 
+func (h *ChannelHandler) Browse(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	workspaceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	query := &dto.BrowseChannelsQuery{
+		Query:      c.Query("q"),
+		Membership: c.Query("membership"),
+		Archived:   c.Query("archived"),
+		Sort:       c.Query("sort"),
+		Cursor:     c.Query("cursor"),
+		Limit:      limit,
+	}
+
+	resp, err := h.channelService.BrowseChannels(workspaceID, userID, query)
+	if err != nil {
+		if err == service.ErrUnauthorized || err == service.ErrGuestRestricted {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrInvalidFilter || err == service.ErrInvalidCursor {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
 func (h *ChannelHandler) Update(c *gin.Context) {
 	userIDStr, _ := c.Get("user_id")
 	userID := userIDStr.(uuid.UUID)
@@ -124,10 +164,51 @@ func (h *ChannelHandler) Update(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
-		if err == service.ErrUnauthorized {
+		if err == service.ErrUnauthorized || err == service.ErrChannelArchived {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrPrivateDefault {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, channel)
+}
+
+func (h *ChannelHandler) Convert(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid channel ID"})
+		return
+	}
+
+	var req dto.ConvertChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	channel, err := h.channelService.ConvertChannel(userID, id, *req.IsPrivate)
+	if err != nil {
+		if err == service.ErrChannelNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrUnauthorized || err == service.ErrChannelArchived {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 			return
 		}
+		if err == service.ErrPrivateDefault {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
 		return
 	}
@@ -135,6 +216,106 @@ func (h *ChannelHandler) Update(c *gin.Context) {
 	c.JSON(http.StatusOK, channel)
 }
 
+func (h *ChannelHandler) SetTopic(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid channel ID"})
+		return
+	}
+
+	var req dto.SetChannelTopicRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	channel, err := h.channelService.SetTopic(userID, id, req.Topic)
+	if err != nil {
+		if err == service.ErrChannelNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrNotChannelMember || err == service.ErrChannelArchived {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, channel)
+}
+
+func (h *ChannelHandler) SetPostingPolicy(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid channel ID"})
+		return
+	}
+
+	var req dto.SetPostingPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	channel, err := h.channelService.SetPostingPolicy(userID, id, &req)
+	if err != nil {
+		if err == service.ErrChannelNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrUnauthorized || err == service.ErrChannelArchived {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrNotWorkspaceMember {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, channel)
+}
+
+func (h *ChannelHandler) ListChanges(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid channel ID"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	changes, err := h.channelService.ListChanges(userID, id, limit, offset)
+	if err != nil {
+		if err == service.ErrChannelNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrUnauthorized {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, changes)
+}
+
 func (h *ChannelHandler) Delete(c *gin.Context) {
 	userIDStr, _ := c.Get("user_id")
 	userID := userIDStr.(uuid.UUID)
@@ -146,7 +327,13 @@ func (h *ChannelHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	err = h.channelService.DeleteChannel(userID, id)
+	var req dto.DeleteChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.channelService.DeleteChannel(userID, id, req.ConfirmName)
 	if err != nil {
 		if err == service.ErrChannelNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
@@ -156,9 +343,165 @@ func (h *ChannelHandler) Delete(c *gin.Context) {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 			return
 		}
+		if err == service.ErrConfirmationFailed {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Channel deleted successfully"})
 }
+
+func (h *ChannelHandler) Archive(c *gin.Context) {
+	h.handleArchive(c, h.channelService.ArchiveChannel)
+}
+
+func (h *ChannelHandler) Unarchive(c *gin.Context) {
+	h.handleArchive(c, h.channelService.UnarchiveChannel)
+}
+
+func (h *ChannelHandler) handleArchive(c *gin.Context, action func(userID, channelID uuid.UUID) (*models.Channel, error)) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid channel ID"})
+		return
+	}
+
+	channel, err := action(userID, id)
+	if err != nil {
+		if err == service.ErrChannelNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrUnauthorized {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, channel)
+}
+
+func (h *ChannelHandler) Join(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid channel ID"})
+		return
+	}
+
+	channel, err := h.channelService.JoinChannel(userID, id)
+	if err != nil {
+		respondMembershipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, channel)
+}
+
+func (h *ChannelHandler) Leave(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid channel ID"})
+		return
+	}
+
+	if err := h.channelService.LeaveChannel(userID, id); err != nil {
+		respondMembershipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Left channel successfully"})
+}
+
+func (h *ChannelHandler) ListMembers(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid channel ID"})
+		return
+	}
+
+	members, err := h.channelService.ListMembers(userID, id)
+	if err != nil {
+		respondMembershipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+func (h *ChannelHandler) AddMembers(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid channel ID"})
+		return
+	}
+
+	var req dto.AddChannelMembersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	members, err := h.channelService.AddMembers(userID, id, req.UserIDs)
+	if err != nil {
+		respondMembershipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+func (h *ChannelHandler) RemoveMember(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid channel ID"})
+		return
+	}
+	memberID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := h.channelService.RemoveMember(userID, id, memberID); err != nil {
+		respondMembershipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed successfully"})
+}
+
+func respondMembershipError(c *gin.Context, err error) {
+	switch err {
+	case service.ErrChannelNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case service.ErrUnauthorized, service.ErrPrivateChannel, service.ErrChannelArchived, service.ErrGuestRestricted:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case service.ErrNotChannelMember, service.ErrNotWorkspaceMember, service.ErrGuestChannelLimit:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
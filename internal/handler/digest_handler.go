@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/models/dto"
+	"github.com/DoDuy2004/slack-clone-backend/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type DigestHandler struct {
+	digestService service.DigestService
+}
+
+func NewDigestHandler(digestService service.DigestService) *DigestHandler {
+	return &DigestHandler{digestService: digestService}
+}
+
+func (h *DigestHandler) GetSettings(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	settings, err := h.digestService.GetSettings(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+func (h *DigestHandler) UpdateSettings(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	var req dto.UpdateDigestSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings, err := h.digestService.UpdateSettings(userID, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
@@ -23,7 +23,7 @@ func (h *DMHandler) GetOrCreate(c *gin.Context) {
 	userIDStr, _ := c.Get("user_id")
 	userID := userIDStr.(uuid.UUID)
 
-	workspaceIDStr := c.Param("workspace_id")
+	workspaceIDStr := c.Param("id")
 	workspaceID, err := uuid.Parse(workspaceIDStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
@@ -38,6 +38,10 @@ func (h *DMHandler) GetOrCreate(c *gin.Context) {
 
 	dm, err := h.dmService.CreateDM(userID, workspaceID, req.ParticipantIDs)
 	if err != nil {
+		if err == service.ErrGuestDMForbidden {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -49,7 +53,7 @@ func (h *DMHandler) List(c *gin.Context) {
 	userIDStr, _ := c.Get("user_id")
 	userID := userIDStr.(uuid.UUID)
 
-	workspaceIDStr := c.Param("workspace_id")
+	workspaceIDStr := c.Param("id")
 	workspaceID, err := uuid.Parse(workspaceIDStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
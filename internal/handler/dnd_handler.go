@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/models/dto"
+	"github.com/DoDuy2004/slack-clone-backend/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type DNDHandler struct {
+	dndService service.DNDService
+}
+
+func NewDNDHandler(dndService service.DNDService) *DNDHandler {
+	return &DNDHandler{dndService: dndService}
+}
+
+func (h *DNDHandler) Get(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	settings, err := h.dndService.GetDND(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+func (h *DNDHandler) Update(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	var req dto.UpdateDNDRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings, err := h.dndService.UpdateDND(userID, &req)
+	if err != nil {
+		if err == service.ErrInvalidTimezone || err == service.ErrInvalidDNDSchedule {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+func (h *DNDHandler) Snooze(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	var req dto.SnoozeDNDRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings, err := h.dndService.Snooze(userID, time.Duration(req.Minutes)*time.Minute)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+func (h *DNDHandler) EndSnooze(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	settings, err := h.dndService.EndSnooze(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
@@ -1,9 +1,10 @@
 package handler
 
 import (
+	"io"
 	"net/http"
-	"time"
 
+	"github.com/DoDuy2004/slack-clone-backend/internal/models/dto"
 	"github.com/DoDuy2004/slack-clone-backend/internal/service"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -17,11 +18,6 @@ func NewInviteHandler(inviteService service.InviteService) *InviteHandler {
 	return &InviteHandler{inviteService: inviteService}
 }
 
-type CreateInviteRequest struct {
-	ExpiresAt *time.Time `json:"expires_at"`
-	MaxUses   *int       `json:"max_uses"`
-}
-
 func (h *InviteHandler) Create(c *gin.Context) {
 	userIDStr, _ := c.Get("user_id")
 	userID := userIDStr.(uuid.UUID)
@@ -33,18 +29,25 @@ func (h *InviteHandler) Create(c *gin.Context) {
 		return
 	}
 
-	var req CreateInviteRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		// Optional fields, so we can ignore error if body is empty or malformed but we want JSON
+	// Every field is optional, so an empty body is fine
+	var req dto.CreateInviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	invite, err := h.inviteService.GenerateInvite(userID, workspaceID, req.ExpiresAt, req.MaxUses)
+	invite, err := h.inviteService.GenerateInvite(userID, workspaceID, &req)
 	if err != nil {
-		if err == service.ErrUnauthorized {
+		switch err {
+		case service.ErrUnauthorized, service.ErrInvitePrivateChannel:
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
-			return
+		case service.ErrChannelNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case service.ErrGuestInviteChannels, service.ErrGuestExpiry, service.ErrMemberInviteGuestFields, service.ErrChannelArchived:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -63,9 +66,96 @@ func (h *InviteHandler) Join(c *gin.Context) {
 
 	workspace, err := h.inviteService.JoinWorkspace(userID, code)
 	if err != nil {
+		if err == service.ErrInviteEmailMismatch {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, workspace)
 }
+
+func (h *InviteHandler) List(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	workspaceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	invites, err := h.inviteService.ListInvites(userID, workspaceID)
+	if err != nil {
+		if err == service.ErrUnauthorized {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, invites)
+}
+
+func (h *InviteHandler) Revoke(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	workspaceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	inviteID, err := uuid.Parse(c.Param("invite_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invite ID"})
+		return
+	}
+
+	if err := h.inviteService.RevokeInvite(userID, workspaceID, inviteID); err != nil {
+		switch err {
+		case service.ErrUnauthorized:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case service.ErrInviteNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invite revoked"})
+}
+
+func (h *InviteHandler) ListRedemptions(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	workspaceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	inviteID, err := uuid.Parse(c.Param("invite_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invite ID"})
+		return
+	}
+
+	redemptions, err := h.inviteService.ListRedemptions(userID, workspaceID, inviteID)
+	if err != nil {
+		if err == service.ErrUnauthorized {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, redemptions)
+}
@@ -14,12 +14,14 @@ import (
 
 type MessageHandler struct {
 	messageService service.MessageService
+	threadService  service.ThreadService
 	hub            *websocket.Hub
 }
 
-func NewMessageHandler(messageService service.MessageService, hub *websocket.Hub) *MessageHandler {
+func NewMessageHandler(messageService service.MessageService, threadService service.ThreadService, hub *websocket.Hub) *MessageHandler {
 	return &MessageHandler{
 		messageService: messageService,
+		threadService:  threadService,
 		hub:            hub,
 	}
 }
@@ -41,9 +43,10 @@ func (h *MessageHandler) SendChannel(c *gin.Context) {
 		return
 	}
 
-	message, err := h.messageService.SendChannelMessage(userID, channelID, req.Content, req.ParentMessageID, req.AttachmentIDs)
+	message, err := h.messageService.SendChannelMessage(userID, channelID, req.Content, req.ParentMessageID, req.AttachmentIDs, req.AlsoSendToChannel, req.Urgent)
 	if err != nil {
-		if err == service.ErrUnauthorized {
+		if err == service.ErrUnauthorized || err == service.ErrBroadcastMentionForbidden || err == service.ErrUrgentOverrideForbidden || err == service.ErrChannelArchived ||
+			err == service.ErrPostingRestricted || err == service.ErrReplyRestricted {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 			return
 		}
@@ -58,6 +61,9 @@ func (h *MessageHandler) SendChannel(c *gin.Context) {
 		Payload:   payload,
 		ChannelID: message.ChannelID,
 	})
+	if message.ParentMessageID != nil {
+		h.threadService.NotifyReply(message)
+	}
 
 	c.JSON(http.StatusCreated, message)
 }
@@ -79,9 +85,9 @@ func (h *MessageHandler) SendDM(c *gin.Context) {
 		return
 	}
 
-	message, err := h.messageService.SendDMMessage(userID, dmID, req.Content, req.ParentMessageID, req.AttachmentIDs)
+	message, err := h.messageService.SendDMMessage(userID, dmID, req.Content, req.ParentMessageID, req.AttachmentIDs, req.AlsoSendToChannel, req.Urgent)
 	if err != nil {
-		if err == service.ErrUnauthorized {
+		if err == service.ErrUnauthorized || err == service.ErrBroadcastMentionForbidden || err == service.ErrUrgentOverrideForbidden {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 			return
 		}
@@ -96,6 +102,9 @@ func (h *MessageHandler) SendDM(c *gin.Context) {
 		Payload: payload,
 		DMID:    message.DMID,
 	})
+	if message.ParentMessageID != nil {
+		h.threadService.NotifyReply(message)
+	}
 
 	c.JSON(http.StatusCreated, message)
 }
@@ -205,7 +214,7 @@ func (h *MessageHandler) Update(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
-		if err == service.ErrUnauthorized {
+		if err == service.ErrUnauthorized || err == service.ErrBroadcastMentionForbidden || err == service.ErrChannelArchived || err == service.ErrSystemMessage {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 			return
 		}
@@ -241,7 +250,7 @@ func (h *MessageHandler) Delete(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
-		if err == service.ErrUnauthorized {
+		if err == service.ErrUnauthorized || err == service.ErrChannelArchived || err == service.ErrSystemMessage {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 			return
 		}
@@ -0,0 +1,239 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/models/dto"
+	"github.com/DoDuy2004/slack-clone-backend/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type NotificationHandler struct {
+	notificationService service.NotificationService
+}
+
+func NewNotificationHandler(notificationService service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{notificationService: notificationService}
+}
+
+func (h *NotificationHandler) List(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	workspaceID, ok := parseWorkspaceFilter(c)
+	if !ok {
+		return
+	}
+
+	unreadOnly := c.Query("unread") == "true"
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	resp, err := h.notificationService.ListNotifications(userID, workspaceID, unreadOnly, c.Query("cursor"), limit)
+	if err != nil {
+		if err == service.ErrInvalidCursor {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *NotificationHandler) UnreadCount(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	workspaceID, ok := parseWorkspaceFilter(c)
+	if !ok {
+		return
+	}
+
+	count, err := h.notificationService.UnreadCount(userID, workspaceID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"unread_count": count})
+}
+
+func (h *NotificationHandler) MarkAsRead(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification ID"})
+		return
+	}
+
+	if err := h.notificationService.MarkAsRead(userID, id); err != nil {
+		if err == service.ErrNotificationNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Notification marked as read"})
+}
+
+func (h *NotificationHandler) MarkAllAsRead(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	workspaceID, ok := parseWorkspaceFilter(c)
+	if !ok {
+		return
+	}
+
+	if err := h.notificationService.MarkAllAsRead(userID, workspaceID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Notifications marked as read"})
+}
+
+func (h *NotificationHandler) GetChannelPreference(c *gin.Context) {
+	h.handlePreference(c, false, false)
+}
+
+func (h *NotificationHandler) UpdateChannelPreference(c *gin.Context) {
+	h.handlePreference(c, false, true)
+}
+
+func (h *NotificationHandler) GetDMPreference(c *gin.Context) {
+	h.handlePreference(c, true, false)
+}
+
+func (h *NotificationHandler) UpdateDMPreference(c *gin.Context) {
+	h.handlePreference(c, true, true)
+}
+
+func (h *NotificationHandler) handlePreference(c *gin.Context, isDM bool, update bool) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		if isDM {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid DM ID"})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid channel ID"})
+		}
+		return
+	}
+
+	var channelID, dmID *uuid.UUID
+	if isDM {
+		dmID = &id
+	} else {
+		channelID = &id
+	}
+
+	var req dto.UpdateNotificationPreferenceRequest
+	if update {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var pref interface{}
+	if update {
+		pref, err = h.notificationService.UpdatePreference(userID, channelID, dmID, &req)
+	} else {
+		pref, err = h.notificationService.GetPreference(userID, channelID, dmID)
+	}
+	if err != nil {
+		if err == service.ErrChannelNotFound || err == service.ErrDMNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrUnauthorized {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, pref)
+}
+
+func (h *NotificationHandler) GetSettings(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	workspaceIDStr := c.Param("id")
+	workspaceID, err := uuid.Parse(workspaceIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	settings, err := h.notificationService.GetUserSettings(userID, workspaceID)
+	if err != nil {
+		if err == service.ErrUnauthorized {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+func (h *NotificationHandler) UpdateSettings(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	workspaceIDStr := c.Param("id")
+	workspaceID, err := uuid.Parse(workspaceIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	var req dto.UpdateNotificationSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings, err := h.notificationService.UpdateUserSettings(userID, workspaceID, &req)
+	if err != nil {
+		if err == service.ErrUnauthorized {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// parseWorkspaceFilter reads the optional workspace_id query parameter.
+func parseWorkspaceFilter(c *gin.Context) (*uuid.UUID, bool) {
+	workspaceIDStr := c.Query("workspace_id")
+	if workspaceIDStr == "" {
+		return nil, true
+	}
+
+	workspaceID, err := uuid.Parse(workspaceIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return nil, false
+	}
+	return &workspaceID, true
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/models/dto"
+	"github.com/DoDuy2004/slack-clone-backend/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type PushHandler struct {
+	pushService service.PushService
+}
+
+func NewPushHandler(pushService service.PushService) *PushHandler {
+	return &PushHandler{pushService: pushService}
+}
+
+func (h *PushHandler) VAPIDPublicKey(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"public_key": h.pushService.VAPIDPublicKey()})
+}
+
+func (h *PushHandler) Subscribe(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	var req dto.PushSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sub, err := h.pushService.Subscribe(userID, &req, c.Request.UserAgent())
+	if err != nil {
+		if err == service.ErrInvalidPushSubscription {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, sub)
+}
+
+func (h *PushHandler) Unsubscribe(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	var req dto.DeletePushSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.pushService.Unsubscribe(userID, req.Endpoint); err != nil {
+		if err == service.ErrPushSubscriptionNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Push subscription removed"})
+}
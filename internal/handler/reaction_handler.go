@@ -49,7 +49,7 @@ func (h *ReactionHandler) Add(c *gin.Context) {
 			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 			return
 		}
-		if err == service.ErrUnauthorized {
+		if err == service.ErrUnauthorized || err == service.ErrChannelArchived {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 			return
 		}
@@ -82,7 +82,7 @@ func (h *ReactionHandler) Remove(c *gin.Context) {
 
 	message, err := h.reactionService.RemoveReaction(userID, messageID, emoji)
 	if err != nil {
-		if err == service.ErrUnauthorized {
+		if err == service.ErrUnauthorized || err == service.ErrChannelArchived {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 			return
 		}
@@ -39,7 +39,7 @@ func (h *SearchHandler) SearchInWorkspace(c *gin.Context) {
 
 	results, err := h.searchService.SearchMessages(userID, workspaceID, query, limit, offset)
 	if err != nil {
-		if err == service.ErrUnauthorized {
+		if err == service.ErrUnauthorized || err == service.ErrGuestRestricted {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 			return
 		}
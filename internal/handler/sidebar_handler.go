@@ -0,0 +1,245 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/models/dto"
+	"github.com/DoDuy2004/slack-clone-backend/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type SidebarHandler struct {
+	sidebarService service.SidebarService
+}
+
+func NewSidebarHandler(sidebarService service.SidebarService) *SidebarHandler {
+	return &SidebarHandler{sidebarService: sidebarService}
+}
+
+func (h *SidebarHandler) Get(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	workspaceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	sidebar, err := h.sidebarService.GetSidebar(userID, workspaceID)
+	if err != nil {
+		respondSidebarError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, sidebar)
+}
+
+func (h *SidebarHandler) CreateSection(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	workspaceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	var req dto.CreateSidebarSectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sidebar, err := h.sidebarService.CreateSection(userID, workspaceID, &req)
+	if err != nil {
+		respondSidebarError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, sidebar)
+}
+
+func (h *SidebarHandler) ReorderSections(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	workspaceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	var req dto.ReorderSidebarSectionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sidebar, err := h.sidebarService.ReorderSections(userID, workspaceID, &req)
+	if err != nil {
+		respondSidebarError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, sidebar)
+}
+
+func (h *SidebarHandler) UpdateSection(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	sectionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid section ID"})
+		return
+	}
+
+	var req dto.UpdateSidebarSectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sidebar, err := h.sidebarService.UpdateSection(userID, sectionID, &req)
+	if err != nil {
+		respondSidebarError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, sidebar)
+}
+
+func (h *SidebarHandler) DeleteSection(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	sectionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid section ID"})
+		return
+	}
+
+	sidebar, err := h.sidebarService.DeleteSection(userID, sectionID)
+	if err != nil {
+		respondSidebarError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, sidebar)
+}
+
+func (h *SidebarHandler) PlaceItem(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	sectionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid section ID"})
+		return
+	}
+
+	var req dto.SidebarItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sidebar, err := h.sidebarService.PlaceItem(userID, sectionID, &req)
+	if err != nil {
+		respondSidebarError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, sidebar)
+}
+
+func (h *SidebarHandler) RemoveItem(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	sectionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid section ID"})
+		return
+	}
+
+	itemID, err := uuid.Parse(c.Param("item_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid item ID"})
+		return
+	}
+
+	sidebar, err := h.sidebarService.RemoveItem(userID, sectionID, itemID)
+	if err != nil {
+		respondSidebarError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, sidebar)
+}
+
+func (h *SidebarHandler) Star(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	workspaceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	var req dto.StarConversationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sidebar, err := h.sidebarService.Star(userID, workspaceID, &req)
+	if err != nil {
+		respondSidebarError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, sidebar)
+}
+
+func (h *SidebarHandler) Unstar(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	workspaceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	var req dto.StarConversationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sidebar, err := h.sidebarService.Unstar(userID, workspaceID, &req)
+	if err != nil {
+		respondSidebarError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, sidebar)
+}
+
+func respondSidebarError(c *gin.Context, err error) {
+	switch err {
+	case service.ErrSidebarSectionNotFound, service.ErrSidebarItemNotFound, service.ErrStarNotFound,
+		service.ErrChannelNotFound, service.ErrDMNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case service.ErrInvalidConversation:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case service.ErrUnauthorized:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ThreadHandler struct {
+	threadService service.ThreadService
+}
+
+func NewThreadHandler(threadService service.ThreadService) *ThreadHandler {
+	return &ThreadHandler{threadService: threadService}
+}
+
+func (h *ThreadHandler) Follow(c *gin.Context) {
+	h.handleThreadAction(c, h.threadService.FollowThread)
+}
+
+func (h *ThreadHandler) Unfollow(c *gin.Context) {
+	h.handleThreadAction(c, h.threadService.UnfollowThread)
+}
+
+func (h *ThreadHandler) MarkAsRead(c *gin.Context) {
+	h.handleThreadAction(c, h.threadService.MarkThreadAsRead)
+}
+
+func (h *ThreadHandler) handleThreadAction(c *gin.Context, action func(userID, threadID uuid.UUID) error) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	threadIDStr := c.Param("id")
+	threadID, err := uuid.Parse(threadIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message ID"})
+		return
+	}
+
+	if err := action(userID, threadID); err != nil {
+		if err == service.ErrMessageNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrInvalidThread {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrUnauthorized {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+func (h *ThreadHandler) Inbox(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	workspaceIDStr := c.Param("id")
+	workspaceID, err := uuid.Parse(workspaceIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	unreadOnly := c.Query("unread") == "true"
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	threads, err := h.threadService.ListThreadInbox(userID, workspaceID, unreadOnly, limit, offset)
+	if err != nil {
+		if err == service.ErrUnauthorized {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, threads)
+}
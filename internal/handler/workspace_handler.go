@@ -2,6 +2,7 @@ package handler
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/DoDuy2004/slack-clone-backend/internal/models/dto"
 	"github.com/DoDuy2004/slack-clone-backend/internal/service"
@@ -11,11 +12,13 @@ import (
 
 type WorkspaceHandler struct {
 	workspaceService service.WorkspaceService
+	teardownService  service.TeardownService
 }
 
-func NewWorkspaceHandler(workspaceService service.WorkspaceService) *WorkspaceHandler {
+func NewWorkspaceHandler(workspaceService service.WorkspaceService, teardownService service.TeardownService) *WorkspaceHandler {
 	return &WorkspaceHandler{
 		workspaceService: workspaceService,
+		teardownService:  teardownService,
 	}
 }
 
@@ -121,12 +124,306 @@ func (h *WorkspaceHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	err = h.workspaceService.DeleteWorkspace(userID, id)
+	ws, err := h.workspaceService.DeleteWorkspace(userID, id)
 	if err != nil {
-		if err == service.ErrWorkspaceNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondDeletionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ws)
+}
+
+func (h *WorkspaceHandler) Restore(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	ws, err := h.workspaceService.RestoreWorkspace(userID, id)
+	if err != nil {
+		respondDeletionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ws)
+}
+
+func (h *WorkspaceHandler) ListDeleted(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	workspaces, err := h.workspaceService.ListDeletedWorkspaces(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, workspaces)
+}
+
+// GetDeletion reports a deleted workspace's restore window and teardown
+// progress
+func (h *WorkspaceHandler) GetDeletion(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	deletion, err := h.teardownService.GetDeletion(userID, id)
+	if err != nil {
+		respondDeletionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, deletion)
+}
+
+func respondDeletionError(c *gin.Context, err error) {
+	switch err {
+	case service.ErrWorkspaceNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case service.ErrUnauthorized:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case service.ErrNotDeleted, service.ErrRestoreExpired:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
+
+func (h *WorkspaceHandler) GetSettings(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	settings, err := h.workspaceService.GetSettings(userID, id)
+	if err != nil {
+		if err == service.ErrUnauthorized {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+func (h *WorkspaceHandler) UpdateSettings(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	var req dto.UpdateWorkspaceSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings, err := h.workspaceService.UpdateSettings(userID, id, &req)
+	if err != nil {
+		if err == service.ErrUnauthorized {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+func (h *WorkspaceHandler) ListMembers(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	members, err := h.workspaceService.ListMembers(userID, id)
+	if err != nil {
+		if err == service.ErrUnauthorized {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 			return
 		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+func (h *WorkspaceHandler) UpdateMemberRole(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	memberID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req dto.UpdateMemberRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	member, err := h.workspaceService.UpdateMemberRole(userID, id, memberID, req.Role)
+	if err != nil {
+		respondMemberError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, member)
+}
+
+func (h *WorkspaceHandler) RemoveMember(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	memberID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := h.workspaceService.RemoveMember(userID, id, memberID); err != nil {
+		respondMemberError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed successfully"})
+}
+
+func (h *WorkspaceHandler) SetGuestExpiry(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	guestID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req dto.SetGuestExpiryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	guest, err := h.workspaceService.SetGuestExpiry(userID, id, guestID, req.ExpiresAt)
+	if err != nil {
+		respondMemberError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, guest)
+}
+
+func respondMemberError(c *gin.Context, err error) {
+	switch err {
+	case service.ErrMemberNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case service.ErrNotGuest, service.ErrGuestExpiry:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case service.ErrUnauthorized, service.ErrOwnerImmutable:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
+
+func (h *WorkspaceHandler) TransferOwnership(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	var req dto.TransferOwnershipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	workspace, err := h.workspaceService.TransferOwnership(userID, id, &req)
+	if err != nil {
+		switch err {
+		case service.ErrWorkspaceNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case service.ErrUnauthorized, service.ErrIncorrectPassword:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case service.ErrTransferTarget, service.ErrTOTPUnsupported:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, workspace)
+}
+
+func (h *WorkspaceHandler) ListAuditLog(c *gin.Context) {
+	userIDStr, _ := c.Get("user_id")
+	userID := userIDStr.(uuid.UUID)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	entries, err := h.workspaceService.ListAuditLog(userID, id, limit, offset)
+	if err != nil {
 		if err == service.ErrUnauthorized {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 			return
@@ -135,5 +432,5 @@ func (h *WorkspaceHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Workspace deleted successfully"})
+	c.JSON(http.StatusOK, entries)
 }
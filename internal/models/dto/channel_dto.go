@@ -3,6 +3,7 @@ package dto
 import (
 	"time"
 
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
 	"github.com/google/uuid"
 )
 
@@ -15,7 +16,51 @@ type CreateChannelRequest struct {
 type UpdateChannelRequest struct {
 	Name        *string `json:"name,omitempty" binding:"omitempty,min=1,max=80"`
 	Description *string `json:"description,omitempty" binding:"omitempty,max=255"`
-	IsPrivate   *bool   `json:"is_private,omitempty"`
+	// IsDefault can only be changed by workspace admins and owners
+	IsDefault *bool `json:"is_default,omitempty"`
+}
+
+// ConvertChannelRequest makes a channel public or private
+type ConvertChannelRequest struct {
+	IsPrivate *bool `json:"is_private" binding:"required"`
+}
+
+// SetChannelTopicRequest sets the topic; an empty topic clears it
+type SetChannelTopicRequest struct {
+	Topic string `json:"topic" binding:"max=250"`
+}
+
+// SetPostingPolicyRequest sets who can post in a channel. UserIDs is the
+// allowlist and only applies to the allowlist policy; a missing reply policy
+// is left unchanged.
+type SetPostingPolicyRequest struct {
+	PostingPolicy string      `json:"posting_policy" binding:"required,oneof=everyone admins allowlist"`
+	ReplyPolicy   string      `json:"reply_policy,omitempty" binding:"omitempty,oneof=everyone admins allowlist"`
+	UserIDs       []uuid.UUID `json:"user_ids,omitempty" binding:"max=100"`
+}
+
+// DeleteChannelRequest confirms a permanent deletion by repeating the channel name
+type DeleteChannelRequest struct {
+	ConfirmName string `json:"confirm_name" binding:"required"`
+}
+
+type AddChannelMembersRequest struct {
+	UserIDs []uuid.UUID `json:"user_ids" binding:"required,min=1,max=100"`
+}
+
+// BrowseChannelsQuery holds the channel directory query parameters
+type BrowseChannelsQuery struct {
+	Query      string // matched against names and topics
+	Membership string // all (default), joined, not_joined
+	Archived   string // exclude (default), include, only
+	Sort       string // name (default), members, activity
+	Cursor     string
+	Limit      int
+}
+
+type ChannelDirectoryResponse struct {
+	Channels   []*models.ChannelDirectoryEntry `json:"channels"`
+	NextCursor string                          `json:"next_cursor,omitempty"`
 }
 
 type ChannelResponse struct {
@@ -0,0 +1,20 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateInviteRequest creates an invite link. Guest invites name the channels
+// the guest joins and, optionally, when their access ends; member invites may
+// name channels to join on top of the defaults. An invite with an email can
+// only be used once, by that address, and is mailed to it.
+type CreateInviteRequest struct {
+	ExpiresAt      *time.Time  `json:"expires_at"`
+	MaxUses        *int        `json:"max_uses"`
+	Email          *string     `json:"email" binding:"omitempty,email"`
+	Role           string      `json:"role" binding:"omitempty,oneof=member multi_channel_guest single_channel_guest"`
+	ChannelIDs     []uuid.UUID `json:"channel_ids"`
+	GuestExpiresAt *time.Time  `json:"guest_expires_at"`
+}
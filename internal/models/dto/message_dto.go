@@ -10,6 +10,12 @@ type CreateMessageRequest struct {
 	Content         string      `json:"content" binding:"required,min=1"`
 	ParentMessageID *uuid.UUID  `json:"parent_message_id,omitempty"`
 	AttachmentIDs   []uuid.UUID `json:"attachment_ids,omitempty"`
+
+	// For thread replies: also show the reply in the channel or DM
+	AlsoSendToChannel bool `json:"also_send_to_channel,omitempty"`
+
+	// Break through recipients' DND, if the workspace allows it
+	Urgent bool `json:"urgent,omitempty"`
 }
 
 type UpdateMessageRequest struct {
@@ -17,18 +23,21 @@ type UpdateMessageRequest struct {
 }
 
 type MessageResponse struct {
-	ID              uuid.UUID    `json:"id"`
-	Content         string       `json:"content"`
-	SenderID        *uuid.UUID   `json:"sender_id,omitempty"`
-	ChannelID       *uuid.UUID   `json:"channel_id,omitempty"`
-	DMID            *uuid.UUID   `json:"dm_id,omitempty"`
-	ParentMessageID *uuid.UUID   `json:"parent_message_id,omitempty"`
-	EditedAt        *time.Time   `json:"edited_at,omitempty"`
-	DeletedAt       *time.Time   `json:"deleted_at,omitempty"`
-	CreatedAt       time.Time    `json:"created_at"`
-	UpdatedAt       time.Time    `json:"updated_at"`
-	ReplyCount      int          `json:"reply_count"`
-	Sender          *UserSummary `json:"sender,omitempty"`
+	ID                uuid.UUID    `json:"id"`
+	Content           string       `json:"content"`
+	SenderID          *uuid.UUID   `json:"sender_id,omitempty"`
+	ChannelID         *uuid.UUID   `json:"channel_id,omitempty"`
+	DMID              *uuid.UUID   `json:"dm_id,omitempty"`
+	ParentMessageID   *uuid.UUID   `json:"parent_message_id,omitempty"`
+	EditedAt          *time.Time   `json:"edited_at,omitempty"`
+	DeletedAt         *time.Time   `json:"deleted_at,omitempty"`
+	CreatedAt         time.Time    `json:"created_at"`
+	UpdatedAt         time.Time    `json:"updated_at"`
+	ReplyCount        int          `json:"reply_count"`
+	LastReplyAt       *time.Time   `json:"last_reply_at,omitempty"`
+	ReplyUserIDs      []uuid.UUID  `json:"reply_user_ids,omitempty"`
+	AlsoSentToChannel bool         `json:"also_sent_to_channel,omitempty"`
+	Sender            *UserSummary `json:"sender,omitempty"`
 }
 
 type UserSummary struct {
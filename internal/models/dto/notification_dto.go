@@ -0,0 +1,48 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+)
+
+type NotificationListResponse struct {
+	Notifications []*models.Notification `json:"notifications"`
+	NextCursor    string                 `json:"next_cursor,omitempty"`
+	UnreadCount   int                    `json:"unread_count"`
+}
+
+type UpdateNotificationPreferenceRequest struct {
+	Level      *string    `json:"level,omitempty" binding:"omitempty,oneof=default all mentions nothing"`
+	Muted      *bool      `json:"muted,omitempty"`
+	MutedUntil *time.Time `json:"muted_until,omitempty"`
+}
+
+type UpdateNotificationSettingsRequest struct {
+	DefaultLevel *string  `json:"default_level,omitempty" binding:"omitempty,oneof=default all mentions nothing"`
+	Keywords     []string `json:"keywords,omitempty" binding:"omitempty,max=50,dive,min=1,max=100"`
+}
+
+// PushSubscriptionRequest mirrors the browser's PushSubscription.toJSON()
+type PushSubscriptionRequest struct {
+	Endpoint       string `json:"endpoint" binding:"required,url"`
+	ExpirationTime *int64 `json:"expirationTime,omitempty"` // milliseconds since epoch
+	Keys           struct {
+		P256dh string `json:"p256dh" binding:"required"`
+		Auth   string `json:"auth" binding:"required"`
+	} `json:"keys" binding:"required"`
+}
+
+type DeletePushSubscriptionRequest struct {
+	Endpoint string `json:"endpoint" binding:"required"`
+}
+
+type RegisterDeviceRequest struct {
+	Platform   string  `json:"platform" binding:"required,oneof=ios android"`
+	Token      string  `json:"token" binding:"required,max=4096"`
+	AppVersion *string `json:"app_version,omitempty" binding:"omitempty,max=50"`
+}
+
+type DeleteDeviceRequest struct {
+	Token string `json:"token" binding:"required"`
+}
@@ -0,0 +1,13 @@
+package dto
+
+import "time"
+
+type SaveMessageRequest struct {
+	RemindAt *time.Time `json:"remind_at,omitempty"`
+}
+
+type UpdateSavedItemRequest struct {
+	Status        *string    `json:"status,omitempty" binding:"omitempty,oneof=in_progress completed"`
+	RemindAt      *time.Time `json:"remind_at,omitempty"`
+	ClearReminder bool       `json:"clear_reminder,omitempty"`
+}
@@ -0,0 +1,40 @@
+package dto
+
+import (
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+	"github.com/google/uuid"
+)
+
+type CreateSidebarSectionRequest struct {
+	Name string `json:"name" binding:"required,min=1,max=80"`
+}
+
+type UpdateSidebarSectionRequest struct {
+	Name      *string `json:"name,omitempty" binding:"omitempty,min=1,max=80"`
+	Collapsed *bool   `json:"collapsed,omitempty"`
+}
+
+type ReorderSidebarSectionsRequest struct {
+	SectionIDs []uuid.UUID `json:"section_ids" binding:"required,min=1"`
+}
+
+// SidebarItemRequest places a conversation in a section; set exactly one of
+// ChannelID and DMID. A missing position appends it to the section.
+type SidebarItemRequest struct {
+	ChannelID *uuid.UUID `json:"channel_id,omitempty"`
+	DMID      *uuid.UUID `json:"dm_id,omitempty"`
+	Position  *int       `json:"position,omitempty" binding:"omitempty,min=0"`
+}
+
+// StarConversationRequest stars or unstars a channel or DM; set exactly one
+type StarConversationRequest struct {
+	ChannelID *uuid.UUID `json:"channel_id,omitempty"`
+	DMID      *uuid.UUID `json:"dm_id,omitempty"`
+}
+
+// SidebarResponse is the user's whole sidebar for a workspace
+type SidebarResponse struct {
+	WorkspaceID uuid.UUID                     `json:"workspace_id"`
+	Sections    []*models.SidebarSection      `json:"sections"`
+	Starred     []*models.StarredConversation `json:"starred"`
+}
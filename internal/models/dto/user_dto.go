@@ -1,7 +1,24 @@
 package dto
 
+import "github.com/DoDuy2004/slack-clone-backend/internal/models"
+
 type UpdateProfileRequest struct {
 	FullName      *string `json:"full_name"`
 	AvatarURL     *string `json:"avatar_url"`
 	StatusMessage *string `json:"status_message"`
 }
+
+type UpdateDNDRequest struct {
+	Timezone        *string            `json:"timezone,omitempty"`
+	ScheduleEnabled *bool              `json:"schedule_enabled,omitempty"`
+	Schedule        []models.DNDWindow `json:"schedule,omitempty"`
+}
+
+type SnoozeDNDRequest struct {
+	Minutes int `json:"minutes" binding:"required,min=1,max=10080"`
+}
+
+type UpdateDigestSettingsRequest struct {
+	Enabled   *bool   `json:"enabled,omitempty"`
+	Frequency *string `json:"frequency,omitempty" binding:"omitempty,oneof=hourly daily weekly"`
+}
@@ -3,6 +3,7 @@ package dto
 import (
 	"time"
 
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
 	"github.com/google/uuid"
 )
 
@@ -17,6 +18,27 @@ type UpdateWorkspaceRequest struct {
 	IconURL *string `json:"icon_url,omitempty"`
 }
 
+type UpdateWorkspaceSettingsRequest struct {
+	BroadcastMentionPolicy   *string `json:"broadcast_mention_policy,omitempty" binding:"omitempty,oneof=everyone admins"`
+	DefaultNotificationLevel *string `json:"default_notification_level,omitempty" binding:"omitempty,oneof=all mentions nothing"`
+	UrgentOverridePolicy     *string `json:"urgent_override_policy,omitempty" binding:"omitempty,oneof=disabled admins everyone"`
+}
+
+// UpdateMemberRoleRequest makes a member an admin or back; ownership is
+// never changed this way
+type UpdateMemberRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=admin member"`
+}
+
+// TransferOwnershipRequest hands the workspace to an admin; the current owner
+// confirms with their password. Accounts have no two-factor setup, so a
+// totp_code is refused rather than taken in place of the password.
+type TransferOwnershipRequest struct {
+	NewOwnerID uuid.UUID `json:"new_owner_id" binding:"required"`
+	Password   string    `json:"password"`
+	TOTPCode   string    `json:"totp_code,omitempty"`
+}
+
 type WorkspaceResponse struct {
 	ID        uuid.UUID `json:"id"`
 	Name      string    `json:"name"`
@@ -28,12 +50,61 @@ type WorkspaceResponse struct {
 }
 
 type WorkspaceMemberResponse struct {
-	ID        uuid.UUID `json:"id"`
-	UserID    uuid.UUID `json:"user_id"`
-	Email     string    `json:"email"`
-	Username  string    `json:"username"`
-	FullName  *string   `json:"full_name,omitempty"`
-	AvatarURL *string   `json:"avatar_url,omitempty"`
-	Role      string    `json:"role"`
-	JoinedAt  time.Time `json:"joined_at"`
+	ID             uuid.UUID  `json:"id"`
+	UserID         uuid.UUID  `json:"user_id"`
+	Email          string     `json:"email"`
+	Username       string     `json:"username"`
+	FullName       *string    `json:"full_name,omitempty"`
+	AvatarURL      *string    `json:"avatar_url,omitempty"`
+	Role           string     `json:"role"`
+	CustomRoleID   *uuid.UUID `json:"custom_role_id,omitempty"`
+	GuestExpiresAt *time.Time `json:"guest_expires_at,omitempty"`
+	JoinedAt       time.Time  `json:"joined_at"`
+}
+
+// SetGuestExpiryRequest sets when a guest's access ends; null keeps it open
+type SetGuestExpiryRequest struct {
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// CreateRoleRequest defines a custom role. Its permissions are granted on top
+// of each holder's built-in role.
+type CreateRoleRequest struct {
+	Name        string   `json:"name" binding:"required,max=50"`
+	Description *string  `json:"description"`
+	Permissions []string `json:"permissions" binding:"required"`
+}
+
+// UpdateRoleRequest changes a custom role; nil fields are left alone
+type UpdateRoleRequest struct {
+	Name        *string  `json:"name" binding:"omitempty,max=50"`
+	Description *string  `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// AssignRoleRequest gives a member a custom role; a null role_id takes it away
+type AssignRoleRequest struct {
+	RoleID *uuid.UUID `json:"role_id"`
+}
+
+type BuiltinRoleResponse struct {
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+}
+
+// WorkspaceRolesResponse lists the built-in and custom roles, and the
+// permissions a custom role may grant
+type WorkspaceRolesResponse struct {
+	Builtin     []*BuiltinRoleResponse  `json:"builtin"`
+	Custom      []*models.WorkspaceRole `json:"custom"`
+	Permissions []string                `json:"permissions"`
+}
+
+// WorkspaceDeletionResponse shows the owner a deleted workspace's restore
+// window and, once it has started, its teardown progress
+type WorkspaceDeletionResponse struct {
+	WorkspaceID uuid.UUID                 `json:"workspace_id"`
+	DeletedAt   *time.Time                `json:"deleted_at,omitempty"`
+	PurgeAfter  *time.Time                `json:"purge_after,omitempty"`
+	Teardown    *models.WorkspaceTeardown `json:"teardown,omitempty"`
 }
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -28,28 +29,84 @@ type Workspace struct {
 	OwnerID   uuid.UUID `json:"owner_id" db:"owner_id"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+
+	DeletedAt  *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	PurgeAfter *time.Time `json:"purge_after,omitempty" db:"purge_after"` // end of the restore window
+}
+
+// WorkspaceTeardown tracks the background removal of a deleted workspace
+type WorkspaceTeardown struct {
+	WorkspaceID   uuid.UUID  `json:"workspace_id" db:"workspace_id"`
+	WorkspaceName string     `json:"workspace_name" db:"workspace_name"`
+	OwnerID       *uuid.UUID `json:"owner_id,omitempty" db:"owner_id"`
+	Status        string     `json:"status" db:"status"` // running, completed
+	Step          string     `json:"step" db:"step"`     // the kind of rows being deleted
+	RowsDeleted   int64      `json:"rows_deleted" db:"rows_deleted"`
+	FilesDeleted  int        `json:"files_deleted" db:"files_deleted"`
+	FilesFailed   int        `json:"files_failed" db:"files_failed"`
+	LastError     *string    `json:"last_error,omitempty" db:"last_error"`
+	StartedAt     time.Time  `json:"started_at" db:"started_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+	CompletedAt   *time.Time `json:"completed_at,omitempty" db:"completed_at"`
 }
 
 type WorkspaceMember struct {
-	ID          uuid.UUID `json:"id" db:"id"`
-	WorkspaceID uuid.UUID `json:"workspace_id" db:"workspace_id"`
-	UserID      uuid.UUID `json:"user_id" db:"user_id"`
-	Role        string    `json:"role" db:"role"` // owner, admin, member
-	JoinedAt    time.Time `json:"joined_at" db:"joined_at"`
+	ID             uuid.UUID  `json:"id" db:"id"`
+	WorkspaceID    uuid.UUID  `json:"workspace_id" db:"workspace_id"`
+	UserID         uuid.UUID  `json:"user_id" db:"user_id"`
+	Role           string     `json:"role" db:"role"` // owner, admin, member, multi_channel_guest, single_channel_guest
+	CustomRoleID   *uuid.UUID `json:"custom_role_id,omitempty" db:"custom_role_id"`
+	GuestExpiresAt *time.Time `json:"guest_expires_at,omitempty" db:"guest_expires_at"` // guests lose access at this time
+	JoinedAt       time.Time  `json:"joined_at" db:"joined_at"`
+
+	// Virtual fields
+	User *User `json:"user,omitempty"`
 }
 
 type Channel struct {
-	ID          uuid.UUID  `json:"id" db:"id"`
-	WorkspaceID uuid.UUID  `json:"workspace_id" db:"workspace_id"`
-	Name        string     `json:"name" db:"name"`
-	Description *string    `json:"description,omitempty" db:"description"`
-	IsPrivate   bool       `json:"is_private" db:"is_private"`
-	CreatedBy   *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
-	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+	ID            uuid.UUID  `json:"id" db:"id"`
+	WorkspaceID   uuid.UUID  `json:"workspace_id" db:"workspace_id"`
+	Name          string     `json:"name" db:"name"`
+	Description   *string    `json:"description,omitempty" db:"description"`
+	Topic         *string    `json:"topic,omitempty" db:"topic"`
+	IsPrivate     bool       `json:"is_private" db:"is_private"`
+	IsDefault     bool       `json:"is_default" db:"is_default"`
+	PostingPolicy string     `json:"posting_policy" db:"posting_policy"` // who can post: everyone, admins, allowlist
+	ReplyPolicy   string     `json:"reply_policy" db:"reply_policy"`     // who can reply in threads
+	CreatedBy     *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+	ArchivedAt    *time.Time `json:"archived_at,omitempty" db:"archived_at"`
+	ArchivedBy    *uuid.UUID `json:"archived_by,omitempty" db:"archived_by"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
 
 	// Virtual fields
-	UnreadCount int `json:"unread_count" db:"-"`
+	UnreadCount int  `json:"unread_count" db:"-"`
+	IsMuted     bool `json:"is_muted" db:"-"`
+	// PosterIDs are the members on the allowlist
+	PosterIDs []uuid.UUID `json:"poster_ids,omitempty" db:"-"`
+}
+
+// ChannelChange records one change to a channel's metadata
+type ChannelChange struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	ChannelID uuid.UUID  `json:"channel_id" db:"channel_id"`
+	ActorID   *uuid.UUID `json:"actor_id,omitempty" db:"actor_id"`
+	Field     string     `json:"field" db:"field"` // name, description, topic, is_private, is_default, archived, posting_policy, reply_policy
+	OldValue  *string    `json:"old_value,omitempty" db:"old_value"`
+	NewValue  *string    `json:"new_value,omitempty" db:"new_value"`
+	MessageID *uuid.UUID `json:"message_id,omitempty" db:"message_id"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+
+	// Virtual fields
+	Actor *User `json:"actor,omitempty" db:"-"`
+}
+
+// ChannelDirectoryEntry is a channel as listed in the channel browser
+type ChannelDirectoryEntry struct {
+	Channel
+	MemberCount    int       `json:"member_count" db:"member_count"`
+	LastActivityAt time.Time `json:"last_activity_at" db:"last_activity_at"` // latest message, or creation
+	IsMember       bool      `json:"is_member" db:"is_member"`
 }
 
 type ChannelMember struct {
@@ -58,6 +115,9 @@ type ChannelMember struct {
 	UserID     uuid.UUID  `json:"user_id" db:"user_id"`
 	JoinedAt   time.Time  `json:"joined_at" db:"joined_at"`
 	LastReadAt *time.Time `json:"last_read_at,omitempty" db:"last_read_at"`
+
+	// Virtual fields
+	User *User `json:"user,omitempty"`
 }
 
 type DirectMessage struct {
@@ -89,11 +149,25 @@ type Message struct {
 	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
 
+	// Thread summary, maintained on the parent message
+	ReplyCount   int         `json:"reply_count,omitempty" db:"reply_count"`
+	LastReplyAt  *time.Time  `json:"last_reply_at,omitempty" db:"last_reply_at"`
+	ReplyUserIDs []uuid.UUID `json:"reply_user_ids,omitempty" db:"reply_user_ids"`
+
+	// Thread reply that is also shown in the channel or DM
+	AlsoSentToChannel bool `json:"also_sent_to_channel,omitempty" db:"also_sent_to_channel"`
+
+	// System messages announce channel changes; Metadata holds the details
+	Type     string          `json:"type" db:"type"` // user, system
+	Metadata json.RawMessage `json:"metadata,omitempty" db:"metadata"`
+
 	// Virtual fields (not in DB, populated by queries)
 	Sender      *User        `json:"sender,omitempty" db:"-"`
 	Reactions   []Reaction   `json:"reactions,omitempty" db:"-"`
 	Attachments []Attachment `json:"attachments,omitempty" db:"-"`
-	ReplyCount  int          `json:"reply_count,omitempty" db:"-"`
+
+	// Set when sending; notifications for urgent messages break through DND
+	Urgent bool `json:"urgent,omitempty" db:"-"`
 }
 
 type Reaction struct {
@@ -118,12 +192,257 @@ type Attachment struct {
 }
 
 type WorkspaceInvite struct {
+	ID             uuid.UUID   `json:"id" db:"id"`
+	WorkspaceID    uuid.UUID   `json:"workspace_id" db:"workspace_id"`
+	InviterID      uuid.UUID   `json:"inviter_id" db:"inviter_id"`
+	Code           string      `json:"code" db:"code"`
+	ExpiresAt      *time.Time  `json:"expires_at,omitempty" db:"expires_at"`
+	MaxUses        *int        `json:"max_uses,omitempty" db:"max_uses"`
+	Uses           int         `json:"uses" db:"uses"`
+	Role           string      `json:"role" db:"role"`                                   // member or a guest role
+	ChannelIDs     []uuid.UUID `json:"channel_ids,omitempty" db:"channel_ids"`           // channels a guest joins instead of the defaults
+	GuestExpiresAt *time.Time  `json:"guest_expires_at,omitempty" db:"guest_expires_at"` // when the guest's access ends
+	Email          *string     `json:"email,omitempty" db:"email"`                       // only this address may use the invite
+	RevokedAt      *time.Time  `json:"revoked_at,omitempty" db:"revoked_at"`
+	RevokedBy      *uuid.UUID  `json:"revoked_by,omitempty" db:"revoked_by"`
+	CreatedAt      time.Time   `json:"created_at" db:"created_at"`
+}
+
+// InviteRedemption records a user joining through an invite
+type InviteRedemption struct {
+	ID         uuid.UUID `json:"id" db:"id"`
+	InviteID   uuid.UUID `json:"invite_id" db:"invite_id"`
+	UserID     uuid.UUID `json:"user_id" db:"user_id"`
+	RedeemedAt time.Time `json:"redeemed_at" db:"redeemed_at"`
+
+	// Virtual fields
+	User *User `json:"user,omitempty" db:"-"`
+}
+
+type SavedItem struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	UserID      uuid.UUID  `json:"user_id" db:"user_id"`
+	MessageID   uuid.UUID  `json:"message_id" db:"message_id"`
+	WorkspaceID uuid.UUID  `json:"workspace_id" db:"workspace_id"`
+	Status      string     `json:"status" db:"status"` // in_progress, completed
+	RemindAt    *time.Time `json:"remind_at,omitempty" db:"remind_at"`
+	RemindedAt  *time.Time `json:"reminded_at,omitempty" db:"reminded_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+
+	// Virtual field
+	Message *Message `json:"message,omitempty" db:"-"`
+}
+
+type ThreadSubscription struct {
+	ID           uuid.UUID  `json:"id" db:"id"`
+	ThreadID     uuid.UUID  `json:"thread_id" db:"thread_id"`
+	UserID       uuid.UUID  `json:"user_id" db:"user_id"`
+	Following    bool       `json:"following" db:"following"`
+	UnfollowedAt *time.Time `json:"unfollowed_at,omitempty" db:"unfollowed_at"`
+	LastReadAt   *time.Time `json:"last_read_at,omitempty" db:"last_read_at"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// ThreadInboxItem is a followed thread as shown in the "Threads" view
+type ThreadInboxItem struct {
+	Parent      *Message   `json:"parent"`
+	UnreadCount int        `json:"unread_count"`
+	LastReplyAt *time.Time `json:"last_reply_at,omitempty"`
+	LastReadAt  *time.Time `json:"last_read_at,omitempty"`
+}
+
+type MessageMention struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	MessageID   uuid.UUID `json:"message_id" db:"message_id"`
+	UserID      uuid.UUID `json:"user_id" db:"user_id"`
+	MentionType string    `json:"mention_type" db:"mention_type"` // user, channel, here, everyone
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+type WorkspaceSettings struct {
+	WorkspaceID              uuid.UUID `json:"workspace_id" db:"workspace_id"`
+	BroadcastMentionPolicy   string    `json:"broadcast_mention_policy" db:"broadcast_mention_policy"`     // everyone, admins
+	DefaultNotificationLevel string    `json:"default_notification_level" db:"default_notification_level"` // all, mentions, nothing
+	UrgentOverridePolicy     string    `json:"urgent_override_policy" db:"urgent_override_policy"`         // disabled, admins, everyone
+	UpdatedAt                time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type Notification struct {
+	ID          uuid.UUID       `json:"id" db:"id"`
+	UserID      uuid.UUID       `json:"user_id" db:"user_id"`
+	WorkspaceID *uuid.UUID      `json:"workspace_id,omitempty" db:"workspace_id"`
+	Type        string          `json:"type" db:"type"` // mention, reaction, thread_reply, invite_accepted
+	ActorID     *uuid.UUID      `json:"actor_id,omitempty" db:"actor_id"`
+	MessageID   *uuid.UUID      `json:"message_id,omitempty" db:"message_id"`
+	ChannelID   *uuid.UUID      `json:"channel_id,omitempty" db:"channel_id"`
+	DMID        *uuid.UUID      `json:"dm_id,omitempty" db:"dm_id"`
+	Data        json.RawMessage `json:"data" db:"data"`
+	Urgent      bool            `json:"urgent" db:"urgent"`
+	ReadAt      *time.Time      `json:"read_at,omitempty" db:"read_at"`
+	DeliveredAt *time.Time      `json:"delivered_at,omitempty" db:"delivered_at"` // nil while held for DND
+	CreatedAt   time.Time       `json:"created_at" db:"created_at"`
+
+	// Virtual fields
+	Actor *User `json:"actor,omitempty"`
+}
+
+type NotificationPreference struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	UserID     uuid.UUID  `json:"user_id" db:"user_id"`
+	ChannelID  *uuid.UUID `json:"channel_id,omitempty" db:"channel_id"`
+	DMID       *uuid.UUID `json:"dm_id,omitempty" db:"dm_id"`
+	Level      *string    `json:"level,omitempty" db:"level"` // all, mentions, nothing; nil inherits the default
+	Muted      bool       `json:"muted" db:"muted"`
+	MutedUntil *time.Time `json:"muted_until,omitempty" db:"muted_until"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
+
+	// Virtual fields
+	EffectiveLevel string `json:"effective_level" db:"-"`
+}
+
+type UserNotificationSettings struct {
+	UserID       uuid.UUID `json:"user_id" db:"user_id"`
+	WorkspaceID  uuid.UUID `json:"workspace_id" db:"workspace_id"`
+	DefaultLevel *string   `json:"default_level,omitempty" db:"default_level"` // all, mentions, nothing; nil inherits the workspace default
+	Keywords     []string  `json:"keywords" db:"keywords"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type DNDSettings struct {
+	UserID          uuid.UUID   `json:"user_id" db:"user_id"`
+	Timezone        string      `json:"timezone" db:"timezone"`
+	ScheduleEnabled bool        `json:"schedule_enabled" db:"schedule_enabled"`
+	Schedule        []DNDWindow `json:"schedule" db:"schedule"`
+	SnoozeUntil     *time.Time  `json:"snooze_until,omitempty" db:"snooze_until"`
+	UpdatedAt       time.Time   `json:"updated_at" db:"updated_at"`
+
+	// Virtual fields
+	Active      bool       `json:"active" db:"-"`
+	ActiveUntil *time.Time `json:"active_until,omitempty" db:"-"`
+}
+
+// DNDWindow is a weekly quiet period in the user's timezone. A window whose
+// end is before its start runs past midnight into the next day.
+type DNDWindow struct {
+	Day   int    `json:"day"`   // 0 = Sunday
+	Start string `json:"start"` // HH:MM
+	End   string `json:"end"`   // HH:MM
+}
+
+type EmailDigestSettings struct {
+	UserID     uuid.UUID  `json:"user_id" db:"user_id"`
+	Enabled    bool       `json:"enabled" db:"enabled"`
+	Frequency  string     `json:"frequency" db:"frequency"` // hourly, daily, weekly
+	LastSentAt *time.Time `json:"last_sent_at,omitempty" db:"last_sent_at"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// DigestItem is an unread DM or mention included in an email digest.
+type DigestItem struct {
+	Kind          string  `json:"kind"` // dm, mention
+	Message       Message `json:"message"`
+	WorkspaceName string  `json:"workspace_name"`
+	ChannelName   *string `json:"channel_name,omitempty"`
+}
+
+type PushSubscription struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	UserID         uuid.UUID  `json:"user_id" db:"user_id"`
+	Endpoint       string     `json:"endpoint" db:"endpoint"`
+	P256dh         string     `json:"-" db:"p256dh"`
+	Auth           string     `json:"-" db:"auth"`
+	UserAgent      *string    `json:"user_agent,omitempty" db:"user_agent"`
+	ExpirationTime *time.Time `json:"expiration_time,omitempty" db:"expiration_time"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	LastUsedAt     *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+}
+
+// PushJob is a queued Web Push delivery to one subscription.
+type PushJob struct {
+	ID             uuid.UUID `json:"id" db:"id"`
+	SubscriptionID uuid.UUID `json:"subscription_id" db:"subscription_id"`
+	Payload        string    `json:"payload" db:"payload"`
+	Urgency        string    `json:"urgency" db:"urgency"`
+	Attempts       int       `json:"attempts" db:"attempts"`
+	NextAttemptAt  time.Time `json:"next_attempt_at" db:"next_attempt_at"`
+	LastError      *string   `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+
+	// Virtual fields
+	Subscription *PushSubscription `json:"subscription,omitempty"`
+}
+
+type DeviceToken struct {
+	ID         uuid.UUID `json:"id" db:"id"`
+	UserID     uuid.UUID `json:"user_id" db:"user_id"`
+	Platform   string    `json:"platform" db:"platform"` // ios, android
+	Token      string    `json:"token" db:"token"`
+	AppVersion *string   `json:"app_version,omitempty" db:"app_version"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// SidebarSection is a user's custom group of conversations in a workspace
+type SidebarSection struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	UserID      uuid.UUID `json:"user_id" db:"user_id"`
+	WorkspaceID uuid.UUID `json:"workspace_id" db:"workspace_id"`
+	Name        string    `json:"name" db:"name"`
+	Position    int       `json:"position" db:"position"`
+	Collapsed   bool      `json:"collapsed" db:"collapsed"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+
+	// Virtual fields
+	Items []*SidebarItem `json:"items" db:"-"`
+}
+
+// SidebarItem places a channel or DM in a sidebar section
+type SidebarItem struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	SectionID uuid.UUID  `json:"section_id" db:"section_id"`
+	UserID    uuid.UUID  `json:"user_id" db:"user_id"`
+	ChannelID *uuid.UUID `json:"channel_id,omitempty" db:"channel_id"`
+	DMID      *uuid.UUID `json:"dm_id,omitempty" db:"dm_id"`
+	Position  int        `json:"position" db:"position"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+type StarredConversation struct {
 	ID          uuid.UUID  `json:"id" db:"id"`
+	UserID      uuid.UUID  `json:"user_id" db:"user_id"`
 	WorkspaceID uuid.UUID  `json:"workspace_id" db:"workspace_id"`
-	InviterID   uuid.UUID  `json:"inviter_id" db:"inviter_id"`
-	Code        string     `json:"code" db:"code"`
-	ExpiresAt   *time.Time `json:"expires_at,omitempty" db:"expires_at"`
-	MaxUses     *int       `json:"max_uses,omitempty" db:"max_uses"`
-	Uses        int        `json:"uses" db:"uses"`
+	ChannelID   *uuid.UUID `json:"channel_id,omitempty" db:"channel_id"`
+	DMID        *uuid.UUID `json:"dm_id,omitempty" db:"dm_id"`
 	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
 }
+
+// AuditLogEntry records a sensitive administrative action in a workspace
+type AuditLogEntry struct {
+	ID          uuid.UUID       `json:"id" db:"id"`
+	WorkspaceID uuid.UUID       `json:"workspace_id" db:"workspace_id"`
+	ActorID     *uuid.UUID      `json:"actor_id,omitempty" db:"actor_id"`
+	Action      string          `json:"action" db:"action"`
+	TargetID    *uuid.UUID      `json:"target_id,omitempty" db:"target_id"`
+	Metadata    json.RawMessage `json:"metadata,omitempty" db:"metadata"`
+	CreatedAt   time.Time       `json:"created_at" db:"created_at"`
+
+	// Virtual fields
+	Actor *User `json:"actor,omitempty" db:"-"`
+}
+
+// WorkspaceRole is a custom role; it grants permissions on top of a member's
+// built-in role
+type WorkspaceRole struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	WorkspaceID uuid.UUID `json:"workspace_id" db:"workspace_id"`
+	Name        string    `json:"name" db:"name"`
+	Description *string   `json:"description,omitempty" db:"description"`
+	Permissions []string  `json:"permissions" db:"permissions"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
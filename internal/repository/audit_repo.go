@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/database"
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+	"github.com/google/uuid"
+)
+
+type AuditRepository interface {
+	Create(entry *models.AuditLogEntry) error
+	// ListByWorkspace returns the workspace's audit log, newest first
+	ListByWorkspace(workspaceID uuid.UUID, limit, offset int) ([]*models.AuditLogEntry, error)
+}
+
+type postgresAuditRepository struct {
+	db *database.DB
+}
+
+func NewAuditRepository(db *database.DB) AuditRepository {
+	return &postgresAuditRepository{db: db}
+}
+
+// auditQuerier is satisfied by both the database and a transaction, so other
+// repositories can record an entry atomically with the change it describes.
+type auditQuerier interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func (r *postgresAuditRepository) Create(entry *models.AuditLogEntry) error {
+	return insertAuditEntry(r.db, entry)
+}
+
+func insertAuditEntry(q auditQuerier, entry *models.AuditLogEntry) error {
+	var metadata interface{}
+	if len(entry.Metadata) > 0 {
+		metadata = string(entry.Metadata)
+	}
+
+	query := `
+		INSERT INTO audit_log (id, workspace_id, actor_id, action, target_id, metadata)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING created_at
+	`
+	return q.QueryRow(
+		query,
+		entry.ID,
+		entry.WorkspaceID,
+		entry.ActorID,
+		entry.Action,
+		entry.TargetID,
+		metadata,
+	).Scan(&entry.CreatedAt)
+}
+
+func (r *postgresAuditRepository) ListByWorkspace(workspaceID uuid.UUID, limit, offset int) ([]*models.AuditLogEntry, error) {
+	query := `
+		SELECT a.id, a.workspace_id, a.actor_id, a.action, a.target_id, a.metadata, a.created_at,
+		       u.username, u.avatar_url, u.full_name
+		FROM audit_log a
+		LEFT JOIN users u ON a.actor_id = u.id
+		WHERE a.workspace_id = $1
+		ORDER BY a.created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := r.db.Query(query, workspaceID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*models.AuditLogEntry
+	for rows.Next() {
+		e := &models.AuditLogEntry{}
+		var metadata []byte
+		var username, fullName, avatarURL sql.NullString
+		if err := rows.Scan(
+			&e.ID, &e.WorkspaceID, &e.ActorID, &e.Action, &e.TargetID, &metadata, &e.CreatedAt,
+			&username, &avatarURL, &fullName,
+		); err != nil {
+			return nil, err
+		}
+		if len(metadata) > 0 {
+			e.Metadata = metadata
+		}
+
+		if username.Valid {
+			e.Actor = &models.User{
+				ID:       *e.ActorID,
+				Username: username.String,
+			}
+			if avatarURL.Valid {
+				e.Actor.AvatarURL = &avatarURL.String
+			}
+			if fullName.Valid {
+				e.Actor.FullName = &fullName.String
+			}
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
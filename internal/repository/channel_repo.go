@@ -3,25 +3,71 @@ package repository
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 
 	"github.com/DoDuy2004/slack-clone-backend/internal/database"
 	"github.com/DoDuy2004/slack-clone-backend/internal/models"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+// Channel directory sort orders
+const (
+	ChannelSortName     = "name"     // alphabetical
+	ChannelSortMembers  = "members"  // most members first
+	ChannelSortActivity = "activity" // most recent message first
+)
+
+// ChannelBrowseFilter selects and orders channels in the directory. AfterValue
+// and AfterID resume after the last entry of the previous page; AfterValue is
+// the entry's sort key: the lowercased name, the member count, or the last
+// activity time.
+type ChannelBrowseFilter struct {
+	Query      string
+	Membership string // all, joined, not_joined
+	Archived   string // exclude, include, only
+	Sort       string
+	AfterValue interface{}
+	AfterID    *uuid.UUID
+	Limit      int
+}
+
 type ChannelRepository interface {
 	Create(channel *models.Channel) error
 	FindByID(id uuid.UUID) (*models.Channel, error)
-	ListByWorkspaceID(workspaceID uuid.UUID, userID uuid.UUID) ([]*models.Channel, error)
+	// ListByWorkspaceID lists the channels the user can see; archived ones only if includeArchived.
+	// Guests only see the channels they are in.
+	ListByWorkspaceID(workspaceID uuid.UUID, userID uuid.UUID, includeArchived bool) ([]*models.Channel, error)
+	// Browse lists the channels the user can see for the channel directory
+	Browse(workspaceID uuid.UUID, userID uuid.UUID, filter *ChannelBrowseFilter) ([]*models.ChannelDirectoryEntry, error)
 	Update(channel *models.Channel) error
 	Delete(id uuid.UUID) error
+	Archive(id uuid.UUID, archivedBy uuid.UUID) error
+	Unarchive(id uuid.UUID) error
+	// SetPostingPolicy sets both policies and replaces the allowlist
+	SetPostingPolicy(channelID uuid.UUID, postingPolicy, replyPolicy string, posterIDs []uuid.UUID) error
+
+	// Change history
+	AddChange(change *models.ChannelChange) error
+	ListChanges(channelID uuid.UUID, limit, offset int) ([]*models.ChannelChange, error)
 
 	// Member operations
 	AddMember(channelID, userID uuid.UUID) error
+	// RemoveMember takes the user out of the channel and unfollows its threads
 	RemoveMember(channelID, userID uuid.UUID) error
+	// PruneNonMembers unfollows the channel's threads and drops the channel's
+	// notification preferences for everyone who isn't a member, for channels
+	// that just went private
+	PruneNonMembers(channelID uuid.UUID) error
 	IsMember(channelID, userID uuid.UUID) (bool, error)
 	ListMembers(channelID uuid.UUID) ([]*models.ChannelMember, error)
+	// ListWorkspaceMemberships lists every channel membership in the workspace, deleted or not
+	ListWorkspaceMemberships(workspaceID uuid.UUID) ([]*models.ChannelMember, error)
 	UpdateLastRead(channelID, userID uuid.UUID) error
+	// CountJoined counts the workspace channels the user is in, archived ones included
+	CountJoined(workspaceID, userID uuid.UUID) (int, error)
+	// SharesChannel reports whether the two users are in a common channel of the workspace
+	SharesChannel(workspaceID, userID, otherID uuid.UUID) (bool, error)
 }
 
 type postgresChannelRepository struct {
@@ -41,9 +87,9 @@ func (r *postgresChannelRepository) Create(channel *models.Channel) error {
 
 	// 1. Insert Channel
 	query := `
-		INSERT INTO channels (id, workspace_id, name, description, is_private, created_by)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING created_at, updated_at
+		INSERT INTO channels (id, workspace_id, name, description, is_private, is_default, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING posting_policy, reply_policy, created_at, updated_at
 	`
 	err = tx.QueryRow(
 		query,
@@ -52,8 +98,9 @@ func (r *postgresChannelRepository) Create(channel *models.Channel) error {
 		channel.Name,
 		channel.Description,
 		channel.IsPrivate,
+		channel.IsDefault,
 		channel.CreatedBy,
-	).Scan(&channel.CreatedAt, &channel.UpdatedAt)
+	).Scan(&channel.PostingPolicy, &channel.ReplyPolicy, &channel.CreatedAt, &channel.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("failed to create channel: %w", err)
 	}
@@ -75,9 +122,15 @@ func (r *postgresChannelRepository) Create(channel *models.Channel) error {
 
 func (r *postgresChannelRepository) FindByID(id uuid.UUID) (*models.Channel, error) {
 	c := &models.Channel{}
-	query := `SELECT id, workspace_id, name, description, is_private, created_by, created_at, updated_at FROM channels WHERE id = $1`
+	query := `
+		SELECT id, workspace_id, name, description, topic, is_private, is_default, posting_policy, reply_policy,
+		       created_by, archived_at, archived_by, created_at, updated_at,
+		       ARRAY(SELECT user_id FROM channel_posters p WHERE p.channel_id = c.id ORDER BY p.created_at)
+		FROM channels c WHERE id = $1
+	`
 	err := r.db.QueryRow(query, id).Scan(
-		&c.ID, &c.WorkspaceID, &c.Name, &c.Description, &c.IsPrivate, &c.CreatedBy, &c.CreatedAt, &c.UpdatedAt,
+		&c.ID, &c.WorkspaceID, &c.Name, &c.Description, &c.Topic, &c.IsPrivate, &c.IsDefault, &c.PostingPolicy, &c.ReplyPolicy,
+		&c.CreatedBy, &c.ArchivedAt, &c.ArchivedBy, &c.CreatedAt, &c.UpdatedAt, pq.Array(&c.PosterIDs),
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -88,22 +141,35 @@ func (r *postgresChannelRepository) FindByID(id uuid.UUID) (*models.Channel, err
 	return c, nil
 }
 
-func (r *postgresChannelRepository) ListByWorkspaceID(workspaceID uuid.UUID, userID uuid.UUID) ([]*models.Channel, error) {
-	// List public channels OR private channels where user is a member
-	// Also include unread count for the current user
+func (r *postgresChannelRepository) ListByWorkspaceID(workspaceID uuid.UUID, userID uuid.UUID, includeArchived bool) ([]*models.Channel, error) {
+	// List public channels OR private channels where user is a member; guests
+	// only get the channels they are in
+	// Also include unread count for the current user; muted channels report none
 	query := `
-		SELECT c.id, c.workspace_id, c.name, c.description, c.is_private, c.created_by, c.created_at, c.updated_at,
+		SELECT c.id, c.workspace_id, c.name, c.description, c.topic, c.is_private, c.is_default, c.posting_policy, c.reply_policy,
+		       c.created_by, c.archived_at, c.archived_by, c.created_at, c.updated_at,
+		       CASE WHEN mute.is_muted THEN 0 ELSE
 		       (SELECT COUNT(*) FROM messages m 
 		        WHERE m.channel_id = c.id 
 		        AND m.created_at > COALESCE(cm.last_read_at, '1970-01-01')
 		        AND m.sender_id != $2
-		        AND m.deleted_at IS NULL) as unread_count
+		        AND m.deleted_at IS NULL) END as unread_count,
+		       mute.is_muted
 		FROM channels c
 		LEFT JOIN channel_members cm ON c.id = cm.channel_id AND cm.user_id = $2
-		WHERE c.workspace_id = $1 AND (c.is_private = false OR cm.user_id IS NOT NULL)
+		LEFT JOIN notification_preferences np ON np.channel_id = c.id AND np.user_id = $2
+		CROSS JOIN LATERAL (
+			SELECT COALESCE(np.muted AND (np.muted_until IS NULL OR np.muted_until > CURRENT_TIMESTAMP), false) AS is_muted
+		) mute
+		WHERE c.workspace_id = $1
+		AND (cm.user_id IS NOT NULL OR (c.is_private = false AND NOT EXISTS(
+			SELECT 1 FROM workspace_members g
+			WHERE g.workspace_id = c.workspace_id AND g.user_id = $2
+			AND g.role IN ('multi_channel_guest', 'single_channel_guest'))))
+		AND ($3 OR c.archived_at IS NULL)
 		ORDER BY c.is_private ASC, c.name ASC
 	`
-	rows, err := r.db.Query(query, workspaceID, userID)
+	rows, err := r.db.Query(query, workspaceID, userID, includeArchived)
 	if err != nil {
 		return nil, err
 	}
@@ -112,7 +178,10 @@ func (r *postgresChannelRepository) ListByWorkspaceID(workspaceID uuid.UUID, use
 	var channels []*models.Channel
 	for rows.Next() {
 		c := &models.Channel{}
-		if err := rows.Scan(&c.ID, &c.WorkspaceID, &c.Name, &c.Description, &c.IsPrivate, &c.CreatedBy, &c.CreatedAt, &c.UpdatedAt, &c.UnreadCount); err != nil {
+		if err := rows.Scan(
+			&c.ID, &c.WorkspaceID, &c.Name, &c.Description, &c.Topic, &c.IsPrivate, &c.IsDefault, &c.PostingPolicy, &c.ReplyPolicy,
+			&c.CreatedBy, &c.ArchivedAt, &c.ArchivedBy, &c.CreatedAt, &c.UpdatedAt, &c.UnreadCount, &c.IsMuted,
+		); err != nil {
 			return nil, err
 		}
 		channels = append(channels, c)
@@ -120,13 +189,85 @@ func (r *postgresChannelRepository) ListByWorkspaceID(workspaceID uuid.UUID, use
 	return channels, nil
 }
 
+func (r *postgresChannelRepository) Browse(workspaceID uuid.UUID, userID uuid.UUID, filter *ChannelBrowseFilter) ([]*models.ChannelDirectoryEntry, error) {
+	// Names match by prefix; names and topics also match by trigram similarity
+	query := `
+		SELECT id, workspace_id, name, description, topic, is_private, is_default, posting_policy, reply_policy, created_by,
+		       archived_at, archived_by, created_at, updated_at, member_count, last_activity_at, is_member
+		FROM (
+			SELECT c.id, c.workspace_id, c.name, c.description, c.topic, c.is_private, c.is_default, c.posting_policy, c.reply_policy, c.created_by,
+			       c.archived_at, c.archived_by, c.created_at, c.updated_at,
+			       (SELECT COUNT(*) FROM channel_members m WHERE m.channel_id = c.id) AS member_count,
+			       COALESCE((SELECT MAX(msg.created_at) FROM messages msg
+			                 WHERE msg.channel_id = c.id AND msg.deleted_at IS NULL), c.created_at) AS last_activity_at,
+			       cm.user_id IS NOT NULL AS is_member,
+			       lower(c.name) AS sort_name
+			FROM channels c
+			LEFT JOIN channel_members cm ON c.id = cm.channel_id AND cm.user_id = $2
+			WHERE c.workspace_id = $1 AND (c.is_private = false OR cm.user_id IS NOT NULL)
+			AND ($3 = '' OR c.name ILIKE $4 || '%' ESCAPE '\' OR c.name % $3 OR $3 <% c.topic)
+			AND ($5 = 'all' OR ($5 = 'joined') = (cm.user_id IS NOT NULL))
+			AND ($6 = 'include' OR ($6 = 'only') = (c.archived_at IS NOT NULL))
+		) directory
+	`
+	args := []interface{}{workspaceID, userID, filter.Query, escapeLike(filter.Query), filter.Membership, filter.Archived}
+
+	var order string
+	switch filter.Sort {
+	case ChannelSortMembers:
+		order = "ORDER BY member_count DESC, id DESC"
+		if filter.AfterID != nil {
+			query += "WHERE (member_count, id) < ($7::bigint, $8::uuid)\n"
+		}
+	case ChannelSortActivity:
+		order = "ORDER BY last_activity_at DESC, id DESC"
+		if filter.AfterID != nil {
+			query += "WHERE (last_activity_at, id) < ($7::timestamp, $8::uuid)\n"
+		}
+	default:
+		order = "ORDER BY sort_name ASC, id ASC"
+		if filter.AfterID != nil {
+			query += "WHERE (sort_name, id) > ($7::text, $8::uuid)\n"
+		}
+	}
+	if filter.AfterID != nil {
+		args = append(args, filter.AfterValue, *filter.AfterID)
+	}
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf("%s LIMIT $%d", order, len(args))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*models.ChannelDirectoryEntry
+	for rows.Next() {
+		e := &models.ChannelDirectoryEntry{}
+		if err := rows.Scan(
+			&e.ID, &e.WorkspaceID, &e.Name, &e.Description, &e.Topic, &e.IsPrivate, &e.IsDefault, &e.PostingPolicy, &e.ReplyPolicy, &e.CreatedBy,
+			&e.ArchivedAt, &e.ArchivedBy, &e.CreatedAt, &e.UpdatedAt, &e.MemberCount, &e.LastActivityAt, &e.IsMember,
+		); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// escapeLike escapes the LIKE wildcards in s so it matches literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
 func (r *postgresChannelRepository) Update(channel *models.Channel) error {
 	query := `
 		UPDATE channels
-		SET name = $1, description = $2, is_private = $3, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $4
+		SET name = $1, description = $2, topic = $3, is_private = $4, is_default = $5, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $6
 	`
-	_, err := r.db.Exec(query, channel.Name, channel.Description, channel.IsPrivate, channel.ID)
+	_, err := r.db.Exec(query, channel.Name, channel.Description, channel.Topic, channel.IsPrivate, channel.IsDefault, channel.ID)
 	return err
 }
 
@@ -136,6 +277,108 @@ func (r *postgresChannelRepository) Delete(id uuid.UUID) error {
 	return err
 }
 
+func (r *postgresChannelRepository) Archive(id uuid.UUID, archivedBy uuid.UUID) error {
+	query := `
+		UPDATE channels
+		SET archived_at = CURRENT_TIMESTAMP, archived_by = $2
+		WHERE id = $1 AND archived_at IS NULL
+	`
+	_, err := r.db.Exec(query, id, archivedBy)
+	return err
+}
+
+func (r *postgresChannelRepository) Unarchive(id uuid.UUID) error {
+	query := `UPDATE channels SET archived_at = NULL, archived_by = NULL WHERE id = $1`
+	_, err := r.db.Exec(query, id)
+	return err
+}
+
+func (r *postgresChannelRepository) SetPostingPolicy(channelID uuid.UUID, postingPolicy, replyPolicy string, posterIDs []uuid.UUID) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE channels SET posting_policy = $1, reply_policy = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $3`
+	if _, err := tx.Exec(query, postingPolicy, replyPolicy, channelID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM channel_posters WHERE channel_id = $1`, channelID); err != nil {
+		return err
+	}
+	for _, posterID := range posterIDs {
+		if _, err := tx.Exec(`INSERT INTO channel_posters (channel_id, user_id) VALUES ($1, $2)`, channelID, posterID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *postgresChannelRepository) AddChange(change *models.ChannelChange) error {
+	query := `
+		INSERT INTO channel_changes (id, channel_id, actor_id, field, old_value, new_value, message_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING created_at
+	`
+	return r.db.QueryRow(
+		query,
+		change.ID,
+		change.ChannelID,
+		change.ActorID,
+		change.Field,
+		change.OldValue,
+		change.NewValue,
+		change.MessageID,
+	).Scan(&change.CreatedAt)
+}
+
+func (r *postgresChannelRepository) ListChanges(channelID uuid.UUID, limit, offset int) ([]*models.ChannelChange, error) {
+	query := `
+		SELECT cc.id, cc.channel_id, cc.actor_id, cc.field, cc.old_value, cc.new_value, cc.message_id, cc.created_at,
+		       u.username, u.avatar_url, u.full_name
+		FROM channel_changes cc
+		LEFT JOIN users u ON cc.actor_id = u.id
+		WHERE cc.channel_id = $1
+		ORDER BY cc.created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := r.db.Query(query, channelID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []*models.ChannelChange
+	for rows.Next() {
+		cc := &models.ChannelChange{}
+		var username, fullName, avatarURL sql.NullString
+		if err := rows.Scan(
+			&cc.ID, &cc.ChannelID, &cc.ActorID, &cc.Field, &cc.OldValue, &cc.NewValue, &cc.MessageID, &cc.CreatedAt,
+			&username, &avatarURL, &fullName,
+		); err != nil {
+			return nil, err
+		}
+
+		if username.Valid {
+			cc.Actor = &models.User{
+				ID:       *cc.ActorID,
+				Username: username.String,
+			}
+			if avatarURL.Valid {
+				cc.Actor.AvatarURL = &avatarURL.String
+			}
+			if fullName.Valid {
+				cc.Actor.FullName = &fullName.String
+			}
+		}
+		changes = append(changes, cc)
+	}
+	return changes, nil
+}
+
 func (r *postgresChannelRepository) AddMember(channelID, userID uuid.UUID) error {
 	query := `
 		INSERT INTO channel_members (id, channel_id, user_id)
@@ -147,14 +390,92 @@ func (r *postgresChannelRepository) AddMember(channelID, userID uuid.UUID) error
 }
 
 func (r *postgresChannelRepository) RemoveMember(channelID, userID uuid.UUID) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	query := `DELETE FROM channel_members WHERE channel_id = $1 AND user_id = $2`
-	_, err := r.db.Exec(query, channelID, userID)
-	return err
+	if _, err := tx.Exec(query, channelID, userID); err != nil {
+		return fmt.Errorf("failed to remove channel member: %w", err)
+	}
+
+	subscriptionsQuery := `
+		DELETE FROM thread_subscriptions
+		WHERE user_id = $2 AND thread_id IN (SELECT id FROM messages WHERE channel_id = $1)
+	`
+	if _, err := tx.Exec(subscriptionsQuery, channelID, userID); err != nil {
+		return fmt.Errorf("failed to unfollow threads: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+func (r *postgresChannelRepository) PruneNonMembers(channelID uuid.UUID) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	subscriptionsQuery := `
+		DELETE FROM thread_subscriptions ts
+		USING messages m
+		WHERE ts.thread_id = m.id AND m.channel_id = $1
+		AND NOT EXISTS(SELECT 1 FROM channel_members cm WHERE cm.channel_id = $1 AND cm.user_id = ts.user_id)
+	`
+	if _, err := tx.Exec(subscriptionsQuery, channelID); err != nil {
+		return fmt.Errorf("failed to unfollow threads: %w", err)
+	}
+
+	preferencesQuery := `
+		DELETE FROM notification_preferences np
+		WHERE np.channel_id = $1
+		AND NOT EXISTS(SELECT 1 FROM channel_members cm WHERE cm.channel_id = $1 AND cm.user_id = np.user_id)
+	`
+	if _, err := tx.Exec(preferencesQuery, channelID); err != nil {
+		return fmt.Errorf("failed to clear notification preferences: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+func (r *postgresChannelRepository) CountJoined(workspaceID, userID uuid.UUID) (int, error) {
+	var count int
+	query := `
+		SELECT COUNT(*) FROM channel_members cm
+		JOIN channels c ON cm.channel_id = c.id
+		WHERE c.workspace_id = $1 AND cm.user_id = $2
+	`
+	err := r.db.QueryRow(query, workspaceID, userID).Scan(&count)
+	return count, err
+}
+
+func (r *postgresChannelRepository) SharesChannel(workspaceID, userID, otherID uuid.UUID) (bool, error) {
+	var exists bool
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM channel_members a
+			JOIN channel_members b ON a.channel_id = b.channel_id
+			JOIN channels c ON a.channel_id = c.id
+			WHERE c.workspace_id = $1 AND a.user_id = $2 AND b.user_id = $3
+		)
+	`
+	err := r.db.QueryRow(query, workspaceID, userID, otherID).Scan(&exists)
+	return exists, err
 }
 
 func (r *postgresChannelRepository) IsMember(channelID, userID uuid.UUID) (bool, error) {
 	var exists bool
-	query := `SELECT EXISTS(SELECT 1 FROM channel_members WHERE channel_id = $1 AND user_id = $2)`
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM channel_members cm
+			JOIN channels c ON cm.channel_id = c.id
+			JOIN workspaces w ON c.workspace_id = w.id
+			WHERE cm.channel_id = $1 AND cm.user_id = $2 AND w.deleted_at IS NULL
+		)
+	`
 	err := r.db.QueryRow(query, channelID, userID).Scan(&exists)
 	return exists, err
 }
@@ -178,6 +499,30 @@ func (r *postgresChannelRepository) ListMembers(channelID uuid.UUID) ([]*models.
 	return members, nil
 }
 
+func (r *postgresChannelRepository) ListWorkspaceMemberships(workspaceID uuid.UUID) ([]*models.ChannelMember, error) {
+	query := `
+		SELECT cm.id, cm.channel_id, cm.user_id, cm.joined_at, cm.last_read_at
+		FROM channel_members cm
+		JOIN channels c ON cm.channel_id = c.id
+		WHERE c.workspace_id = $1
+	`
+	rows, err := r.db.Query(query, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []*models.ChannelMember
+	for rows.Next() {
+		m := &models.ChannelMember{}
+		if err := rows.Scan(&m.ID, &m.ChannelID, &m.UserID, &m.JoinedAt, &m.LastReadAt); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
 func (r *postgresChannelRepository) UpdateLastRead(channelID, userID uuid.UUID) error {
 	query := `
 		UPDATE channel_members
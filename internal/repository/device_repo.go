@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"github.com/DoDuy2004/slack-clone-backend/internal/database"
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+	"github.com/google/uuid"
+)
+
+type DeviceRepository interface {
+	// Upsert registers a device token; a token seen before is moved to the user.
+	Upsert(device *models.DeviceToken) error
+	Delete(userID uuid.UUID, token string) (bool, error)
+	DeleteByToken(token string) error
+	ListByUser(userID uuid.UUID) ([]*models.DeviceToken, error)
+}
+
+type postgresDeviceRepository struct {
+	db *database.DB
+}
+
+func NewDeviceRepository(db *database.DB) DeviceRepository {
+	return &postgresDeviceRepository{db: db}
+}
+
+func (r *postgresDeviceRepository) Upsert(device *models.DeviceToken) error {
+	query := `
+		INSERT INTO device_tokens (user_id, platform, token, app_version)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (token) DO UPDATE
+		SET user_id = EXCLUDED.user_id, platform = EXCLUDED.platform, app_version = EXCLUDED.app_version
+		RETURNING id, created_at, updated_at
+	`
+	return r.db.QueryRow(
+		query,
+		device.UserID,
+		device.Platform,
+		device.Token,
+		device.AppVersion,
+	).Scan(&device.ID, &device.CreatedAt, &device.UpdatedAt)
+}
+
+func (r *postgresDeviceRepository) Delete(userID uuid.UUID, token string) (bool, error) {
+	query := `DELETE FROM device_tokens WHERE user_id = $1 AND token = $2`
+	result, err := r.db.Exec(query, userID, token)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+func (r *postgresDeviceRepository) DeleteByToken(token string) error {
+	query := `DELETE FROM device_tokens WHERE token = $1`
+	_, err := r.db.Exec(query, token)
+	return err
+}
+
+func (r *postgresDeviceRepository) ListByUser(userID uuid.UUID) ([]*models.DeviceToken, error) {
+	query := `
+		SELECT id, user_id, platform, token, app_version, created_at, updated_at
+		FROM device_tokens
+		WHERE user_id = $1
+		ORDER BY updated_at DESC
+	`
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var devices []*models.DeviceToken
+	for rows.Next() {
+		device := &models.DeviceToken{}
+		if err := rows.Scan(
+			&device.ID, &device.UserID, &device.Platform, &device.Token,
+			&device.AppVersion, &device.CreatedAt, &device.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		devices = append(devices, device)
+	}
+	return devices, rows.Err()
+}
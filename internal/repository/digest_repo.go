@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/database"
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+	"github.com/google/uuid"
+)
+
+type DigestRepository interface {
+	GetSettings(userID uuid.UUID) (*models.EmailDigestSettings, error)
+	UpsertSettings(settings *models.EmailDigestSettings) error
+	// ListRecipients returns users idle for longer than idleFor who are due a
+	// digest and have unread DMs or mentions since their last one.
+	ListRecipients(idleFor time.Duration, limit int) ([]*models.User, error)
+	ListItems(userID uuid.UUID, limit int) ([]*models.DigestItem, error)
+	MarkSent(userID uuid.UUID) error
+}
+
+type postgresDigestRepository struct {
+	db *database.DB
+}
+
+func NewDigestRepository(db *database.DB) DigestRepository {
+	return &postgresDigestRepository{db: db}
+}
+
+// Unread DMs and mentions newer than both the read marker and the last digest,
+// in conversations the user can still see
+const digestUnreadDMs = `
+	SELECT m.id, m.content, m.sender_id, m.channel_id, m.dm_id, m.created_at,
+	       'dm' AS kind, w.name AS workspace_name, NULL::varchar AS channel_name
+	FROM dm_participants dp
+	JOIN messages m ON m.dm_id = dp.dm_id
+	JOIN direct_messages d ON d.id = dp.dm_id
+	JOIN workspaces w ON w.id = d.workspace_id
+	WHERE dp.user_id = u.id
+	AND w.deleted_at IS NULL
+	AND m.sender_id IS DISTINCT FROM u.id
+	AND m.deleted_at IS NULL
+	AND (m.parent_message_id IS NULL OR m.also_sent_to_channel = true)
+	AND m.created_at > COALESCE(dp.last_read_at, '1970-01-01')
+	AND m.created_at > COALESCE(s.last_sent_at, '1970-01-01')
+`
+
+const digestUnreadMentions = `
+	SELECT m.id, m.content, m.sender_id, m.channel_id, m.dm_id, m.created_at,
+	       'mention' AS kind, w.name AS workspace_name, c.name AS channel_name
+	FROM message_mentions mm
+	JOIN messages m ON m.id = mm.message_id
+	JOIN channels c ON c.id = m.channel_id
+	JOIN workspaces w ON w.id = c.workspace_id
+	LEFT JOIN channel_members cm ON cm.channel_id = m.channel_id AND cm.user_id = u.id
+	WHERE mm.user_id = u.id
+	AND m.deleted_at IS NULL
+	AND w.deleted_at IS NULL
+	AND (cm.user_id IS NOT NULL OR (c.is_private = false AND EXISTS(
+		SELECT 1 FROM workspace_members wm
+		WHERE wm.workspace_id = c.workspace_id AND wm.user_id = u.id
+		AND wm.role NOT IN ('multi_channel_guest', 'single_channel_guest')
+		AND ` + activeMemberSQL + `)))
+	AND m.created_at > COALESCE(cm.last_read_at, '1970-01-01')
+	AND m.created_at > COALESCE(s.last_sent_at, '1970-01-01')
+`
+
+func (r *postgresDigestRepository) GetSettings(userID uuid.UUID) (*models.EmailDigestSettings, error) {
+	settings := &models.EmailDigestSettings{}
+	query := `
+		SELECT user_id, enabled, frequency, last_sent_at, updated_at
+		FROM email_digest_settings
+		WHERE user_id = $1
+	`
+	err := r.db.QueryRow(query, userID).Scan(
+		&settings.UserID, &settings.Enabled, &settings.Frequency, &settings.LastSentAt, &settings.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+func (r *postgresDigestRepository) UpsertSettings(settings *models.EmailDigestSettings) error {
+	query := `
+		INSERT INTO email_digest_settings (user_id, enabled, frequency)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE
+		SET enabled = EXCLUDED.enabled, frequency = EXCLUDED.frequency, updated_at = CURRENT_TIMESTAMP
+		RETURNING last_sent_at, updated_at
+	`
+	return r.db.QueryRow(query, settings.UserID, settings.Enabled, settings.Frequency).Scan(
+		&settings.LastSentAt, &settings.UpdatedAt,
+	)
+}
+
+func (r *postgresDigestRepository) ListRecipients(idleFor time.Duration, limit int) ([]*models.User, error) {
+	query := `
+		SELECT u.id, u.email, u.username, u.full_name
+		FROM users u
+		LEFT JOIN email_digest_settings s ON s.user_id = u.id
+		WHERE u.status <> 'online'
+		AND u.last_seen_at < CURRENT_TIMESTAMP - make_interval(secs => $1)
+		AND COALESCE(s.enabled, true)
+		AND (s.last_sent_at IS NULL OR s.last_sent_at < CURRENT_TIMESTAMP -
+			CASE COALESCE(s.frequency, 'daily')
+				WHEN 'hourly' THEN INTERVAL '1 hour'
+				WHEN 'weekly' THEN INTERVAL '7 days'
+				ELSE INTERVAL '1 day'
+			END)
+		AND (EXISTS (` + digestUnreadDMs + `) OR EXISTS (` + digestUnreadMentions + `))
+		ORDER BY u.last_seen_at ASC
+		LIMIT $2
+	`
+	rows, err := r.db.Query(query, idleFor.Seconds(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*models.User
+	for rows.Next() {
+		u := &models.User{}
+		if err := rows.Scan(&u.ID, &u.Email, &u.Username, &u.FullName); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, nil
+}
+
+func (r *postgresDigestRepository) ListItems(userID uuid.UUID, limit int) ([]*models.DigestItem, error) {
+	query := `
+		SELECT i.id, i.content, i.sender_id, i.channel_id, i.dm_id, i.created_at,
+		       i.kind, i.workspace_name, i.channel_name,
+		       su.username, su.full_name
+		FROM users u
+		LEFT JOIN email_digest_settings s ON s.user_id = u.id
+		CROSS JOIN LATERAL (` + digestUnreadDMs + ` UNION ALL ` + digestUnreadMentions + `) i
+		LEFT JOIN users su ON su.id = i.sender_id
+		WHERE u.id = $1
+		ORDER BY i.created_at DESC
+		LIMIT $2
+	`
+	rows, err := r.db.Query(query, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []*models.DigestItem
+	for rows.Next() {
+		item := &models.DigestItem{}
+		m := &item.Message
+		var username, fullName sql.NullString
+		if err := rows.Scan(
+			&m.ID, &m.Content, &m.SenderID, &m.ChannelID, &m.DMID, &m.CreatedAt,
+			&item.Kind, &item.WorkspaceName, &item.ChannelName,
+			&username, &fullName,
+		); err != nil {
+			return nil, err
+		}
+
+		if username.Valid {
+			m.Sender = &models.User{
+				ID:       *m.SenderID,
+				Username: username.String,
+			}
+			if fullName.Valid {
+				m.Sender.FullName = &fullName.String
+			}
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (r *postgresDigestRepository) MarkSent(userID uuid.UUID) error {
+	query := `
+		INSERT INTO email_digest_settings (user_id, last_sent_at)
+		VALUES ($1, CURRENT_TIMESTAMP)
+		ON CONFLICT (user_id) DO UPDATE
+		SET last_sent_at = CURRENT_TIMESTAMP
+	`
+	_, err := r.db.Exec(query, userID)
+	return err
+}
@@ -15,6 +15,7 @@ type DMRepository interface {
 	GetByID(id uuid.UUID) (*models.DirectMessage, error)
 	IsParticipant(dmID, userID uuid.UUID) (bool, error)
 	UpdateLastRead(dmID, userID uuid.UUID) error
+	ListParticipantIDs(dmID uuid.UUID) ([]uuid.UUID, error)
 }
 
 type postgresDMRepository struct {
@@ -190,7 +191,14 @@ func (r *postgresDMRepository) GetByID(id uuid.UUID) (*models.DirectMessage, err
 }
 
 func (r *postgresDMRepository) IsParticipant(dmID, userID uuid.UUID) (bool, error) {
-	query := `SELECT EXISTS(SELECT 1 FROM dm_participants WHERE dm_id = $1 AND user_id = $2)`
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM dm_participants p
+			JOIN direct_messages d ON p.dm_id = d.id
+			JOIN workspaces w ON d.workspace_id = w.id
+			WHERE p.dm_id = $1 AND p.user_id = $2 AND w.deleted_at IS NULL
+		)
+	`
 	var exists bool
 	err := r.db.QueryRow(query, dmID, userID).Scan(&exists)
 	return exists, err
@@ -205,3 +213,22 @@ func (r *postgresDMRepository) UpdateLastRead(dmID, userID uuid.UUID) error {
 	_, err := r.db.Exec(query, dmID, userID)
 	return err
 }
+
+func (r *postgresDMRepository) ListParticipantIDs(dmID uuid.UUID) ([]uuid.UUID, error) {
+	query := `SELECT user_id FROM dm_participants WHERE dm_id = $1`
+	rows, err := r.db.Query(query, dmID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, id)
+	}
+	return userIDs, nil
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/database"
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+	"github.com/google/uuid"
+)
+
+type DNDRepository interface {
+	Get(userID uuid.UUID) (*models.DNDSettings, error)
+	Upsert(settings *models.DNDSettings) error
+}
+
+type postgresDNDRepository struct {
+	db *database.DB
+}
+
+func NewDNDRepository(db *database.DB) DNDRepository {
+	return &postgresDNDRepository{db: db}
+}
+
+func (r *postgresDNDRepository) Get(userID uuid.UUID) (*models.DNDSettings, error) {
+	settings := &models.DNDSettings{}
+	var schedule []byte
+	query := `
+		SELECT user_id, timezone, schedule_enabled, schedule, snooze_until, updated_at
+		FROM user_dnd_settings
+		WHERE user_id = $1
+	`
+	err := r.db.QueryRow(query, userID).Scan(
+		&settings.UserID, &settings.Timezone, &settings.ScheduleEnabled, &schedule, &settings.SnoozeUntil, &settings.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(schedule, &settings.Schedule); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+func (r *postgresDNDRepository) Upsert(settings *models.DNDSettings) error {
+	if settings.Schedule == nil {
+		settings.Schedule = []models.DNDWindow{}
+	}
+	schedule, err := json.Marshal(settings.Schedule)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO user_dnd_settings (user_id, timezone, schedule_enabled, schedule, snooze_until)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id) DO UPDATE
+		SET timezone = EXCLUDED.timezone, schedule_enabled = EXCLUDED.schedule_enabled,
+		    schedule = EXCLUDED.schedule, snooze_until = EXCLUDED.snooze_until, updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at
+	`
+	return r.db.QueryRow(
+		query,
+		settings.UserID,
+		settings.Timezone,
+		settings.ScheduleEnabled,
+		string(schedule),
+		settings.SnoozeUntil,
+	).Scan(&settings.UpdatedAt)
+}
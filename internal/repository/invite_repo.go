@@ -2,18 +2,37 @@ package repository
 
 import (
 	"database/sql"
+	"fmt"
 
 	"github.com/DoDuy2004/slack-clone-backend/internal/database"
 	"github.com/DoDuy2004/slack-clone-backend/internal/models"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type InviteRepository interface {
 	Create(invite *models.WorkspaceInvite) error
 	FindByCode(code string) (*models.WorkspaceInvite, error)
-	IncrementUses(id uuid.UUID) error
+	// ListByWorkspace returns every invite of the workspace, newest first,
+	// including expired and revoked ones
+	ListByWorkspace(workspaceID uuid.UUID) ([]*models.WorkspaceInvite, error)
+	// Revoke marks the invite revoked. It reports false if there is no such
+	// invite in the workspace or it was already revoked.
+	Revoke(workspaceID, id, revokedBy uuid.UUID) (bool, error)
+	// Redeem adds the user to the invite's workspace with the invite's role
+	// and channels, plus the default channels if joinDefaults is set, counts
+	// the use and records the redemption, all in one transaction. It reports
+	// false, changing nothing, if the invite is revoked, expired or used up,
+	// its workspace is deleted, or the user is already a member.
+	Redeem(invite *models.WorkspaceInvite, userID uuid.UUID, joinDefaults bool) (bool, error)
+	// ListRedemptions returns who joined through an invite of the workspace,
+	// newest first
+	ListRedemptions(workspaceID, inviteID uuid.UUID) ([]*models.InviteRedemption, error)
 }
 
+const inviteColumns = `id, workspace_id, inviter_id, code, expires_at, max_uses, uses, role, channel_ids, guest_expires_at,
+	email, revoked_at, revoked_by, created_at`
+
 type postgresInviteRepository struct {
 	db *database.DB
 }
@@ -24,8 +43,8 @@ func NewInviteRepository(db *database.DB) InviteRepository {
 
 func (r *postgresInviteRepository) Create(invite *models.WorkspaceInvite) error {
 	query := `
-		INSERT INTO workspace_invites (id, workspace_id, inviter_id, code, expires_at, max_uses, uses)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO workspace_invites (id, workspace_id, inviter_id, code, expires_at, max_uses, uses, role, channel_ids, guest_expires_at, email)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 		RETURNING created_at
 	`
 	return r.db.QueryRow(
@@ -37,17 +56,149 @@ func (r *postgresInviteRepository) Create(invite *models.WorkspaceInvite) error
 		invite.ExpiresAt,
 		invite.MaxUses,
 		invite.Uses,
+		invite.Role,
+		pq.Array(invite.ChannelIDs),
+		invite.GuestExpiresAt,
+		invite.Email,
 	).Scan(&invite.CreatedAt)
 }
 
 func (r *postgresInviteRepository) FindByCode(code string) (*models.WorkspaceInvite, error) {
-	invite := &models.WorkspaceInvite{}
+	query := `SELECT ` + inviteColumns + ` FROM workspace_invites WHERE code = $1`
+	invite, err := scanInvite(r.db.QueryRow(query, code))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return invite, nil
+}
+
+func (r *postgresInviteRepository) ListByWorkspace(workspaceID uuid.UUID) ([]*models.WorkspaceInvite, error) {
+	query := `SELECT ` + inviteColumns + ` FROM workspace_invites WHERE workspace_id = $1 ORDER BY created_at DESC`
+	rows, err := r.db.Query(query, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invites := []*models.WorkspaceInvite{}
+	for rows.Next() {
+		invite, err := scanInvite(rows)
+		if err != nil {
+			return nil, err
+		}
+		invites = append(invites, invite)
+	}
+	return invites, rows.Err()
+}
+
+func (r *postgresInviteRepository) Revoke(workspaceID, id, revokedBy uuid.UUID) (bool, error) {
+	query := `
+		UPDATE workspace_invites SET revoked_at = CURRENT_TIMESTAMP, revoked_by = $3
+		WHERE id = $1 AND workspace_id = $2 AND revoked_at IS NULL
+	`
+	result, err := r.db.Exec(query, id, workspaceID, revokedBy)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+func (r *postgresInviteRepository) Redeem(invite *models.WorkspaceInvite, userID uuid.UUID, joinDefaults bool) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// 1. Take a use; the row lock makes concurrent redemptions of the same
+	// invite wait, so they can't go over max_uses between them
+	useQuery := `
+		UPDATE workspace_invites SET uses = uses + 1
+		WHERE id = $1 AND revoked_at IS NULL
+		AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+		AND (max_uses IS NULL OR uses < max_uses)
+		AND NOT EXISTS(SELECT 1 FROM workspaces w WHERE w.id = workspace_id AND w.deleted_at IS NOT NULL)
+		RETURNING uses
+	`
+	err = tx.QueryRow(useQuery, invite.ID).Scan(&invite.Uses)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// 2. Add the member; an existing member keeps their role
+	memberQuery := `
+		INSERT INTO workspace_members (id, workspace_id, user_id, role, guest_expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (workspace_id, user_id) DO NOTHING
+	`
+	result, err := tx.Exec(memberQuery, uuid.New(), invite.WorkspaceID, userID, invite.Role, invite.GuestExpiresAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to add workspace member: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+		return false, err
+	}
+
+	// 3. Join the invite's channels, and the defaults for members
+	if joinDefaults {
+		if err := joinDefaultChannels(tx, invite.WorkspaceID, userID); err != nil {
+			return false, err
+		}
+	}
+	if err := joinChannels(tx, invite.WorkspaceID, userID, invite.InviterID, invite.ChannelIDs); err != nil {
+		return false, err
+	}
+
+	// 4. Record who used the invite
+	redemptionQuery := `INSERT INTO workspace_invite_redemptions (id, invite_id, user_id) VALUES ($1, $2, $3)`
+	if _, err := tx.Exec(redemptionQuery, uuid.New(), invite.ID, userID); err != nil {
+		return false, fmt.Errorf("failed to record invite redemption: %w", err)
+	}
+
+	return true, tx.Commit()
+}
+
+func (r *postgresInviteRepository) ListRedemptions(workspaceID, inviteID uuid.UUID) ([]*models.InviteRedemption, error) {
 	query := `
-		SELECT id, workspace_id, inviter_id, code, expires_at, max_uses, uses, created_at
-		FROM workspace_invites
-		WHERE code = $1
+		SELECT ir.id, ir.invite_id, ir.user_id, ir.redeemed_at,
+		       u.username, u.avatar_url, u.full_name
+		FROM workspace_invite_redemptions ir
+		JOIN workspace_invites wi ON ir.invite_id = wi.id
+		JOIN users u ON ir.user_id = u.id
+		WHERE wi.workspace_id = $1 AND ir.invite_id = $2
+		ORDER BY ir.redeemed_at DESC
 	`
-	err := r.db.QueryRow(query, code).Scan(
+	rows, err := r.db.Query(query, workspaceID, inviteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	redemptions := []*models.InviteRedemption{}
+	for rows.Next() {
+		ir := &models.InviteRedemption{User: &models.User{}}
+		if err := rows.Scan(
+			&ir.ID, &ir.InviteID, &ir.UserID, &ir.RedeemedAt,
+			&ir.User.Username, &ir.User.AvatarURL, &ir.User.FullName,
+		); err != nil {
+			return nil, err
+		}
+		ir.User.ID = ir.UserID
+		redemptions = append(redemptions, ir)
+	}
+	return redemptions, rows.Err()
+}
+
+func scanInvite(row interface{ Scan(...interface{}) error }) (*models.WorkspaceInvite, error) {
+	invite := &models.WorkspaceInvite{}
+	err := row.Scan(
 		&invite.ID,
 		&invite.WorkspaceID,
 		&invite.InviterID,
@@ -55,19 +206,16 @@ func (r *postgresInviteRepository) FindByCode(code string) (*models.WorkspaceInv
 		&invite.ExpiresAt,
 		&invite.MaxUses,
 		&invite.Uses,
+		&invite.Role,
+		pq.Array(&invite.ChannelIDs),
+		&invite.GuestExpiresAt,
+		&invite.Email,
+		&invite.RevokedAt,
+		&invite.RevokedBy,
 		&invite.CreatedAt,
 	)
-	if err == sql.ErrNoRows {
-		return nil, nil
-	}
 	if err != nil {
 		return nil, err
 	}
 	return invite, nil
 }
-
-func (r *postgresInviteRepository) IncrementUses(id uuid.UUID) error {
-	query := `UPDATE workspace_invites SET uses = uses + 1 WHERE id = $1`
-	_, err := r.db.Exec(query, id)
-	return err
-}
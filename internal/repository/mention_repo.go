@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"github.com/DoDuy2004/slack-clone-backend/internal/database"
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+	"github.com/google/uuid"
+)
+
+type MentionRepository interface {
+	// ReplaceForMessage stores the message's mentions and returns the ones that are new.
+	ReplaceForMessage(messageID uuid.UUID, mentions []*models.MessageMention) ([]*models.MessageMention, error)
+	ListByMessageID(messageID uuid.UUID) ([]*models.MessageMention, error)
+}
+
+type postgresMentionRepository struct {
+	db *database.DB
+}
+
+func NewMentionRepository(db *database.DB) MentionRepository {
+	return &postgresMentionRepository{db: db}
+}
+
+func (r *postgresMentionRepository) ReplaceForMessage(messageID uuid.UUID, mentions []*models.MessageMention) ([]*models.MessageMention, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// 1. Collect existing mentions
+	rows, err := tx.Query(`SELECT user_id FROM message_mentions WHERE message_id = $1`, messageID)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[uuid.UUID]bool)
+	for rows.Next() {
+		var userID uuid.UUID
+		if err := rows.Scan(&userID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		existing[userID] = true
+	}
+	rows.Close()
+
+	// 2. Replace with the current set
+	if _, err := tx.Exec(`DELETE FROM message_mentions WHERE message_id = $1`, messageID); err != nil {
+		return nil, err
+	}
+
+	var added []*models.MessageMention
+	insertQuery := `
+		INSERT INTO message_mentions (id, message_id, user_id, mention_type)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at
+	`
+	for _, m := range mentions {
+		m.ID = uuid.New()
+		m.MessageID = messageID
+		if err := tx.QueryRow(insertQuery, m.ID, m.MessageID, m.UserID, m.MentionType).Scan(&m.CreatedAt); err != nil {
+			return nil, err
+		}
+		if !existing[m.UserID] {
+			added = append(added, m)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return added, nil
+}
+
+func (r *postgresMentionRepository) ListByMessageID(messageID uuid.UUID) ([]*models.MessageMention, error) {
+	query := `
+		SELECT id, message_id, user_id, mention_type, created_at
+		FROM message_mentions
+		WHERE message_id = $1
+	`
+	rows, err := r.db.Query(query, messageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var mentions []*models.MessageMention
+	for rows.Next() {
+		m := &models.MessageMention{}
+		if err := rows.Scan(&m.ID, &m.MessageID, &m.UserID, &m.MentionType, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		mentions = append(mentions, m)
+	}
+	return mentions, nil
+}
@@ -6,8 +6,12 @@ import (
 	"github.com/DoDuy2004/slack-clone-backend/internal/database"
 	"github.com/DoDuy2004/slack-clone-backend/internal/models"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+// threadReplierLimit caps how many recent repliers a thread summary keeps.
+const threadReplierLimit = 5
+
 type MessageRepository interface {
 	Create(message *models.Message) error
 	FindByID(id uuid.UUID) (*models.Message, error)
@@ -16,7 +20,8 @@ type MessageRepository interface {
 	ListReplies(parentID uuid.UUID) ([]*models.Message, error)
 	Update(message *models.Message) error
 	SoftDelete(id uuid.UUID) error
-	Search(workspaceID uuid.UUID, query string, limit, offset int) ([]*models.Message, error)
+	// Search finds messages in the channels and DMs the user can see
+	Search(workspaceID, userID uuid.UUID, query string, limit, offset int) ([]*models.Message, error)
 }
 
 type postgresMessageRepository struct {
@@ -28,12 +33,24 @@ func NewMessageRepository(db *database.DB) MessageRepository {
 }
 
 func (r *postgresMessageRepository) Create(message *models.Message) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var metadata interface{}
+	if len(message.Metadata) > 0 {
+		metadata = string(message.Metadata)
+	}
+
+	// 1. Insert Message
 	query := `
-		INSERT INTO messages (id, content, sender_id, channel_id, dm_id, parent_message_id)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING created_at, updated_at
+		INSERT INTO messages (id, content, sender_id, channel_id, dm_id, parent_message_id, also_sent_to_channel, type, metadata)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, COALESCE(NULLIF($8, ''), 'user'), $9)
+		RETURNING type, created_at, updated_at
 	`
-	return r.db.QueryRow(
+	err = tx.QueryRow(
 		query,
 		message.ID,
 		message.Content,
@@ -41,18 +58,44 @@ func (r *postgresMessageRepository) Create(message *models.Message) error {
 		message.ChannelID,
 		message.DMID,
 		message.ParentMessageID,
-	).Scan(&message.CreatedAt, &message.UpdatedAt)
+		message.AlsoSentToChannel,
+		message.Type,
+		metadata,
+	).Scan(&message.Type, &message.CreatedAt, &message.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	// 2. Update the parent's thread summary
+	if message.ParentMessageID != nil {
+		summaryQuery := `
+			UPDATE messages
+			SET reply_count = reply_count + 1,
+			    last_reply_at = $2,
+			    reply_user_ids = CASE WHEN $3::uuid IS NULL THEN reply_user_ids
+			        ELSE (ARRAY[$3::uuid] || array_remove(reply_user_ids, $3::uuid))[1:$4] END
+			WHERE id = $1
+		`
+		if _, err := tx.Exec(summaryQuery, *message.ParentMessageID, message.CreatedAt, message.SenderID, threadReplierLimit); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
 
 func (r *postgresMessageRepository) FindByID(id uuid.UUID) (*models.Message, error) {
 	m := &models.Message{}
+	var metadata []byte
 	query := `
-		SELECT id, content, sender_id, channel_id, dm_id, parent_message_id, edited_at, deleted_at, created_at, updated_at
+		SELECT id, content, sender_id, channel_id, dm_id, parent_message_id, edited_at, deleted_at, created_at, updated_at,
+		       reply_count, last_reply_at, reply_user_ids, also_sent_to_channel, type, metadata
 		FROM messages
 		WHERE id = $1
 	`
 	err := r.db.QueryRow(query, id).Scan(
 		&m.ID, &m.Content, &m.SenderID, &m.ChannelID, &m.DMID, &m.ParentMessageID, &m.EditedAt, &m.DeletedAt, &m.CreatedAt, &m.UpdatedAt,
+		&m.ReplyCount, &m.LastReplyAt, pq.Array(&m.ReplyUserIDs), &m.AlsoSentToChannel, &m.Type, &metadata,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -60,6 +103,7 @@ func (r *postgresMessageRepository) FindByID(id uuid.UUID) (*models.Message, err
 	if err != nil {
 		return nil, err
 	}
+	m.Metadata = metadata
 
 	// Attach reactions
 	messages := []*models.Message{m}
@@ -73,11 +117,11 @@ func (r *postgresMessageRepository) FindByID(id uuid.UUID) (*models.Message, err
 func (r *postgresMessageRepository) ListByChannelID(channelID uuid.UUID, limit, offset int) ([]*models.Message, error) {
 	query := `
 		SELECT m.id, m.content, m.sender_id, m.channel_id, m.dm_id, m.parent_message_id, m.edited_at, m.deleted_at, m.created_at, m.updated_at,
-		       u.username, u.avatar_url, u.full_name,
-		       (SELECT COUNT(*) FROM messages WHERE parent_message_id = m.id) as reply_count
+		       m.reply_count, m.last_reply_at, m.reply_user_ids, m.also_sent_to_channel, m.type, m.metadata,
+		       u.username, u.avatar_url, u.full_name
 		FROM messages m
 		LEFT JOIN users u ON m.sender_id = u.id
-		WHERE m.channel_id = $1 AND m.parent_message_id IS NULL
+		WHERE m.channel_id = $1 AND (m.parent_message_id IS NULL OR m.also_sent_to_channel = true)
 		ORDER BY m.created_at DESC
 		LIMIT $2 OFFSET $3
 	`
@@ -90,13 +134,16 @@ func (r *postgresMessageRepository) ListByChannelID(channelID uuid.UUID, limit,
 	var messages []*models.Message
 	for rows.Next() {
 		m := &models.Message{}
+		var metadata []byte
 		var username, fullName, avatarURL sql.NullString
 		if err := rows.Scan(
 			&m.ID, &m.Content, &m.SenderID, &m.ChannelID, &m.DMID, &m.ParentMessageID, &m.EditedAt, &m.DeletedAt, &m.CreatedAt, &m.UpdatedAt,
-			&username, &avatarURL, &fullName, &m.ReplyCount,
+			&m.ReplyCount, &m.LastReplyAt, pq.Array(&m.ReplyUserIDs), &m.AlsoSentToChannel, &m.Type, &metadata,
+			&username, &avatarURL, &fullName,
 		); err != nil {
 			return nil, err
 		}
+		m.Metadata = metadata
 
 		if username.Valid {
 			m.Sender = &models.User{
@@ -126,11 +173,11 @@ func (r *postgresMessageRepository) ListByChannelID(channelID uuid.UUID, limit,
 func (r *postgresMessageRepository) ListByDMID(dmID uuid.UUID, limit, offset int) ([]*models.Message, error) {
 	query := `
 		SELECT m.id, m.content, m.sender_id, m.channel_id, m.dm_id, m.parent_message_id, m.edited_at, m.deleted_at, m.created_at, m.updated_at,
-		       u.username, u.avatar_url, u.full_name,
-		       (SELECT COUNT(*) FROM messages WHERE parent_message_id = m.id) as reply_count
+		       m.reply_count, m.last_reply_at, m.reply_user_ids, m.also_sent_to_channel, m.type, m.metadata,
+		       u.username, u.avatar_url, u.full_name
 		FROM messages m
 		LEFT JOIN users u ON m.sender_id = u.id
-		WHERE m.dm_id = $1 AND m.parent_message_id IS NULL
+		WHERE m.dm_id = $1 AND (m.parent_message_id IS NULL OR m.also_sent_to_channel = true)
 		ORDER BY m.created_at DESC
 		LIMIT $2 OFFSET $3
 	`
@@ -143,13 +190,16 @@ func (r *postgresMessageRepository) ListByDMID(dmID uuid.UUID, limit, offset int
 	var messages []*models.Message
 	for rows.Next() {
 		m := &models.Message{}
+		var metadata []byte
 		var username, fullName, avatarURL sql.NullString
 		if err := rows.Scan(
 			&m.ID, &m.Content, &m.SenderID, &m.ChannelID, &m.DMID, &m.ParentMessageID, &m.EditedAt, &m.DeletedAt, &m.CreatedAt, &m.UpdatedAt,
-			&username, &avatarURL, &fullName, &m.ReplyCount,
+			&m.ReplyCount, &m.LastReplyAt, pq.Array(&m.ReplyUserIDs), &m.AlsoSentToChannel, &m.Type, &metadata,
+			&username, &avatarURL, &fullName,
 		); err != nil {
 			return nil, err
 		}
+		m.Metadata = metadata
 
 		if username.Valid {
 			m.Sender = &models.User{
@@ -197,7 +247,7 @@ func (r *postgresMessageRepository) attachReactions(messages []*models.Message)
 		WHERE r.message_id = ANY($1)
 		ORDER BY r.created_at ASC
 	`
-	rows, err := r.db.Query(query, messageIDs)
+	rows, err := r.db.Query(query, pq.Array(messageIDs))
 	if err != nil {
 		return err
 	}
@@ -234,7 +284,7 @@ func (r *postgresMessageRepository) attachAttachments(messages []*models.Message
 		WHERE message_id = ANY($1)
 		ORDER BY uploaded_at ASC
 	`
-	rows, err := r.db.Query(query, messageIDs)
+	rows, err := r.db.Query(query, pq.Array(messageIDs))
 	if err != nil {
 		return err
 	}
@@ -257,6 +307,7 @@ func (r *postgresMessageRepository) attachAttachments(messages []*models.Message
 func (r *postgresMessageRepository) ListReplies(parentID uuid.UUID) ([]*models.Message, error) {
 	query := `
 		SELECT m.id, m.content, m.sender_id, m.channel_id, m.dm_id, m.parent_message_id, m.edited_at, m.deleted_at, m.created_at, m.updated_at,
+		       m.reply_count, m.last_reply_at, m.reply_user_ids, m.also_sent_to_channel, m.type, m.metadata,
 		       u.username, u.avatar_url, u.full_name
 		FROM messages m
 		LEFT JOIN users u ON m.sender_id = u.id
@@ -272,13 +323,16 @@ func (r *postgresMessageRepository) ListReplies(parentID uuid.UUID) ([]*models.M
 	var messages []*models.Message
 	for rows.Next() {
 		m := &models.Message{}
+		var metadata []byte
 		var username, fullName, avatarURL sql.NullString
 		if err := rows.Scan(
 			&m.ID, &m.Content, &m.SenderID, &m.ChannelID, &m.DMID, &m.ParentMessageID, &m.EditedAt, &m.DeletedAt, &m.CreatedAt, &m.UpdatedAt,
+			&m.ReplyCount, &m.LastReplyAt, pq.Array(&m.ReplyUserIDs), &m.AlsoSentToChannel, &m.Type, &metadata,
 			&username, &avatarURL, &fullName,
 		); err != nil {
 			return nil, err
 		}
+		m.Metadata = metadata
 
 		if username.Valid {
 			m.Sender = &models.User{
@@ -316,32 +370,82 @@ func (r *postgresMessageRepository) Update(message *models.Message) error {
 }
 
 func (r *postgresMessageRepository) SoftDelete(id uuid.UUID) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	query := `
 		UPDATE messages
 		SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING parent_message_id
 	`
-	_, err := r.db.Exec(query, id)
-	return err
+	var parentID *uuid.UUID
+	err = tx.QueryRow(query, id).Scan(&parentID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Recompute the parent's thread summary from the remaining replies
+	if parentID != nil {
+		summaryQuery := `
+			UPDATE messages p
+			SET reply_count = s.reply_count,
+			    last_reply_at = s.last_reply_at,
+			    reply_user_ids = ARRAY(
+			        SELECT r.sender_id
+			        FROM messages r
+			        WHERE r.parent_message_id = p.id AND r.deleted_at IS NULL AND r.sender_id IS NOT NULL
+			        GROUP BY r.sender_id
+			        ORDER BY MAX(r.created_at) DESC
+			        LIMIT $2
+			    )
+			FROM (
+			    SELECT COUNT(*) AS reply_count, MAX(created_at) AS last_reply_at
+			    FROM messages
+			    WHERE parent_message_id = $1 AND deleted_at IS NULL
+			) s
+			WHERE p.id = $1
+		`
+		if _, err := tx.Exec(summaryQuery, *parentID, threadReplierLimit); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
 
-func (r *postgresMessageRepository) Search(workspaceID uuid.UUID, query string, limit, offset int) ([]*models.Message, error) {
+func (r *postgresMessageRepository) Search(workspaceID, userID uuid.UUID, query string, limit, offset int) ([]*models.Message, error) {
 	sqlQuery := `
 		SELECT m.id, m.content, m.sender_id, m.channel_id, m.dm_id, m.parent_message_id, m.edited_at, m.deleted_at, m.created_at, m.updated_at,
+		       m.reply_count, m.last_reply_at, m.reply_user_ids, m.also_sent_to_channel, m.type, m.metadata,
 		       u.username, u.avatar_url, u.full_name
 		FROM messages m
 		JOIN users u ON m.sender_id = u.id
 		WHERE (
-			m.channel_id IN (SELECT id FROM channels WHERE workspace_id = $1)
-			OR 
-			m.dm_id IN (SELECT id FROM direct_messages WHERE workspace_id = $1)
+			m.channel_id IN (
+				SELECT c.id FROM channels c
+				WHERE c.workspace_id = $1 AND (c.is_private = false OR EXISTS(
+					SELECT 1 FROM channel_members cm WHERE cm.channel_id = c.id AND cm.user_id = $5))
+			)
+			OR
+			m.dm_id IN (
+				SELECT d.id FROM direct_messages d
+				JOIN dm_participants dp ON dp.dm_id = d.id AND dp.user_id = $5
+				WHERE d.workspace_id = $1
+			)
 		)
 		AND to_tsvector('english', m.content) @@ plainto_tsquery('english', $2)
-		AND m.deleted_at IS NULL
+		AND m.deleted_at IS NULL AND m.type = 'user'
 		ORDER BY ts_rank(to_tsvector('english', m.content), plainto_tsquery('english', $2)) DESC
 		LIMIT $3 OFFSET $4
 	`
-	rows, err := r.db.Query(sqlQuery, workspaceID, query, limit, offset)
+	rows, err := r.db.Query(sqlQuery, workspaceID, query, limit, offset, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -350,13 +454,16 @@ func (r *postgresMessageRepository) Search(workspaceID uuid.UUID, query string,
 	var messages []*models.Message
 	for rows.Next() {
 		m := &models.Message{}
+		var metadata []byte
 		var username, fullName, avatarURL sql.NullString
 		if err := rows.Scan(
 			&m.ID, &m.Content, &m.SenderID, &m.ChannelID, &m.DMID, &m.ParentMessageID, &m.EditedAt, &m.DeletedAt, &m.CreatedAt, &m.UpdatedAt,
+			&m.ReplyCount, &m.LastReplyAt, pq.Array(&m.ReplyUserIDs), &m.AlsoSentToChannel, &m.Type, &metadata,
 			&username, &avatarURL, &fullName,
 		); err != nil {
 			return nil, err
 		}
+		m.Metadata = metadata
 
 		if username.Valid {
 			m.Sender = &models.User{
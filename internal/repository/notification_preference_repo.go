@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/database"
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type NotificationPreferenceRepository interface {
+	// Per-conversation preferences; exactly one of channelID and dmID is set
+	Get(userID uuid.UUID, channelID, dmID *uuid.UUID) (*models.NotificationPreference, error)
+	Upsert(pref *models.NotificationPreference) error
+	ListForConversation(channelID, dmID *uuid.UUID, userIDs []uuid.UUID) ([]*models.NotificationPreference, error)
+
+	// Per-user workspace defaults
+	GetUserSettings(userID, workspaceID uuid.UUID) (*models.UserNotificationSettings, error)
+	UpsertUserSettings(settings *models.UserNotificationSettings) error
+	ListUserSettings(workspaceID uuid.UUID, userIDs []uuid.UUID) ([]*models.UserNotificationSettings, error)
+}
+
+type postgresNotificationPreferenceRepository struct {
+	db *database.DB
+}
+
+func NewNotificationPreferenceRepository(db *database.DB) NotificationPreferenceRepository {
+	return &postgresNotificationPreferenceRepository{db: db}
+}
+
+func (r *postgresNotificationPreferenceRepository) Get(userID uuid.UUID, channelID, dmID *uuid.UUID) (*models.NotificationPreference, error) {
+	pref := &models.NotificationPreference{}
+	query := `
+		SELECT id, user_id, channel_id, dm_id, level, muted, muted_until, created_at, updated_at
+		FROM notification_preferences
+		WHERE user_id = $1 AND (channel_id = $2 OR dm_id = $3)
+	`
+	err := r.db.QueryRow(query, userID, channelID, dmID).Scan(
+		&pref.ID, &pref.UserID, &pref.ChannelID, &pref.DMID, &pref.Level,
+		&pref.Muted, &pref.MutedUntil, &pref.CreatedAt, &pref.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pref, nil
+}
+
+func (r *postgresNotificationPreferenceRepository) Upsert(pref *models.NotificationPreference) error {
+	conflict := "(user_id, channel_id)"
+	if pref.DMID != nil {
+		conflict = "(user_id, dm_id)"
+	}
+
+	query := `
+		INSERT INTO notification_preferences (id, user_id, channel_id, dm_id, level, muted, muted_until)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT ` + conflict + ` DO UPDATE
+		SET level = EXCLUDED.level, muted = EXCLUDED.muted, muted_until = EXCLUDED.muted_until, updated_at = CURRENT_TIMESTAMP
+		RETURNING id, created_at, updated_at
+	`
+	return r.db.QueryRow(
+		query,
+		pref.ID,
+		pref.UserID,
+		pref.ChannelID,
+		pref.DMID,
+		pref.Level,
+		pref.Muted,
+		pref.MutedUntil,
+	).Scan(&pref.ID, &pref.CreatedAt, &pref.UpdatedAt)
+}
+
+func (r *postgresNotificationPreferenceRepository) ListForConversation(channelID, dmID *uuid.UUID, userIDs []uuid.UUID) ([]*models.NotificationPreference, error) {
+	query := `
+		SELECT id, user_id, channel_id, dm_id, level, muted, muted_until, created_at, updated_at
+		FROM notification_preferences
+		WHERE (channel_id = $1 OR dm_id = $2) AND user_id = ANY($3)
+	`
+	rows, err := r.db.Query(query, channelID, dmID, pq.Array(userIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prefs []*models.NotificationPreference
+	for rows.Next() {
+		pref := &models.NotificationPreference{}
+		if err := rows.Scan(
+			&pref.ID, &pref.UserID, &pref.ChannelID, &pref.DMID, &pref.Level,
+			&pref.Muted, &pref.MutedUntil, &pref.CreatedAt, &pref.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		prefs = append(prefs, pref)
+	}
+	return prefs, nil
+}
+
+func (r *postgresNotificationPreferenceRepository) GetUserSettings(userID, workspaceID uuid.UUID) (*models.UserNotificationSettings, error) {
+	settings := &models.UserNotificationSettings{}
+	query := `
+		SELECT user_id, workspace_id, default_level, keywords, updated_at
+		FROM user_notification_settings
+		WHERE user_id = $1 AND workspace_id = $2
+	`
+	err := r.db.QueryRow(query, userID, workspaceID).Scan(
+		&settings.UserID, &settings.WorkspaceID, &settings.DefaultLevel, pq.Array(&settings.Keywords), &settings.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+func (r *postgresNotificationPreferenceRepository) UpsertUserSettings(settings *models.UserNotificationSettings) error {
+	if settings.Keywords == nil {
+		settings.Keywords = []string{}
+	}
+
+	query := `
+		INSERT INTO user_notification_settings (user_id, workspace_id, default_level, keywords)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, workspace_id) DO UPDATE
+		SET default_level = EXCLUDED.default_level, keywords = EXCLUDED.keywords, updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at
+	`
+	return r.db.QueryRow(
+		query,
+		settings.UserID,
+		settings.WorkspaceID,
+		settings.DefaultLevel,
+		pq.Array(settings.Keywords),
+	).Scan(&settings.UpdatedAt)
+}
+
+func (r *postgresNotificationPreferenceRepository) ListUserSettings(workspaceID uuid.UUID, userIDs []uuid.UUID) ([]*models.UserNotificationSettings, error) {
+	query := `
+		SELECT user_id, workspace_id, default_level, keywords, updated_at
+		FROM user_notification_settings
+		WHERE workspace_id = $1 AND user_id = ANY($2)
+	`
+	rows, err := r.db.Query(query, workspaceID, pq.Array(userIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*models.UserNotificationSettings
+	for rows.Next() {
+		settings := &models.UserNotificationSettings{}
+		if err := rows.Scan(
+			&settings.UserID, &settings.WorkspaceID, &settings.DefaultLevel, pq.Array(&settings.Keywords), &settings.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		list = append(list, settings)
+	}
+	return list, nil
+}
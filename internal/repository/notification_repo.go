@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/database"
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type NotificationRepository interface {
+	Create(notification *models.Notification) error
+	// ListByUser returns notifications older than the (beforeTime, beforeID) cursor, newest first.
+	ListByUser(userID uuid.UUID, workspaceID *uuid.UUID, unreadOnly bool, beforeTime *time.Time, beforeID *uuid.UUID, limit int) ([]*models.Notification, error)
+	CountUnread(userID uuid.UUID, workspaceID *uuid.UUID) (int, error)
+	MarkRead(id, userID uuid.UUID) (bool, error)
+	MarkAllRead(userID uuid.UUID, workspaceID *uuid.UUID) error
+
+	// Notifications held back during DND
+	// ListHeldUserIDs pages through the users with held notifications in
+	// user ID order, starting after afterID when it is set
+	ListHeldUserIDs(afterID *uuid.UUID, limit int) ([]uuid.UUID, error)
+	ListHeld(userID uuid.UUID, limit int) ([]*models.Notification, error)
+	MarkDelivered(ids []uuid.UUID) error
+}
+
+type postgresNotificationRepository struct {
+	db *database.DB
+}
+
+func NewNotificationRepository(db *database.DB) NotificationRepository {
+	return &postgresNotificationRepository{db: db}
+}
+
+func (r *postgresNotificationRepository) Create(n *models.Notification) error {
+	data := "{}"
+	if len(n.Data) > 0 {
+		data = string(n.Data)
+	}
+
+	query := `
+		INSERT INTO notifications (id, user_id, workspace_id, type, actor_id, message_id, channel_id, dm_id, data, urgent, delivered_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING created_at
+	`
+	return r.db.QueryRow(
+		query,
+		n.ID,
+		n.UserID,
+		n.WorkspaceID,
+		n.Type,
+		n.ActorID,
+		n.MessageID,
+		n.ChannelID,
+		n.DMID,
+		data,
+		n.Urgent,
+		n.DeliveredAt,
+	).Scan(&n.CreatedAt)
+}
+
+func (r *postgresNotificationRepository) ListByUser(userID uuid.UUID, workspaceID *uuid.UUID, unreadOnly bool, beforeTime *time.Time, beforeID *uuid.UUID, limit int) ([]*models.Notification, error) {
+	query := `
+		SELECT n.id, n.user_id, n.workspace_id, n.type, n.actor_id, n.message_id, n.channel_id, n.dm_id, n.data,
+		       n.urgent, n.read_at, n.delivered_at, n.created_at,
+		       u.username, u.avatar_url, u.full_name
+		FROM notifications n
+		LEFT JOIN users u ON n.actor_id = u.id
+		WHERE n.user_id = $1
+		AND ($2::uuid IS NULL OR n.workspace_id = $2)
+		AND ($3 = false OR n.read_at IS NULL)
+		AND ($4::timestamp IS NULL OR (n.created_at, n.id) < ($4, $5::uuid))
+		ORDER BY n.created_at DESC, n.id DESC
+		LIMIT $6
+	`
+	rows, err := r.db.Query(query, userID, workspaceID, unreadOnly, beforeTime, beforeID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanNotifications(rows)
+}
+
+func scanNotifications(rows *sql.Rows) ([]*models.Notification, error) {
+	var notifications []*models.Notification
+	for rows.Next() {
+		n := &models.Notification{}
+		var data []byte
+		var username, fullName, avatarURL sql.NullString
+		if err := rows.Scan(
+			&n.ID, &n.UserID, &n.WorkspaceID, &n.Type, &n.ActorID, &n.MessageID, &n.ChannelID, &n.DMID, &data,
+			&n.Urgent, &n.ReadAt, &n.DeliveredAt, &n.CreatedAt,
+			&username, &avatarURL, &fullName,
+		); err != nil {
+			return nil, err
+		}
+		n.Data = data
+
+		if username.Valid {
+			n.Actor = &models.User{
+				ID:       *n.ActorID,
+				Username: username.String,
+			}
+			if avatarURL.Valid {
+				n.Actor.AvatarURL = &avatarURL.String
+			}
+			if fullName.Valid {
+				n.Actor.FullName = &fullName.String
+			}
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, nil
+}
+
+func (r *postgresNotificationRepository) CountUnread(userID uuid.UUID, workspaceID *uuid.UUID) (int, error) {
+	var count int
+	query := `
+		SELECT COUNT(*)
+		FROM notifications
+		WHERE user_id = $1 AND read_at IS NULL
+		AND ($2::uuid IS NULL OR workspace_id = $2)
+	`
+	err := r.db.QueryRow(query, userID, workspaceID).Scan(&count)
+	return count, err
+}
+
+func (r *postgresNotificationRepository) MarkRead(id, userID uuid.UUID) (bool, error) {
+	query := `
+		UPDATE notifications
+		SET read_at = COALESCE(read_at, CURRENT_TIMESTAMP)
+		WHERE id = $1 AND user_id = $2
+	`
+	result, err := r.db.Exec(query, id, userID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (r *postgresNotificationRepository) MarkAllRead(userID uuid.UUID, workspaceID *uuid.UUID) error {
+	query := `
+		UPDATE notifications
+		SET read_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND read_at IS NULL
+		AND ($2::uuid IS NULL OR workspace_id = $2)
+	`
+	_, err := r.db.Exec(query, userID, workspaceID)
+	return err
+}
+
+func (r *postgresNotificationRepository) ListHeldUserIDs(afterID *uuid.UUID, limit int) ([]uuid.UUID, error) {
+	query := `
+		SELECT DISTINCT user_id
+		FROM notifications
+		WHERE delivered_at IS NULL AND ($1::uuid IS NULL OR user_id > $1)
+		ORDER BY user_id
+		LIMIT $2
+	`
+	rows, err := r.db.Query(query, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []uuid.UUID
+	for rows.Next() {
+		var userID uuid.UUID
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, nil
+}
+
+func (r *postgresNotificationRepository) ListHeld(userID uuid.UUID, limit int) ([]*models.Notification, error) {
+	query := `
+		SELECT n.id, n.user_id, n.workspace_id, n.type, n.actor_id, n.message_id, n.channel_id, n.dm_id, n.data,
+		       n.urgent, n.read_at, n.delivered_at, n.created_at,
+		       u.username, u.avatar_url, u.full_name
+		FROM notifications n
+		LEFT JOIN users u ON n.actor_id = u.id
+		WHERE n.user_id = $1 AND n.delivered_at IS NULL
+		ORDER BY n.created_at ASC
+		LIMIT $2
+	`
+	rows, err := r.db.Query(query, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanNotifications(rows)
+}
+
+func (r *postgresNotificationRepository) MarkDelivered(ids []uuid.UUID) error {
+	query := `
+		UPDATE notifications
+		SET delivered_at = CURRENT_TIMESTAMP
+		WHERE id = ANY($1) AND delivered_at IS NULL
+	`
+	_, err := r.db.Exec(query, pq.Array(ids))
+	return err
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/database"
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+	"github.com/google/uuid"
+)
+
+type PushRepository interface {
+	// UpsertSubscription registers a device; an existing endpoint is moved to the user.
+	UpsertSubscription(sub *models.PushSubscription) error
+	DeleteSubscription(userID uuid.UUID, endpoint string) (bool, error)
+	DeleteSubscriptionByID(id uuid.UUID) error
+	ListSubscriptions(userID uuid.UUID) ([]*models.PushSubscription, error)
+	DeleteExpiredSubscriptions(now time.Time) (int64, error)
+
+	// EnqueueForUser queues payload for every subscription of the user.
+	EnqueueForUser(userID uuid.UUID, payload, urgency string) (int64, error)
+	// ClaimDueJobs returns due jobs and leases them until leaseUntil so other
+	// workers skip them.
+	ClaimDueJobs(now, leaseUntil time.Time, limit int) ([]*models.PushJob, error)
+	DeleteJob(id uuid.UUID) error
+	RetryJob(id uuid.UUID, nextAttemptAt time.Time, lastError string) error
+	MarkSubscriptionUsed(id uuid.UUID) error
+}
+
+type postgresPushRepository struct {
+	db *database.DB
+}
+
+func NewPushRepository(db *database.DB) PushRepository {
+	return &postgresPushRepository{db: db}
+}
+
+func (r *postgresPushRepository) UpsertSubscription(sub *models.PushSubscription) error {
+	query := `
+		INSERT INTO push_subscriptions (user_id, endpoint, p256dh, auth, user_agent, expiration_time)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (endpoint) DO UPDATE
+		SET user_id = EXCLUDED.user_id, p256dh = EXCLUDED.p256dh, auth = EXCLUDED.auth,
+		    user_agent = EXCLUDED.user_agent, expiration_time = EXCLUDED.expiration_time
+		RETURNING id, created_at, last_used_at
+	`
+	return r.db.QueryRow(
+		query,
+		sub.UserID,
+		sub.Endpoint,
+		sub.P256dh,
+		sub.Auth,
+		sub.UserAgent,
+		sub.ExpirationTime,
+	).Scan(&sub.ID, &sub.CreatedAt, &sub.LastUsedAt)
+}
+
+func (r *postgresPushRepository) DeleteSubscription(userID uuid.UUID, endpoint string) (bool, error) {
+	query := `DELETE FROM push_subscriptions WHERE user_id = $1 AND endpoint = $2`
+	result, err := r.db.Exec(query, userID, endpoint)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+func (r *postgresPushRepository) DeleteSubscriptionByID(id uuid.UUID) error {
+	query := `DELETE FROM push_subscriptions WHERE id = $1`
+	_, err := r.db.Exec(query, id)
+	return err
+}
+
+func (r *postgresPushRepository) ListSubscriptions(userID uuid.UUID) ([]*models.PushSubscription, error) {
+	query := `
+		SELECT id, user_id, endpoint, p256dh, auth, user_agent, expiration_time, created_at, last_used_at
+		FROM push_subscriptions
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []*models.PushSubscription
+	for rows.Next() {
+		sub := &models.PushSubscription{}
+		if err := rows.Scan(
+			&sub.ID, &sub.UserID, &sub.Endpoint, &sub.P256dh, &sub.Auth,
+			&sub.UserAgent, &sub.ExpirationTime, &sub.CreatedAt, &sub.LastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
+
+func (r *postgresPushRepository) DeleteExpiredSubscriptions(now time.Time) (int64, error) {
+	query := `DELETE FROM push_subscriptions WHERE expiration_time IS NOT NULL AND expiration_time <= $1`
+	result, err := r.db.Exec(query, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (r *postgresPushRepository) EnqueueForUser(userID uuid.UUID, payload, urgency string) (int64, error) {
+	query := `
+		INSERT INTO push_jobs (subscription_id, payload, urgency)
+		SELECT id, $2, $3
+		FROM push_subscriptions
+		WHERE user_id = $1 AND (expiration_time IS NULL OR expiration_time > CURRENT_TIMESTAMP)
+	`
+	result, err := r.db.Exec(query, userID, payload, urgency)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (r *postgresPushRepository) ClaimDueJobs(now, leaseUntil time.Time, limit int) ([]*models.PushJob, error) {
+	query := `
+		WITH due AS (
+			SELECT id FROM push_jobs
+			WHERE next_attempt_at <= $1
+			ORDER BY next_attempt_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE push_jobs j
+		SET next_attempt_at = $2
+		FROM due, push_subscriptions s
+		WHERE j.id = due.id AND s.id = j.subscription_id
+		RETURNING j.id, j.subscription_id, j.payload, j.urgency, j.attempts, j.next_attempt_at, j.last_error, j.created_at,
+		          s.id, s.user_id, s.endpoint, s.p256dh, s.auth
+	`
+	rows, err := r.db.Query(query, now, leaseUntil, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []*models.PushJob
+	for rows.Next() {
+		job := &models.PushJob{Subscription: &models.PushSubscription{}}
+		if err := rows.Scan(
+			&job.ID, &job.SubscriptionID, &job.Payload, &job.Urgency, &job.Attempts, &job.NextAttemptAt, &job.LastError, &job.CreatedAt,
+			&job.Subscription.ID, &job.Subscription.UserID, &job.Subscription.Endpoint, &job.Subscription.P256dh, &job.Subscription.Auth,
+		); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+func (r *postgresPushRepository) DeleteJob(id uuid.UUID) error {
+	query := `DELETE FROM push_jobs WHERE id = $1`
+	_, err := r.db.Exec(query, id)
+	return err
+}
+
+func (r *postgresPushRepository) RetryJob(id uuid.UUID, nextAttemptAt time.Time, lastError string) error {
+	query := `
+		UPDATE push_jobs
+		SET attempts = attempts + 1, next_attempt_at = $2, last_error = $3
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(query, id, nextAttemptAt, lastError)
+	return err
+}
+
+func (r *postgresPushRepository) MarkSubscriptionUsed(id uuid.UUID) error {
+	query := `UPDATE push_subscriptions SET last_used_at = CURRENT_TIMESTAMP WHERE id = $1`
+	_, err := r.db.Exec(query, id)
+	return err
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/database"
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type RoleRepository interface {
+	Create(role *models.WorkspaceRole) error
+	FindByID(id uuid.UUID) (*models.WorkspaceRole, error)
+	ListByWorkspace(workspaceID uuid.UUID) ([]*models.WorkspaceRole, error)
+	Update(role *models.WorkspaceRole) error
+	// Delete removes the role; members holding it keep only their built-in role
+	Delete(id uuid.UUID) error
+	// AssignToMember sets or, with a nil roleID, clears the member's custom role
+	AssignToMember(workspaceID, userID uuid.UUID, roleID *uuid.UUID) error
+}
+
+type postgresRoleRepository struct {
+	db *database.DB
+}
+
+func NewRoleRepository(db *database.DB) RoleRepository {
+	return &postgresRoleRepository{db: db}
+}
+
+func (r *postgresRoleRepository) Create(role *models.WorkspaceRole) error {
+	query := `
+		INSERT INTO workspace_roles (id, workspace_id, name, description, permissions)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at, updated_at
+	`
+	return r.db.QueryRow(
+		query,
+		role.ID,
+		role.WorkspaceID,
+		role.Name,
+		role.Description,
+		pq.Array(role.Permissions),
+	).Scan(&role.CreatedAt, &role.UpdatedAt)
+}
+
+func (r *postgresRoleRepository) FindByID(id uuid.UUID) (*models.WorkspaceRole, error) {
+	role := &models.WorkspaceRole{}
+	query := `
+		SELECT id, workspace_id, name, description, permissions, created_at, updated_at
+		FROM workspace_roles WHERE id = $1
+	`
+	err := r.db.QueryRow(query, id).Scan(
+		&role.ID, &role.WorkspaceID, &role.Name, &role.Description, pq.Array(&role.Permissions), &role.CreatedAt, &role.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+func (r *postgresRoleRepository) ListByWorkspace(workspaceID uuid.UUID) ([]*models.WorkspaceRole, error) {
+	query := `
+		SELECT id, workspace_id, name, description, permissions, created_at, updated_at
+		FROM workspace_roles WHERE workspace_id = $1
+		ORDER BY name ASC
+	`
+	rows, err := r.db.Query(query, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []*models.WorkspaceRole
+	for rows.Next() {
+		role := &models.WorkspaceRole{}
+		if err := rows.Scan(
+			&role.ID, &role.WorkspaceID, &role.Name, &role.Description, pq.Array(&role.Permissions), &role.CreatedAt, &role.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
+
+func (r *postgresRoleRepository) Update(role *models.WorkspaceRole) error {
+	query := `
+		UPDATE workspace_roles SET name = $1, description = $2, permissions = $3
+		WHERE id = $4
+		RETURNING updated_at
+	`
+	return r.db.QueryRow(query, role.Name, role.Description, pq.Array(role.Permissions), role.ID).Scan(&role.UpdatedAt)
+}
+
+func (r *postgresRoleRepository) Delete(id uuid.UUID) error {
+	_, err := r.db.Exec(`DELETE FROM workspace_roles WHERE id = $1`, id)
+	return err
+}
+
+func (r *postgresRoleRepository) AssignToMember(workspaceID, userID uuid.UUID, roleID *uuid.UUID) error {
+	query := `UPDATE workspace_members SET custom_role_id = $3 WHERE workspace_id = $1 AND user_id = $2`
+	_, err := r.db.Exec(query, workspaceID, userID, roleID)
+	return err
+}
@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/database"
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type SavedItemRepository interface {
+	Upsert(item *models.SavedItem) error
+	FindByID(id uuid.UUID) (*models.SavedItem, error)
+	FindByUserAndMessage(userID, messageID uuid.UUID) (*models.SavedItem, error)
+	// ListByUser lists the user's saved items in conversations they can still
+	// see; public channels they aren't in only if includePublic
+	ListByUser(workspaceID, userID uuid.UUID, status string, includePublic bool, limit, offset int) ([]*models.SavedItem, error)
+	Update(item *models.SavedItem) error
+	Delete(id uuid.UUID) error
+	ListDueReminders(limit int) ([]*models.SavedItem, error)
+	MarkReminded(id uuid.UUID) error
+}
+
+type postgresSavedItemRepository struct {
+	db *database.DB
+}
+
+func NewSavedItemRepository(db *database.DB) SavedItemRepository {
+	return &postgresSavedItemRepository{db: db}
+}
+
+func (r *postgresSavedItemRepository) Upsert(item *models.SavedItem) error {
+	// Saving an already saved message only refreshes its reminder
+	query := `
+		INSERT INTO saved_items (id, user_id, message_id, workspace_id, status, remind_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id, message_id) DO UPDATE
+		SET remind_at = EXCLUDED.remind_at, reminded_at = NULL, updated_at = CURRENT_TIMESTAMP
+		RETURNING id, status, completed_at, created_at, updated_at
+	`
+	return r.db.QueryRow(
+		query,
+		item.ID,
+		item.UserID,
+		item.MessageID,
+		item.WorkspaceID,
+		item.Status,
+		item.RemindAt,
+	).Scan(&item.ID, &item.Status, &item.CompletedAt, &item.CreatedAt, &item.UpdatedAt)
+}
+
+func (r *postgresSavedItemRepository) FindByID(id uuid.UUID) (*models.SavedItem, error) {
+	item := &models.SavedItem{}
+	query := `
+		SELECT id, user_id, message_id, workspace_id, status, remind_at, reminded_at, completed_at, created_at, updated_at
+		FROM saved_items
+		WHERE id = $1
+	`
+	err := r.db.QueryRow(query, id).Scan(
+		&item.ID, &item.UserID, &item.MessageID, &item.WorkspaceID, &item.Status,
+		&item.RemindAt, &item.RemindedAt, &item.CompletedAt, &item.CreatedAt, &item.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func (r *postgresSavedItemRepository) FindByUserAndMessage(userID, messageID uuid.UUID) (*models.SavedItem, error) {
+	item := &models.SavedItem{}
+	query := `
+		SELECT id, user_id, message_id, workspace_id, status, remind_at, reminded_at, completed_at, created_at, updated_at
+		FROM saved_items
+		WHERE user_id = $1 AND message_id = $2
+	`
+	err := r.db.QueryRow(query, userID, messageID).Scan(
+		&item.ID, &item.UserID, &item.MessageID, &item.WorkspaceID, &item.Status,
+		&item.RemindAt, &item.RemindedAt, &item.CompletedAt, &item.CreatedAt, &item.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func (r *postgresSavedItemRepository) ListByUser(workspaceID, userID uuid.UUID, status string, includePublic bool, limit, offset int) ([]*models.SavedItem, error) {
+	// Filter access here so that pages stay full
+	query := `
+		SELECT s.id, s.user_id, s.message_id, s.workspace_id, s.status, s.remind_at, s.reminded_at, s.completed_at, s.created_at, s.updated_at,
+		       m.id, m.content, m.sender_id, m.channel_id, m.dm_id, m.parent_message_id, m.edited_at, m.deleted_at, m.created_at, m.updated_at,
+		       m.reply_count, m.last_reply_at, m.reply_user_ids, m.also_sent_to_channel, m.type, m.metadata,
+		       u.username, u.avatar_url, u.full_name
+		FROM saved_items s
+		JOIN messages m ON s.message_id = m.id
+		LEFT JOIN users u ON m.sender_id = u.id
+		LEFT JOIN channels c ON m.channel_id = c.id
+		WHERE s.workspace_id = $1 AND s.user_id = $2
+		AND ($3 = '' OR s.status = $3)
+		AND m.deleted_at IS NULL
+		AND (
+			(c.id IS NOT NULL AND (($4 AND c.is_private = false) OR EXISTS(
+				SELECT 1 FROM channel_members cm WHERE cm.channel_id = c.id AND cm.user_id = $2)))
+			OR
+			(m.dm_id IS NOT NULL AND EXISTS(
+				SELECT 1 FROM dm_participants dp WHERE dp.dm_id = m.dm_id AND dp.user_id = $2))
+		)
+		ORDER BY s.created_at DESC
+		LIMIT $5 OFFSET $6
+	`
+	rows, err := r.db.Query(query, workspaceID, userID, status, includePublic, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []*models.SavedItem
+	for rows.Next() {
+		item := &models.SavedItem{}
+		m := &models.Message{}
+		var metadata []byte
+		var username, fullName, avatarURL sql.NullString
+		if err := rows.Scan(
+			&item.ID, &item.UserID, &item.MessageID, &item.WorkspaceID, &item.Status,
+			&item.RemindAt, &item.RemindedAt, &item.CompletedAt, &item.CreatedAt, &item.UpdatedAt,
+			&m.ID, &m.Content, &m.SenderID, &m.ChannelID, &m.DMID, &m.ParentMessageID, &m.EditedAt, &m.DeletedAt, &m.CreatedAt, &m.UpdatedAt,
+			&m.ReplyCount, &m.LastReplyAt, pq.Array(&m.ReplyUserIDs), &m.AlsoSentToChannel, &m.Type, &metadata,
+			&username, &avatarURL, &fullName,
+		); err != nil {
+			return nil, err
+		}
+		m.Metadata = metadata
+
+		if username.Valid {
+			m.Sender = &models.User{
+				ID:       *m.SenderID,
+				Username: username.String,
+			}
+			if avatarURL.Valid {
+				m.Sender.AvatarURL = &avatarURL.String
+			}
+			if fullName.Valid {
+				m.Sender.FullName = &fullName.String
+			}
+		}
+		item.Message = m
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (r *postgresSavedItemRepository) Update(item *models.SavedItem) error {
+	query := `
+		UPDATE saved_items
+		SET status = $1, remind_at = $2, reminded_at = $3, completed_at = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $5
+		RETURNING updated_at
+	`
+	return r.db.QueryRow(
+		query,
+		item.Status,
+		item.RemindAt,
+		item.RemindedAt,
+		item.CompletedAt,
+		item.ID,
+	).Scan(&item.UpdatedAt)
+}
+
+func (r *postgresSavedItemRepository) Delete(id uuid.UUID) error {
+	query := `DELETE FROM saved_items WHERE id = $1`
+	_, err := r.db.Exec(query, id)
+	return err
+}
+
+func (r *postgresSavedItemRepository) ListDueReminders(limit int) ([]*models.SavedItem, error) {
+	query := `
+		SELECT id, user_id, message_id, workspace_id, status, remind_at, reminded_at, completed_at, created_at, updated_at
+		FROM saved_items
+		WHERE remind_at IS NOT NULL AND remind_at <= CURRENT_TIMESTAMP
+		AND reminded_at IS NULL
+		AND status = 'in_progress'
+		ORDER BY remind_at ASC
+		LIMIT $1
+	`
+	rows, err := r.db.Query(query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []*models.SavedItem
+	for rows.Next() {
+		item := &models.SavedItem{}
+		if err := rows.Scan(
+			&item.ID, &item.UserID, &item.MessageID, &item.WorkspaceID, &item.Status,
+			&item.RemindAt, &item.RemindedAt, &item.CompletedAt, &item.CreatedAt, &item.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (r *postgresSavedItemRepository) MarkReminded(id uuid.UUID) error {
+	query := `
+		UPDATE saved_items
+		SET reminded_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(query, id)
+	return err
+}
@@ -0,0 +1,281 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/database"
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type SidebarRepository interface {
+	// Sections
+	CreateSection(section *models.SidebarSection) error
+	FindSection(id uuid.UUID) (*models.SidebarSection, error)
+	UpdateSection(section *models.SidebarSection) error
+	DeleteSection(id uuid.UUID) error
+	// ReorderSections sets each section's position to its index in sectionIDs
+	ReorderSections(userID, workspaceID uuid.UUID, sectionIDs []uuid.UUID) error
+	// ListSections returns the user's sections with their items, leaving out
+	// conversations the user can no longer see
+	ListSections(userID, workspaceID uuid.UUID) ([]*models.SidebarSection, error)
+
+	// Items; exactly one of channelID and dmID is set
+	// PlaceItem puts the conversation in item.SectionID, moving it out of any
+	// other section. A nil position appends it to the end.
+	PlaceItem(item *models.SidebarItem, position *int) error
+	DeleteItem(sectionID, itemID uuid.UUID) (bool, error)
+
+	// Starred conversations
+	Star(star *models.StarredConversation) error
+	Unstar(userID uuid.UUID, channelID, dmID *uuid.UUID) (bool, error)
+	ListStarred(userID, workspaceID uuid.UUID) ([]*models.StarredConversation, error)
+}
+
+type postgresSidebarRepository struct {
+	db *database.DB
+}
+
+func NewSidebarRepository(db *database.DB) SidebarRepository {
+	return &postgresSidebarRepository{db: db}
+}
+
+func (r *postgresSidebarRepository) CreateSection(section *models.SidebarSection) error {
+	query := `
+		INSERT INTO sidebar_sections (id, user_id, workspace_id, name, collapsed, position)
+		VALUES ($1, $2, $3, $4, $5,
+			(SELECT COALESCE(MAX(position) + 1, 0) FROM sidebar_sections WHERE user_id = $2 AND workspace_id = $3))
+		RETURNING position, created_at, updated_at
+	`
+	return r.db.QueryRow(
+		query,
+		section.ID,
+		section.UserID,
+		section.WorkspaceID,
+		section.Name,
+		section.Collapsed,
+	).Scan(&section.Position, &section.CreatedAt, &section.UpdatedAt)
+}
+
+func (r *postgresSidebarRepository) FindSection(id uuid.UUID) (*models.SidebarSection, error) {
+	s := &models.SidebarSection{}
+	query := `
+		SELECT id, user_id, workspace_id, name, position, collapsed, created_at, updated_at
+		FROM sidebar_sections WHERE id = $1
+	`
+	err := r.db.QueryRow(query, id).Scan(
+		&s.ID, &s.UserID, &s.WorkspaceID, &s.Name, &s.Position, &s.Collapsed, &s.CreatedAt, &s.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (r *postgresSidebarRepository) UpdateSection(section *models.SidebarSection) error {
+	query := `UPDATE sidebar_sections SET name = $1, collapsed = $2 WHERE id = $3`
+	_, err := r.db.Exec(query, section.Name, section.Collapsed, section.ID)
+	return err
+}
+
+func (r *postgresSidebarRepository) DeleteSection(id uuid.UUID) error {
+	_, err := r.db.Exec(`DELETE FROM sidebar_sections WHERE id = $1`, id)
+	return err
+}
+
+func (r *postgresSidebarRepository) ReorderSections(userID, workspaceID uuid.UUID, sectionIDs []uuid.UUID) error {
+	query := `
+		UPDATE sidebar_sections
+		SET position = array_position($3::uuid[], id) - 1
+		WHERE user_id = $1 AND workspace_id = $2 AND id = ANY($3)
+	`
+	_, err := r.db.Exec(query, userID, workspaceID, pq.Array(sectionIDs))
+	return err
+}
+
+func (r *postgresSidebarRepository) ListSections(userID, workspaceID uuid.UUID) ([]*models.SidebarSection, error) {
+	query := `
+		SELECT id, user_id, workspace_id, name, position, collapsed, created_at, updated_at
+		FROM sidebar_sections
+		WHERE user_id = $1 AND workspace_id = $2
+		ORDER BY position ASC, created_at ASC
+	`
+	rows, err := r.db.Query(query, userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sections []*models.SidebarSection
+	byID := make(map[uuid.UUID]*models.SidebarSection)
+	for rows.Next() {
+		s := &models.SidebarSection{Items: []*models.SidebarItem{}}
+		if err := rows.Scan(&s.ID, &s.UserID, &s.WorkspaceID, &s.Name, &s.Position, &s.Collapsed, &s.CreatedAt, &s.UpdatedAt); err != nil {
+			return nil, err
+		}
+		sections = append(sections, s)
+		byID[s.ID] = s
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(sections) == 0 {
+		return sections, nil
+	}
+
+	itemsQuery := `
+		SELECT i.id, i.section_id, i.user_id, i.channel_id, i.dm_id, i.position, i.created_at
+		FROM sidebar_items i
+		JOIN sidebar_sections s ON i.section_id = s.id
+		LEFT JOIN channels c ON i.channel_id = c.id
+		WHERE s.user_id = $1 AND s.workspace_id = $2
+		AND (
+			(i.channel_id IS NOT NULL AND (c.is_private = false OR EXISTS(
+				SELECT 1 FROM channel_members cm WHERE cm.channel_id = c.id AND cm.user_id = $1)))
+			OR
+			(i.dm_id IS NOT NULL AND EXISTS(
+				SELECT 1 FROM dm_participants dp WHERE dp.dm_id = i.dm_id AND dp.user_id = $1))
+		)
+		ORDER BY i.position ASC, i.created_at ASC
+	`
+	itemRows, err := r.db.Query(itemsQuery, userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		item := &models.SidebarItem{}
+		if err := itemRows.Scan(&item.ID, &item.SectionID, &item.UserID, &item.ChannelID, &item.DMID, &item.Position, &item.CreatedAt); err != nil {
+			return nil, err
+		}
+		if s, ok := byID[item.SectionID]; ok {
+			s.Items = append(s.Items, item)
+		}
+	}
+	return sections, nil
+}
+
+func (r *postgresSidebarRepository) PlaceItem(item *models.SidebarItem, position *int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	conflict := "(user_id, channel_id)"
+	if item.DMID != nil {
+		conflict = "(user_id, dm_id)"
+	}
+
+	// 1. Make room at the requested position, or find the end of the section
+	if position != nil {
+		item.Position = *position
+		shiftQuery := `
+			UPDATE sidebar_items SET position = position + 1
+			WHERE section_id = $1 AND position >= $2
+			AND NOT (channel_id IS NOT DISTINCT FROM $3 AND dm_id IS NOT DISTINCT FROM $4)
+		`
+		if _, err := tx.Exec(shiftQuery, item.SectionID, item.Position, item.ChannelID, item.DMID); err != nil {
+			return err
+		}
+	} else {
+		endQuery := `SELECT COALESCE(MAX(position) + 1, 0) FROM sidebar_items WHERE section_id = $1`
+		if err := tx.QueryRow(endQuery, item.SectionID).Scan(&item.Position); err != nil {
+			return err
+		}
+	}
+
+	// 2. Insert the item, or move it if it is already in a section
+	query := `
+		INSERT INTO sidebar_items (id, section_id, user_id, channel_id, dm_id, position)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT ` + conflict + ` DO UPDATE
+		SET section_id = EXCLUDED.section_id, position = EXCLUDED.position
+		RETURNING id, created_at
+	`
+	err = tx.QueryRow(
+		query,
+		item.ID,
+		item.SectionID,
+		item.UserID,
+		item.ChannelID,
+		item.DMID,
+		item.Position,
+	).Scan(&item.ID, &item.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *postgresSidebarRepository) DeleteItem(sectionID, itemID uuid.UUID) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM sidebar_items WHERE id = $1 AND section_id = $2`, itemID, sectionID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+func (r *postgresSidebarRepository) Star(star *models.StarredConversation) error {
+	conflict := "(user_id, channel_id)"
+	if star.DMID != nil {
+		conflict = "(user_id, dm_id)"
+	}
+
+	// The no-op update makes RETURNING yield the existing row
+	query := `
+		INSERT INTO starred_conversations (id, user_id, workspace_id, channel_id, dm_id)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT ` + conflict + ` DO UPDATE SET workspace_id = EXCLUDED.workspace_id
+		RETURNING id, created_at
+	`
+	return r.db.QueryRow(
+		query,
+		star.ID,
+		star.UserID,
+		star.WorkspaceID,
+		star.ChannelID,
+		star.DMID,
+	).Scan(&star.ID, &star.CreatedAt)
+}
+
+func (r *postgresSidebarRepository) Unstar(userID uuid.UUID, channelID, dmID *uuid.UUID) (bool, error) {
+	query := `DELETE FROM starred_conversations WHERE user_id = $1 AND (channel_id = $2 OR dm_id = $3)`
+	result, err := r.db.Exec(query, userID, channelID, dmID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+func (r *postgresSidebarRepository) ListStarred(userID, workspaceID uuid.UUID) ([]*models.StarredConversation, error) {
+	query := `
+		SELECT id, user_id, workspace_id, channel_id, dm_id, created_at
+		FROM starred_conversations
+		WHERE user_id = $1 AND workspace_id = $2
+		ORDER BY created_at ASC
+	`
+	rows, err := r.db.Query(query, userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	starred := []*models.StarredConversation{}
+	for rows.Next() {
+		s := &models.StarredConversation{}
+		if err := rows.Scan(&s.ID, &s.UserID, &s.WorkspaceID, &s.ChannelID, &s.DMID, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		starred = append(starred, s)
+	}
+	return starred, nil
+}
@@ -0,0 +1,229 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/database"
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// TeardownSteps are the kinds of rows a deleted workspace loses, in order,
+// after its files. Whatever is left goes with the workspace row at the end.
+var TeardownSteps = []string{"messages", "notifications", "channels", "dms", "members", "invites", "audit_log"}
+
+// teardownQueries delete one batch of a workspace's rows per step; $1 is the
+// workspace and $2 the batch size
+var teardownQueries = map[string]string{
+	// Replies go before the messages they reply to, so a batch never
+	// cascades into a whole thread
+	"messages": `
+		DELETE FROM messages WHERE id IN (
+			SELECT m.id FROM messages m
+			WHERE m.channel_id IN (SELECT id FROM channels WHERE workspace_id = $1)
+			OR m.dm_id IN (SELECT id FROM direct_messages WHERE workspace_id = $1)
+			ORDER BY (m.parent_message_id IS NULL)
+			LIMIT $2
+		)
+		RETURNING id
+	`,
+	"notifications": `DELETE FROM notifications WHERE id IN (SELECT id FROM notifications WHERE workspace_id = $1 LIMIT $2) RETURNING id`,
+	"channels":      `DELETE FROM channels WHERE id IN (SELECT id FROM channels WHERE workspace_id = $1 LIMIT $2) RETURNING id`,
+	"dms":           `DELETE FROM direct_messages WHERE id IN (SELECT id FROM direct_messages WHERE workspace_id = $1 LIMIT $2) RETURNING id`,
+	"members":       `DELETE FROM workspace_members WHERE id IN (SELECT id FROM workspace_members WHERE workspace_id = $1 LIMIT $2) RETURNING id`,
+	"invites":       `DELETE FROM workspace_invites WHERE id IN (SELECT id FROM workspace_invites WHERE workspace_id = $1 LIMIT $2) RETURNING id`,
+	"audit_log":     `DELETE FROM audit_log WHERE id IN (SELECT id FROM audit_log WHERE workspace_id = $1 LIMIT $2) RETURNING id`,
+}
+
+type TeardownRepository interface {
+	// ListDue returns deleted workspaces whose restore window has ended,
+	// including ones whose teardown was interrupted
+	ListDue(limit int) ([]uuid.UUID, error)
+	// Start records that the workspace's teardown has begun, unless it has
+	// already, and returns its progress. It returns nil if the workspace
+	// isn't due, for example because it was just restored.
+	Start(workspaceID uuid.UUID) (*models.WorkspaceTeardown, error)
+	Find(workspaceID uuid.UUID) (*models.WorkspaceTeardown, error)
+	SaveProgress(teardown *models.WorkspaceTeardown) error
+
+	// ListAttachments returns a batch of the attachments on the workspace's
+	// messages
+	ListAttachments(workspaceID uuid.UUID, limit int) ([]*models.Attachment, error)
+	DeleteAttachments(ids []uuid.UUID) (int64, error)
+	// DeleteBatch deletes up to limit rows for one of TeardownSteps and
+	// returns their IDs
+	DeleteBatch(workspaceID uuid.UUID, step string, limit int) ([]uuid.UUID, error)
+	// Finish deletes the workspace row, and with it anything left, and marks
+	// the teardown completed
+	Finish(teardown *models.WorkspaceTeardown) error
+}
+
+type postgresTeardownRepository struct {
+	db *database.DB
+}
+
+func NewTeardownRepository(db *database.DB) TeardownRepository {
+	return &postgresTeardownRepository{db: db}
+}
+
+func (r *postgresTeardownRepository) ListDue(limit int) ([]uuid.UUID, error) {
+	query := `
+		SELECT id FROM workspaces
+		WHERE deleted_at IS NOT NULL AND purge_after <= CURRENT_TIMESTAMP
+		ORDER BY purge_after ASC
+		LIMIT $1
+	`
+	rows, err := r.db.Query(query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *postgresTeardownRepository) Start(workspaceID uuid.UUID) (*models.WorkspaceTeardown, error) {
+	query := `
+		INSERT INTO workspace_teardowns (workspace_id, workspace_name, owner_id)
+		SELECT id, name, owner_id FROM workspaces
+		WHERE id = $1 AND deleted_at IS NOT NULL AND purge_after <= CURRENT_TIMESTAMP
+		ON CONFLICT (workspace_id) DO NOTHING
+	`
+	if _, err := r.db.Exec(query, workspaceID); err != nil {
+		return nil, err
+	}
+
+	teardown, err := r.Find(workspaceID)
+	if err != nil || teardown == nil || teardown.Status != "running" {
+		return nil, err
+	}
+	return teardown, nil
+}
+
+func (r *postgresTeardownRepository) Find(workspaceID uuid.UUID) (*models.WorkspaceTeardown, error) {
+	t := &models.WorkspaceTeardown{}
+	query := `
+		SELECT workspace_id, workspace_name, owner_id, status, step, rows_deleted, files_deleted, files_failed,
+		       last_error, started_at, updated_at, completed_at
+		FROM workspace_teardowns WHERE workspace_id = $1
+	`
+	err := r.db.QueryRow(query, workspaceID).Scan(
+		&t.WorkspaceID, &t.WorkspaceName, &t.OwnerID, &t.Status, &t.Step, &t.RowsDeleted, &t.FilesDeleted, &t.FilesFailed,
+		&t.LastError, &t.StartedAt, &t.UpdatedAt, &t.CompletedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (r *postgresTeardownRepository) SaveProgress(teardown *models.WorkspaceTeardown) error {
+	query := `
+		UPDATE workspace_teardowns
+		SET step = $2, rows_deleted = $3, files_deleted = $4, files_failed = $5, last_error = $6, updated_at = CURRENT_TIMESTAMP
+		WHERE workspace_id = $1
+		RETURNING updated_at
+	`
+	return r.db.QueryRow(
+		query,
+		teardown.WorkspaceID,
+		teardown.Step,
+		teardown.RowsDeleted,
+		teardown.FilesDeleted,
+		teardown.FilesFailed,
+		teardown.LastError,
+	).Scan(&teardown.UpdatedAt)
+}
+
+func (r *postgresTeardownRepository) ListAttachments(workspaceID uuid.UUID, limit int) ([]*models.Attachment, error) {
+	query := `
+		SELECT a.id, a.message_id, a.file_name, a.file_url
+		FROM attachments a
+		JOIN messages m ON a.message_id = m.id
+		WHERE m.channel_id IN (SELECT id FROM channels WHERE workspace_id = $1)
+		OR m.dm_id IN (SELECT id FROM direct_messages WHERE workspace_id = $1)
+		LIMIT $2
+	`
+	rows, err := r.db.Query(query, workspaceID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attachments []*models.Attachment
+	for rows.Next() {
+		a := &models.Attachment{}
+		if err := rows.Scan(&a.ID, &a.MessageID, &a.FileName, &a.FileURL); err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, rows.Err()
+}
+
+func (r *postgresTeardownRepository) DeleteAttachments(ids []uuid.UUID) (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM attachments WHERE id = ANY($1)`, pq.Array(ids))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (r *postgresTeardownRepository) DeleteBatch(workspaceID uuid.UUID, step string, limit int) ([]uuid.UUID, error) {
+	rows, err := r.db.Query(teardownQueries[step], workspaceID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *postgresTeardownRepository) Finish(teardown *models.WorkspaceTeardown) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM workspaces WHERE id = $1 AND deleted_at IS NOT NULL`, teardown.WorkspaceID); err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE workspace_teardowns
+		SET status = 'completed', step = 'done', rows_deleted = $2, files_deleted = $3, files_failed = $4,
+		    last_error = NULL, updated_at = CURRENT_TIMESTAMP, completed_at = CURRENT_TIMESTAMP
+		WHERE workspace_id = $1
+		RETURNING status, step, updated_at, completed_at
+	`
+	err = tx.QueryRow(query, teardown.WorkspaceID, teardown.RowsDeleted, teardown.FilesDeleted, teardown.FilesFailed).Scan(
+		&teardown.Status, &teardown.Step, &teardown.UpdatedAt, &teardown.CompletedAt,
+	)
+	if err != nil {
+		return err
+	}
+	teardown.LastError = nil
+
+	return tx.Commit()
+}
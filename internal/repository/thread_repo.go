@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/database"
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type ThreadRepository interface {
+	Follow(threadID, userID uuid.UUID) error
+	AutoFollow(threadID, userID uuid.UUID) error
+	Unfollow(threadID, userID uuid.UUID) error
+	MarkRead(threadID, userID uuid.UUID) error
+	GetSubscription(threadID, userID uuid.UUID) (*models.ThreadSubscription, error)
+	// ListFollowerIDs lists the followers who can still see the thread
+	ListFollowerIDs(threadID uuid.UUID) ([]uuid.UUID, error)
+	// ListInbox lists followed threads the user can still see; threads in
+	// public channels they aren't in only if includePublic
+	ListInbox(workspaceID, userID uuid.UUID, unreadOnly, includePublic bool, limit, offset int) ([]*models.ThreadInboxItem, error)
+}
+
+type postgresThreadRepository struct {
+	db *database.DB
+}
+
+func NewThreadRepository(db *database.DB) ThreadRepository {
+	return &postgresThreadRepository{db: db}
+}
+
+func (r *postgresThreadRepository) Follow(threadID, userID uuid.UUID) error {
+	query := `
+		INSERT INTO thread_subscriptions (id, thread_id, user_id, following)
+		VALUES ($1, $2, $3, true)
+		ON CONFLICT (thread_id, user_id) DO UPDATE
+		SET following = true, unfollowed_at = NULL, updated_at = CURRENT_TIMESTAMP
+	`
+	_, err := r.db.Exec(query, uuid.New(), threadID, userID)
+	return err
+}
+
+// AutoFollow subscribes the user unless they explicitly unfollowed the thread.
+func (r *postgresThreadRepository) AutoFollow(threadID, userID uuid.UUID) error {
+	query := `
+		INSERT INTO thread_subscriptions (id, thread_id, user_id, following)
+		VALUES ($1, $2, $3, true)
+		ON CONFLICT (thread_id, user_id) DO UPDATE
+		SET following = true, updated_at = CURRENT_TIMESTAMP
+		WHERE thread_subscriptions.unfollowed_at IS NULL AND thread_subscriptions.following = false
+	`
+	_, err := r.db.Exec(query, uuid.New(), threadID, userID)
+	return err
+}
+
+func (r *postgresThreadRepository) Unfollow(threadID, userID uuid.UUID) error {
+	query := `
+		INSERT INTO thread_subscriptions (id, thread_id, user_id, following, unfollowed_at)
+		VALUES ($1, $2, $3, false, CURRENT_TIMESTAMP)
+		ON CONFLICT (thread_id, user_id) DO UPDATE
+		SET following = false, unfollowed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+	`
+	_, err := r.db.Exec(query, uuid.New(), threadID, userID)
+	return err
+}
+
+func (r *postgresThreadRepository) MarkRead(threadID, userID uuid.UUID) error {
+	// A read marker alone does not follow the thread
+	query := `
+		INSERT INTO thread_subscriptions (id, thread_id, user_id, following, last_read_at)
+		VALUES ($1, $2, $3, false, CURRENT_TIMESTAMP)
+		ON CONFLICT (thread_id, user_id) DO UPDATE
+		SET last_read_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+	`
+	_, err := r.db.Exec(query, uuid.New(), threadID, userID)
+	return err
+}
+
+func (r *postgresThreadRepository) GetSubscription(threadID, userID uuid.UUID) (*models.ThreadSubscription, error) {
+	sub := &models.ThreadSubscription{}
+	query := `
+		SELECT id, thread_id, user_id, following, unfollowed_at, last_read_at, created_at, updated_at
+		FROM thread_subscriptions
+		WHERE thread_id = $1 AND user_id = $2
+	`
+	err := r.db.QueryRow(query, threadID, userID).Scan(
+		&sub.ID, &sub.ThreadID, &sub.UserID, &sub.Following, &sub.UnfollowedAt, &sub.LastReadAt, &sub.CreatedAt, &sub.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (r *postgresThreadRepository) ListFollowerIDs(threadID uuid.UUID) ([]uuid.UUID, error) {
+	// Channel members, workspace members other than guests for public
+	// channels, and DM participants
+	query := `
+		SELECT ts.user_id
+		FROM thread_subscriptions ts
+		JOIN messages m ON ts.thread_id = m.id
+		LEFT JOIN channels c ON m.channel_id = c.id
+		WHERE ts.thread_id = $1 AND ts.following = true
+		AND (
+			(c.id IS NOT NULL AND (
+				EXISTS(SELECT 1 FROM channel_members cm WHERE cm.channel_id = c.id AND cm.user_id = ts.user_id)
+				OR (c.is_private = false AND EXISTS(
+					SELECT 1 FROM workspace_members wm
+					WHERE wm.workspace_id = c.workspace_id AND wm.user_id = ts.user_id
+					AND wm.role NOT IN ('multi_channel_guest', 'single_channel_guest')
+					AND ` + activeMemberSQL + `))))
+			OR
+			(m.dm_id IS NOT NULL AND EXISTS(
+				SELECT 1 FROM dm_participants dp WHERE dp.dm_id = m.dm_id AND dp.user_id = ts.user_id))
+		)
+	`
+	rows, err := r.db.Query(query, threadID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, id)
+	}
+	return userIDs, nil
+}
+
+func (r *postgresThreadRepository) ListInbox(workspaceID, userID uuid.UUID, unreadOnly, includePublic bool, limit, offset int) ([]*models.ThreadInboxItem, error) {
+	// Followed threads in channels the user can still see, or DMs they take part in
+	query := `
+		SELECT * FROM (
+			SELECT m.id, m.content, m.sender_id, m.channel_id, m.dm_id, m.parent_message_id, m.edited_at, m.deleted_at, m.created_at, m.updated_at,
+			       m.reply_count, m.last_reply_at, m.reply_user_ids, m.also_sent_to_channel, m.type, m.metadata,
+			       u.username, u.avatar_url, u.full_name,
+			       ts.last_read_at,
+			       (SELECT COUNT(*) FROM messages r
+			        WHERE r.parent_message_id = m.id
+			        AND r.created_at > COALESCE(ts.last_read_at, '1970-01-01')
+			        AND r.sender_id != $2
+			        AND r.deleted_at IS NULL) as unread_count
+			FROM thread_subscriptions ts
+			JOIN messages m ON ts.thread_id = m.id
+			LEFT JOIN users u ON m.sender_id = u.id
+			LEFT JOIN channels c ON m.channel_id = c.id
+			LEFT JOIN direct_messages d ON m.dm_id = d.id
+			WHERE ts.user_id = $2 AND ts.following = true
+			AND m.deleted_at IS NULL
+			AND (
+				(c.workspace_id = $1 AND (($4 AND c.is_private = false) OR EXISTS(
+					SELECT 1 FROM channel_members cm WHERE cm.channel_id = c.id AND cm.user_id = $2)))
+				OR
+				(d.workspace_id = $1 AND EXISTS(
+					SELECT 1 FROM dm_participants dp WHERE dp.dm_id = d.id AND dp.user_id = $2))
+			)
+		) threads
+		WHERE ($3 = false OR unread_count > 0)
+		ORDER BY last_reply_at DESC NULLS LAST
+		LIMIT $5 OFFSET $6
+	`
+	rows, err := r.db.Query(query, workspaceID, userID, unreadOnly, includePublic, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []*models.ThreadInboxItem
+	for rows.Next() {
+		m := &models.Message{}
+		item := &models.ThreadInboxItem{Parent: m}
+		var metadata []byte
+		var username, fullName, avatarURL sql.NullString
+		if err := rows.Scan(
+			&m.ID, &m.Content, &m.SenderID, &m.ChannelID, &m.DMID, &m.ParentMessageID, &m.EditedAt, &m.DeletedAt, &m.CreatedAt, &m.UpdatedAt,
+			&m.ReplyCount, &m.LastReplyAt, pq.Array(&m.ReplyUserIDs), &m.AlsoSentToChannel, &m.Type, &metadata,
+			&username, &avatarURL, &fullName,
+			&item.LastReadAt, &item.UnreadCount,
+		); err != nil {
+			return nil, err
+		}
+		m.Metadata = metadata
+		item.LastReplyAt = m.LastReplyAt
+
+		if username.Valid {
+			m.Sender = &models.User{
+				ID:       *m.SenderID,
+				Username: username.String,
+			}
+			if avatarURL.Valid {
+				m.Sender.AvatarURL = &avatarURL.String
+			}
+			if fullName.Valid {
+				m.Sender.FullName = &fullName.String
+			}
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
@@ -3,10 +3,12 @@ package repository
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 
 	"github.com/DoDuy2004/slack-clone-backend/internal/database"
 	"github.com/DoDuy2004/slack-clone-backend/internal/models"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type UserRepository interface {
@@ -16,6 +18,8 @@ type UserRepository interface {
 	Update(user *models.User) error
 	UpdateStatus(userID uuid.UUID, status string) error
 	FindByUsername(username string) (*models.User, error)
+	FindByIDs(ids []uuid.UUID) ([]*models.User, error)
+	FindWorkspaceMembersByUsernames(workspaceID uuid.UUID, usernames []string) ([]*models.User, error)
 }
 
 type postgresUserRepository struct {
@@ -174,3 +178,57 @@ func (r *postgresUserRepository) FindByUsername(username string) (*models.User,
 	}
 	return user, nil
 }
+
+func (r *postgresUserRepository) FindByIDs(ids []uuid.UUID) ([]*models.User, error) {
+	query := `
+		SELECT id, email, username, password_hash, full_name, avatar_url, status, status_message, created_at, updated_at, last_seen_at
+		FROM users
+		WHERE id = ANY($1)
+	`
+	return r.queryUsers(query, pq.Array(ids))
+}
+
+func (r *postgresUserRepository) FindWorkspaceMembersByUsernames(workspaceID uuid.UUID, usernames []string) ([]*models.User, error) {
+	lowered := make([]string, len(usernames))
+	for i, username := range usernames {
+		lowered[i] = strings.ToLower(username)
+	}
+
+	query := `
+		SELECT u.id, u.email, u.username, u.password_hash, u.full_name, u.avatar_url, u.status, u.status_message, u.created_at, u.updated_at, u.last_seen_at
+		FROM users u
+		JOIN workspace_members wm ON wm.user_id = u.id AND wm.workspace_id = $1
+		WHERE LOWER(u.username) = ANY($2)
+	`
+	return r.queryUsers(query, workspaceID, pq.Array(lowered))
+}
+
+func (r *postgresUserRepository) queryUsers(query string, args ...interface{}) ([]*models.User, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []*models.User
+	for rows.Next() {
+		user := &models.User{}
+		if err := rows.Scan(
+			&user.ID,
+			&user.Email,
+			&user.Username,
+			&user.PasswordHash,
+			&user.FullName,
+			&user.AvatarURL,
+			&user.Status,
+			&user.StatusMessage,
+			&user.CreatedAt,
+			&user.UpdatedAt,
+			&user.LastSeenAt,
+		); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
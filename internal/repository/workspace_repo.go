@@ -3,27 +3,64 @@ package repository
 import (
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/DoDuy2004/slack-clone-backend/internal/database"
 	"github.com/DoDuy2004/slack-clone-backend/internal/models"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type WorkspaceRepository interface {
 	Create(workspace *models.Workspace, ownerID uuid.UUID) error
+	// FindByID and FindBySlug also return deleted workspaces that haven't
+	// been torn down yet, with DeletedAt set
 	FindByID(id uuid.UUID) (*models.Workspace, error)
 	FindBySlug(slug string) (*models.Workspace, error)
 	ListByUserID(userID uuid.UUID) ([]*models.Workspace, error)
 	Update(workspace *models.Workspace) error
-	Delete(id uuid.UUID) error
+	// SoftDelete marks the workspace deleted, restorable until purgeAfter. It
+	// reports false if it was already deleted.
+	SoftDelete(id, deletedBy uuid.UUID, purgeAfter time.Time) (bool, error)
+	// Restore undeletes the workspace. It reports false if it isn't deleted,
+	// its restore window has ended or its teardown has started.
+	Restore(id uuid.UUID) (bool, error)
+	// ListDeleted returns the owner's deleted workspaces that haven't been
+	// torn down yet
+	ListDeleted(ownerID uuid.UUID) ([]*models.Workspace, error)
+	// TransferOwnership makes toID the owner and fromID an admin, recording
+	// entry in the audit log in the same transaction. It reports false if
+	// fromID is no longer the owner.
+	TransferOwnership(workspaceID, fromID, toID uuid.UUID, entry *models.AuditLogEntry) (bool, error)
 
-	// Member operations
+	// Member operations. Guests whose access has expired, and members of
+	// deleted workspaces, are left out of every lookup.
+	// AddMember adds the user, or updates their role if they are already a
+	// member. New members are joined to the workspace's default channels.
 	AddMember(workspaceID, userID uuid.UUID, role string) error
+	// UpdateMemberRole sets a non-guest role, clearing any guest expiry
+	UpdateMemberRole(workspaceID, userID uuid.UUID, role string) error
+	SetGuestExpiry(workspaceID, userID uuid.UUID, expiresAt *time.Time) error
+	// RemoveMember also takes the user out of the workspace's channels and DMs
 	RemoveMember(workspaceID, userID uuid.UUID) error
 	GetMember(workspaceID, userID uuid.UUID) (*models.WorkspaceMember, error)
+	// ListMembers returns the members with their user profiles, oldest first
 	ListMembers(workspaceID uuid.UUID) ([]*models.WorkspaceMember, error)
+	// ListExpiredGuests returns guests in any workspace whose access has ended
+	ListExpiredGuests() ([]*models.WorkspaceMember, error)
+
+	// Settings
+	GetSettings(workspaceID uuid.UUID) (*models.WorkspaceSettings, error)
+	UpdateSettings(settings *models.WorkspaceSettings) error
 }
 
+// activeMemberSQL filters workspace_members, aliased wm, down to members
+// whose guest access, if any, hasn't expired, of workspaces that aren't deleted
+const activeMemberSQL = `((wm.guest_expires_at IS NULL OR wm.guest_expires_at > CURRENT_TIMESTAMP)
+	AND NOT EXISTS(SELECT 1 FROM workspaces dw WHERE dw.id = wm.workspace_id AND dw.deleted_at IS NOT NULL))`
+
+const workspaceColumns = `id, name, slug, icon_url, owner_id, created_at, updated_at, deleted_at, purge_after`
+
 type postgresWorkspaceRepository struct {
 	db *database.DB
 }
@@ -67,14 +104,30 @@ func (r *postgresWorkspaceRepository) Create(workspace *models.Workspace, ownerI
 		return fmt.Errorf("failed to add owner as member: %w", err)
 	}
 
+	// 3. Create #general as the first default channel
+	channelID := uuid.New()
+	channelQuery := `
+		INSERT INTO channels (id, workspace_id, name, description, is_private, is_default, created_by)
+		VALUES ($1, $2, $3, $4, false, true, $5)
+	`
+	_, err = tx.Exec(channelQuery, channelID, workspace.ID, "general", "Company-wide announcements and work-based matters", ownerID)
+	if err != nil {
+		return fmt.Errorf("failed to create general channel: %w", err)
+	}
+
+	_, err = tx.Exec(`INSERT INTO channel_members (id, channel_id, user_id) VALUES ($1, $2, $3)`, uuid.New(), channelID, ownerID)
+	if err != nil {
+		return fmt.Errorf("failed to add owner to general channel: %w", err)
+	}
+
 	return tx.Commit()
 }
 
 func (r *postgresWorkspaceRepository) FindByID(id uuid.UUID) (*models.Workspace, error) {
 	ws := &models.Workspace{}
-	query := `SELECT id, name, slug, icon_url, owner_id, created_at, updated_at FROM workspaces WHERE id = $1`
+	query := `SELECT ` + workspaceColumns + ` FROM workspaces WHERE id = $1`
 	err := r.db.QueryRow(query, id).Scan(
-		&ws.ID, &ws.Name, &ws.Slug, &ws.IconURL, &ws.OwnerID, &ws.CreatedAt, &ws.UpdatedAt,
+		&ws.ID, &ws.Name, &ws.Slug, &ws.IconURL, &ws.OwnerID, &ws.CreatedAt, &ws.UpdatedAt, &ws.DeletedAt, &ws.PurgeAfter,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -87,9 +140,9 @@ func (r *postgresWorkspaceRepository) FindByID(id uuid.UUID) (*models.Workspace,
 
 func (r *postgresWorkspaceRepository) FindBySlug(slug string) (*models.Workspace, error) {
 	ws := &models.Workspace{}
-	query := `SELECT id, name, slug, icon_url, owner_id, created_at, updated_at FROM workspaces WHERE slug = $1`
+	query := `SELECT ` + workspaceColumns + ` FROM workspaces WHERE slug = $1`
 	err := r.db.QueryRow(query, slug).Scan(
-		&ws.ID, &ws.Name, &ws.Slug, &ws.IconURL, &ws.OwnerID, &ws.CreatedAt, &ws.UpdatedAt,
+		&ws.ID, &ws.Name, &ws.Slug, &ws.IconURL, &ws.OwnerID, &ws.CreatedAt, &ws.UpdatedAt, &ws.DeletedAt, &ws.PurgeAfter,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -105,7 +158,7 @@ func (r *postgresWorkspaceRepository) ListByUserID(userID uuid.UUID) ([]*models.
 		SELECT w.id, w.name, w.slug, w.icon_url, w.owner_id, w.created_at, w.updated_at
 		FROM workspaces w
 		JOIN workspace_members wm ON w.id = wm.workspace_id
-		WHERE wm.user_id = $1
+		WHERE wm.user_id = $1 AND ` + activeMemberSQL + `
 		ORDER BY w.created_at DESC
 	`
 	rows, err := r.db.Query(query, userID)
@@ -135,33 +188,224 @@ func (r *postgresWorkspaceRepository) Update(workspace *models.Workspace) error
 	return err
 }
 
-func (r *postgresWorkspaceRepository) Delete(id uuid.UUID) error {
-	query := `DELETE FROM workspaces WHERE id = $1`
-	_, err := r.db.Exec(query, id)
-	return err
+func (r *postgresWorkspaceRepository) SoftDelete(id, deletedBy uuid.UUID, purgeAfter time.Time) (bool, error) {
+	query := `
+		UPDATE workspaces SET deleted_at = CURRENT_TIMESTAMP, deleted_by = $2, purge_after = $3
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+	result, err := r.db.Exec(query, id, deletedBy, purgeAfter)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+func (r *postgresWorkspaceRepository) Restore(id uuid.UUID) (bool, error) {
+	query := `
+		UPDATE workspaces SET deleted_at = NULL, deleted_by = NULL, purge_after = NULL
+		WHERE id = $1 AND deleted_at IS NOT NULL AND purge_after > CURRENT_TIMESTAMP
+		AND NOT EXISTS(SELECT 1 FROM workspace_teardowns t WHERE t.workspace_id = workspaces.id)
+	`
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+func (r *postgresWorkspaceRepository) ListDeleted(ownerID uuid.UUID) ([]*models.Workspace, error) {
+	query := `
+		SELECT ` + workspaceColumns + `
+		FROM workspaces
+		WHERE owner_id = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC
+	`
+	rows, err := r.db.Query(query, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	workspaces := []*models.Workspace{}
+	for rows.Next() {
+		ws := &models.Workspace{}
+		if err := rows.Scan(&ws.ID, &ws.Name, &ws.Slug, &ws.IconURL, &ws.OwnerID, &ws.CreatedAt, &ws.UpdatedAt, &ws.DeletedAt, &ws.PurgeAfter); err != nil {
+			return nil, err
+		}
+		workspaces = append(workspaces, ws)
+	}
+	return workspaces, rows.Err()
+}
+
+func (r *postgresWorkspaceRepository) TransferOwnership(workspaceID, fromID, toID uuid.UUID, entry *models.AuditLogEntry) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// 1. Move the owner; the WHERE guards against a concurrent transfer
+	result, err := tx.Exec(
+		`UPDATE workspaces SET owner_id = $3, updated_at = CURRENT_TIMESTAMP WHERE id = $1 AND owner_id = $2`,
+		workspaceID, fromID, toID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to update workspace owner: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil || rows == 0 {
+		return false, err
+	}
+
+	// 2. Swap the member roles
+	roleQuery := `UPDATE workspace_members SET role = $3 WHERE workspace_id = $1 AND user_id = $2`
+	if _, err := tx.Exec(roleQuery, workspaceID, toID, "owner"); err != nil {
+		return false, fmt.Errorf("failed to promote new owner: %w", err)
+	}
+	if _, err := tx.Exec(roleQuery, workspaceID, fromID, "admin"); err != nil {
+		return false, fmt.Errorf("failed to demote previous owner: %w", err)
+	}
+
+	// 3. Record it
+	if err := insertAuditEntry(tx, entry); err != nil {
+		return false, fmt.Errorf("failed to record ownership transfer: %w", err)
+	}
+
+	return true, tx.Commit()
 }
 
 func (r *postgresWorkspaceRepository) AddMember(workspaceID, userID uuid.UUID, role string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// xmax is zero only for freshly inserted rows, so a role change on an
+	// existing member doesn't put them back in channels they have left
+	var inserted bool
 	query := `
 		INSERT INTO workspace_members (id, workspace_id, user_id, role)
 		VALUES ($1, $2, $3, $4)
-		ON CONFLICT (workspace_id, user_id) DO UPDATE SET role = EXCLUDED.role
+		ON CONFLICT (workspace_id, user_id) DO UPDATE SET role = EXCLUDED.role, guest_expires_at = NULL
+		RETURNING (xmax = 0)
+	`
+	if err := tx.QueryRow(query, uuid.New(), workspaceID, userID, role).Scan(&inserted); err != nil {
+		return fmt.Errorf("failed to add workspace member: %w", err)
+	}
+
+	if inserted {
+		if err := joinDefaultChannels(tx, workspaceID, userID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// joinDefaultChannels adds a new member to the workspace's default channels.
+func joinDefaultChannels(tx *sql.Tx, workspaceID, userID uuid.UUID) error {
+	query := `
+		INSERT INTO channel_members (channel_id, user_id)
+		SELECT c.id, $2
+		FROM channels c
+		WHERE c.workspace_id = $1 AND c.is_default = true AND c.archived_at IS NULL
+		ON CONFLICT DO NOTHING
 	`
-	_, err := r.db.Exec(query, uuid.New(), workspaceID, userID, role)
+	if _, err := tx.Exec(query, workspaceID, userID); err != nil {
+		return fmt.Errorf("failed to join default channels: %w", err)
+	}
+	return nil
+}
+
+// joinChannels adds a member to the given channels, skipping any that are
+// archived or not in the workspace, and private ones the inviter has left.
+func joinChannels(tx *sql.Tx, workspaceID, userID, inviterID uuid.UUID, channelIDs []uuid.UUID) error {
+	query := `
+		INSERT INTO channel_members (channel_id, user_id)
+		SELECT c.id, $2
+		FROM channels c
+		WHERE c.workspace_id = $1 AND c.id = ANY($3) AND c.archived_at IS NULL
+		  AND (c.is_private = false
+		       OR EXISTS(SELECT 1 FROM channel_members cm WHERE cm.channel_id = c.id AND cm.user_id = $4))
+		ON CONFLICT DO NOTHING
+	`
+	if _, err := tx.Exec(query, workspaceID, userID, pq.Array(channelIDs), inviterID); err != nil {
+		return fmt.Errorf("failed to join channels: %w", err)
+	}
+	return nil
+}
+
+func (r *postgresWorkspaceRepository) UpdateMemberRole(workspaceID, userID uuid.UUID, role string) error {
+	query := `UPDATE workspace_members SET role = $3, guest_expires_at = NULL WHERE workspace_id = $1 AND user_id = $2`
+	_, err := r.db.Exec(query, workspaceID, userID, role)
+	return err
+}
+
+func (r *postgresWorkspaceRepository) SetGuestExpiry(workspaceID, userID uuid.UUID, expiresAt *time.Time) error {
+	query := `UPDATE workspace_members SET guest_expires_at = $3 WHERE workspace_id = $1 AND user_id = $2`
+	_, err := r.db.Exec(query, workspaceID, userID, expiresAt)
 	return err
 }
 
 func (r *postgresWorkspaceRepository) RemoveMember(workspaceID, userID uuid.UUID) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// 1. Unfollow the workspace's threads
+	subscriptionsQuery := `
+		DELETE FROM thread_subscriptions
+		WHERE user_id = $2 AND thread_id IN (
+			SELECT m.id FROM messages m
+			LEFT JOIN channels c ON m.channel_id = c.id
+			LEFT JOIN direct_messages d ON m.dm_id = d.id
+			WHERE c.workspace_id = $1 OR d.workspace_id = $1
+		)
+	`
+	if _, err := tx.Exec(subscriptionsQuery, workspaceID, userID); err != nil {
+		return fmt.Errorf("failed to unfollow threads: %w", err)
+	}
+
+	// 2. Leave the workspace's channels
+	channelsQuery := `
+		DELETE FROM channel_members
+		WHERE user_id = $2 AND channel_id IN (SELECT id FROM channels WHERE workspace_id = $1)
+	`
+	if _, err := tx.Exec(channelsQuery, workspaceID, userID); err != nil {
+		return fmt.Errorf("failed to leave channels: %w", err)
+	}
+
+	// 3. Leave the workspace's DMs; the other participants keep the history
+	dmsQuery := `
+		DELETE FROM dm_participants
+		WHERE user_id = $2 AND dm_id IN (SELECT id FROM direct_messages WHERE workspace_id = $1)
+	`
+	if _, err := tx.Exec(dmsQuery, workspaceID, userID); err != nil {
+		return fmt.Errorf("failed to leave direct messages: %w", err)
+	}
+
+	// 4. Remove the membership
 	query := `DELETE FROM workspace_members WHERE workspace_id = $1 AND user_id = $2`
-	_, err := r.db.Exec(query, workspaceID, userID)
-	return err
+	if _, err := tx.Exec(query, workspaceID, userID); err != nil {
+		return fmt.Errorf("failed to remove workspace member: %w", err)
+	}
+
+	return tx.Commit()
 }
 
 func (r *postgresWorkspaceRepository) GetMember(workspaceID, userID uuid.UUID) (*models.WorkspaceMember, error) {
 	member := &models.WorkspaceMember{}
-	query := `SELECT id, workspace_id, user_id, role, joined_at FROM workspace_members WHERE workspace_id = $1 AND user_id = $2`
+	query := `
+		SELECT id, workspace_id, user_id, role, custom_role_id, guest_expires_at, joined_at
+		FROM workspace_members wm
+		WHERE workspace_id = $1 AND user_id = $2 AND ` + activeMemberSQL
 	err := r.db.QueryRow(query, workspaceID, userID).Scan(
-		&member.ID, &member.WorkspaceID, &member.UserID, &member.Role, &member.JoinedAt,
+		&member.ID, &member.WorkspaceID, &member.UserID, &member.Role, &member.CustomRoleID, &member.GuestExpiresAt, &member.JoinedAt,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -170,20 +414,100 @@ func (r *postgresWorkspaceRepository) GetMember(workspaceID, userID uuid.UUID) (
 }
 
 func (r *postgresWorkspaceRepository) ListMembers(workspaceID uuid.UUID) ([]*models.WorkspaceMember, error) {
-	query := `SELECT id, workspace_id, user_id, role, joined_at FROM workspace_members WHERE workspace_id = $1`
+	query := `
+		SELECT wm.id, wm.workspace_id, wm.user_id, wm.role, wm.custom_role_id, wm.guest_expires_at, wm.joined_at,
+		       u.email, u.username, u.full_name, u.avatar_url
+		FROM workspace_members wm
+		JOIN users u ON wm.user_id = u.id
+		WHERE wm.workspace_id = $1 AND ` + activeMemberSQL + `
+		ORDER BY wm.joined_at ASC
+	`
 	rows, err := r.db.Query(query, workspaceID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	var members []*models.WorkspaceMember
+	for rows.Next() {
+		m := &models.WorkspaceMember{User: &models.User{}}
+		if err := rows.Scan(
+			&m.ID, &m.WorkspaceID, &m.UserID, &m.Role, &m.CustomRoleID, &m.GuestExpiresAt, &m.JoinedAt,
+			&m.User.Email, &m.User.Username, &m.User.FullName, &m.User.AvatarURL,
+		); err != nil {
+			return nil, err
+		}
+		m.User.ID = m.UserID
+		members = append(members, m)
+	}
+	return members, nil
+}
+
+func (r *postgresWorkspaceRepository) ListExpiredGuests() ([]*models.WorkspaceMember, error) {
+	query := `
+		SELECT id, workspace_id, user_id, role, custom_role_id, guest_expires_at, joined_at
+		FROM workspace_members
+		WHERE guest_expires_at <= CURRENT_TIMESTAMP
+		ORDER BY guest_expires_at ASC
+	`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
 	var members []*models.WorkspaceMember
 	for rows.Next() {
 		m := &models.WorkspaceMember{}
-		if err := rows.Scan(&m.ID, &m.WorkspaceID, &m.UserID, &m.Role, &m.JoinedAt); err != nil {
+		if err := rows.Scan(&m.ID, &m.WorkspaceID, &m.UserID, &m.Role, &m.CustomRoleID, &m.GuestExpiresAt, &m.JoinedAt); err != nil {
 			return nil, err
 		}
 		members = append(members, m)
 	}
 	return members, nil
 }
+
+func (r *postgresWorkspaceRepository) GetSettings(workspaceID uuid.UUID) (*models.WorkspaceSettings, error) {
+	settings := &models.WorkspaceSettings{}
+	query := `
+		SELECT workspace_id, broadcast_mention_policy, default_notification_level, urgent_override_policy, updated_at
+		FROM workspace_settings WHERE workspace_id = $1
+	`
+	err := r.db.QueryRow(query, workspaceID).Scan(
+		&settings.WorkspaceID, &settings.BroadcastMentionPolicy, &settings.DefaultNotificationLevel,
+		&settings.UrgentOverridePolicy, &settings.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		// Workspaces without a settings row use the defaults
+		return &models.WorkspaceSettings{
+			WorkspaceID:              workspaceID,
+			BroadcastMentionPolicy:   "everyone",
+			DefaultNotificationLevel: "mentions",
+			UrgentOverridePolicy:     "disabled",
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+func (r *postgresWorkspaceRepository) UpdateSettings(settings *models.WorkspaceSettings) error {
+	query := `
+		INSERT INTO workspace_settings (workspace_id, broadcast_mention_policy, default_notification_level, urgent_override_policy)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (workspace_id) DO UPDATE
+		SET broadcast_mention_policy = EXCLUDED.broadcast_mention_policy,
+		    default_notification_level = EXCLUDED.default_notification_level,
+		    urgent_override_policy = EXCLUDED.urgent_override_policy,
+		    updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at
+	`
+	return r.db.QueryRow(
+		query,
+		settings.WorkspaceID,
+		settings.BroadcastMentionPolicy,
+		settings.DefaultNotificationLevel,
+		settings.UrgentOverridePolicy,
+	).Scan(&settings.UpdatedAt)
+}
@@ -1,45 +1,116 @@
 package service
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/DoDuy2004/slack-clone-backend/internal/authz"
 	"github.com/DoDuy2004/slack-clone-backend/internal/models"
 	"github.com/DoDuy2004/slack-clone-backend/internal/models/dto"
 	"github.com/DoDuy2004/slack-clone-backend/internal/repository"
+	"github.com/DoDuy2004/slack-clone-backend/internal/websocket"
 	"github.com/google/uuid"
 )
 
 var (
-	ErrChannelNotFound = errors.New("channel not found")
+	ErrChannelNotFound    = errors.New("channel not found")
+	ErrPrivateChannel     = errors.New("private channels can only be joined by invitation")
+	ErrNotChannelMember   = errors.New("user is not a member of this channel")
+	ErrNotWorkspaceMember = errors.New("user is not a member of this workspace")
+	ErrChannelArchived    = errors.New("channel is archived")
+	ErrConfirmationFailed = errors.New("confirmation does not match the channel name")
+	ErrPrivateDefault     = errors.New("private channels cannot be default channels")
+	ErrInvalidFilter      = errors.New("invalid filter")
+	ErrPostingRestricted  = errors.New("you don't have permission to post in this channel")
+	ErrReplyRestricted    = errors.New("you don't have permission to reply in this channel")
+	ErrGuestRestricted    = errors.New("guests can only access the channels they were added to")
+	ErrGuestChannelLimit  = errors.New("single-channel guests can only be in one channel")
+)
+
+// Channel posting policies
+const (
+	PostingPolicyEveryone  = "everyone"
+	PostingPolicyAdmins    = "admins"    // workspace admins and owners
+	PostingPolicyAllowlist = "allowlist" // admins and the members on the channel's allowlist
+)
+
+// Channel fields recorded in the change history
+const (
+	ChannelFieldName        = "name"
+	ChannelFieldDescription = "description"
+	ChannelFieldTopic       = "topic"
+	ChannelFieldPrivacy     = "is_private"
+	ChannelFieldDefault     = "is_default"
+	ChannelFieldArchived    = "archived"
+	ChannelFieldPosting     = "posting_policy"
+	ChannelFieldReplies     = "reply_policy"
 )
 
 type ChannelService interface {
 	CreateChannel(userID uuid.UUID, workspaceID uuid.UUID, req *dto.CreateChannelRequest) (*models.Channel, error)
 	GetChannel(channelID uuid.UUID, userID uuid.UUID) (*models.Channel, error)
-	ListWorkspaceChannels(workspaceID uuid.UUID, userID uuid.UUID) ([]*models.Channel, error)
+	ListWorkspaceChannels(workspaceID uuid.UUID, userID uuid.UUID, includeArchived bool) ([]*models.Channel, error)
+	// BrowseChannels pages through the channel directory: public channels and
+	// the private ones the user belongs to.
+	BrowseChannels(workspaceID uuid.UUID, userID uuid.UUID, query *dto.BrowseChannelsQuery) (*dto.ChannelDirectoryResponse, error)
 	UpdateChannel(userID uuid.UUID, channelID uuid.UUID, req *dto.UpdateChannelRequest) (*models.Channel, error)
-	DeleteChannel(userID uuid.UUID, channelID uuid.UUID) error
+	// DeleteChannel permanently removes a channel and its history. Only the
+	// workspace owner can do it, and confirmName must repeat the channel name.
+	DeleteChannel(userID uuid.UUID, channelID uuid.UUID, confirmName string) error
+	ArchiveChannel(userID uuid.UUID, channelID uuid.UUID) (*models.Channel, error)
+	UnarchiveChannel(userID uuid.UUID, channelID uuid.UUID) (*models.Channel, error)
+	// ConvertChannel makes a channel public or private. Only workspace admins
+	// and owners can do it; non-members lose access to a channel made private.
+	ConvertChannel(userID uuid.UUID, channelID uuid.UUID, isPrivate bool) (*models.Channel, error)
+	// SetTopic lets any channel member change the topic. An empty topic clears it.
+	SetTopic(userID uuid.UUID, channelID uuid.UUID, topic string) (*models.Channel, error)
+	// SetPostingPolicy restricts who can post and reply in threads. Only
+	// workspace admins and owners can do it.
+	SetPostingPolicy(userID uuid.UUID, channelID uuid.UUID, req *dto.SetPostingPolicyRequest) (*models.Channel, error)
+	// ListChanges returns the channel's change history, newest first. Only
+	// workspace admins and owners can see it.
+	ListChanges(userID uuid.UUID, channelID uuid.UUID, limit, offset int) ([]*models.ChannelChange, error)
+
+	// Membership
+	JoinChannel(userID uuid.UUID, channelID uuid.UUID) (*models.Channel, error)
+	LeaveChannel(userID uuid.UUID, channelID uuid.UUID) error
+	AddMembers(userID uuid.UUID, channelID uuid.UUID, memberIDs []uuid.UUID) ([]*models.ChannelMember, error)
+	RemoveMember(userID uuid.UUID, channelID uuid.UUID, memberID uuid.UUID) error
+	ListMembers(userID uuid.UUID, channelID uuid.UUID) ([]*models.ChannelMember, error)
 }
 
 type channelService struct {
 	channelRepo   repository.ChannelRepository
 	workspaceRepo repository.WorkspaceRepository
+	userRepo      repository.UserRepository
+	messageRepo   repository.MessageRepository
+	authz         *authz.Authorizer
+	hub           *websocket.Hub
 }
 
-func NewChannelService(channelRepo repository.ChannelRepository, workspaceRepo repository.WorkspaceRepository) ChannelService {
+func NewChannelService(channelRepo repository.ChannelRepository, workspaceRepo repository.WorkspaceRepository, userRepo repository.UserRepository, messageRepo repository.MessageRepository, authorizer *authz.Authorizer, hub *websocket.Hub) ChannelService {
 	return &channelService{
 		channelRepo:   channelRepo,
 		workspaceRepo: workspaceRepo,
+		userRepo:      userRepo,
+		messageRepo:   messageRepo,
+		authz:         authorizer,
+		hub:           hub,
 	}
 }
 
 func (s *channelService) CreateChannel(userID uuid.UUID, workspaceID uuid.UUID, req *dto.CreateChannelRequest) (*models.Channel, error) {
-	// Verify user is member of workspace
-	member, err := s.workspaceRepo.GetMember(workspaceID, userID)
+	allowed, err := s.authz.Can(workspaceID, userID, authz.ChannelCreate)
 	if err != nil {
 		return nil, err
 	}
-	if member == nil {
+	if !allowed {
 		return nil, ErrUnauthorized
 	}
 
@@ -68,17 +139,8 @@ func (s *channelService) GetChannel(channelID uuid.UUID, userID uuid.UUID) (*mod
 		return nil, ErrChannelNotFound
 	}
 
-	// If private, verify membership
-	if channel.IsPrivate {
-		isMember, err := s.channelRepo.IsMember(channelID, userID)
-		if err != nil {
-			return nil, err
-		}
-		if !isMember {
-			return nil, ErrUnauthorized
-		}
-	} else {
-		// If public, verify workspace membership
+	// If public, any workspace member except guests can see it
+	if !channel.IsPrivate {
 		isWSMember, err := s.workspaceRepo.GetMember(channel.WorkspaceID, userID)
 		if err != nil {
 			return nil, err
@@ -86,13 +148,26 @@ func (s *channelService) GetChannel(channelID uuid.UUID, userID uuid.UUID) (*mod
 		if isWSMember == nil {
 			return nil, ErrUnauthorized
 		}
+		if canReadPublicChannel(isWSMember) {
+			return channel, nil
+		}
+	}
+
+	// Otherwise verify membership
+	isMember, err := s.channelRepo.IsMember(channelID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !isMember {
+		return nil, ErrUnauthorized
 	}
 
 	return channel, nil
 }
 
-func (s *channelService) ListWorkspaceChannels(workspaceID uuid.UUID, userID uuid.UUID) ([]*models.Channel, error) {
-	// Verify workspace membership
+func (s *channelService) ListWorkspaceChannels(workspaceID uuid.UUID, userID uuid.UUID, includeArchived bool) ([]*models.Channel, error) {
+	// Verify workspace membership; the repository leaves out public channels
+	// a guest isn't in
 	member, err := s.workspaceRepo.GetMember(workspaceID, userID)
 	if err != nil {
 		return nil, err
@@ -101,7 +176,63 @@ func (s *channelService) ListWorkspaceChannels(workspaceID uuid.UUID, userID uui
 		return nil, ErrUnauthorized
 	}
 
-	return s.channelRepo.ListByWorkspaceID(workspaceID, userID)
+	return s.channelRepo.ListByWorkspaceID(workspaceID, userID, includeArchived)
+}
+
+func (s *channelService) BrowseChannels(workspaceID uuid.UUID, userID uuid.UUID, query *dto.BrowseChannelsQuery) (*dto.ChannelDirectoryResponse, error) {
+	member, err := s.workspaceRepo.GetMember(workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, ErrUnauthorized
+	}
+	if authz.IsGuest(member.Role) {
+		return nil, ErrGuestRestricted
+	}
+
+	filter := &repository.ChannelBrowseFilter{
+		Query:      strings.TrimSpace(query.Query),
+		Membership: defaultString(query.Membership, "all"),
+		Archived:   defaultString(query.Archived, "exclude"),
+		Sort:       defaultString(query.Sort, repository.ChannelSortName),
+		Limit:      query.Limit,
+	}
+	switch {
+	case filter.Membership != "all" && filter.Membership != "joined" && filter.Membership != "not_joined",
+		filter.Archived != "exclude" && filter.Archived != "include" && filter.Archived != "only",
+		filter.Sort != repository.ChannelSortName && filter.Sort != repository.ChannelSortMembers && filter.Sort != repository.ChannelSortActivity:
+		return nil, ErrInvalidFilter
+	}
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 50
+	}
+	limit := filter.Limit
+
+	if query.Cursor != "" {
+		value, id, err := decodeDirectoryCursor(filter.Sort, query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		filter.AfterValue, filter.AfterID = value, &id
+	}
+
+	// Fetch one extra row to know whether there is a next page
+	filter.Limit = limit + 1
+	entries, err := s.channelRepo.Browse(workspaceID, userID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.ChannelDirectoryResponse{Channels: entries}
+	if len(entries) > limit {
+		resp.Channels = entries[:limit]
+		resp.NextCursor = encodeDirectoryCursor(filter.Sort, resp.Channels[limit-1])
+	}
+	if resp.Channels == nil {
+		resp.Channels = []*models.ChannelDirectoryEntry{}
+	}
+	return resp, nil
 }
 
 func (s *channelService) UpdateChannel(userID uuid.UUID, channelID uuid.UUID, req *dto.UpdateChannelRequest) (*models.Channel, error) {
@@ -113,42 +244,644 @@ func (s *channelService) UpdateChannel(userID uuid.UUID, channelID uuid.UUID, re
 		return nil, ErrChannelNotFound
 	}
 
-	// Verify permissions (only creator or workspace admin/owner can update)
-	// For simplicity, we'll check if user is channel creator OR workspace admin/owner
-	isWSMember, err := s.workspaceRepo.GetMember(channel.WorkspaceID, userID)
+	// The creator can update their own channel; anyone else needs permission
+	grant, err := s.authz.Grant(channel.WorkspaceID, userID)
 	if err != nil {
 		return nil, err
 	}
-
-	canUpdate := false
-	if channel.CreatedBy != nil && *channel.CreatedBy == userID {
-		canUpdate = true
-	} else if isWSMember != nil && (isWSMember.Role == "owner" || isWSMember.Role == "admin") {
-		canUpdate = true
+	if grant == nil || (!isCreator(channel, userID) && !grant.Has(authz.ChannelUpdate)) {
+		return nil, ErrUnauthorized
 	}
-
-	if !canUpdate {
+	// Default channels affect every new member, so they need their own permission
+	if req.IsDefault != nil && !grant.Has(authz.ChannelManageDefaults) {
 		return nil, ErrUnauthorized
 	}
+	if channel.ArchivedAt != nil {
+		return nil, ErrChannelArchived
+	}
 
+	before := *channel
 	if req.Name != nil {
 		channel.Name = *req.Name
 	}
 	if req.Description != nil {
 		channel.Description = req.Description
 	}
-	if req.IsPrivate != nil {
-		channel.IsPrivate = *req.IsPrivate
+	if req.IsDefault != nil {
+		channel.IsDefault = *req.IsDefault
+	}
+	if channel.IsPrivate && channel.IsDefault {
+		return nil, ErrPrivateDefault
+	}
+
+	changes := channelChanges(&before, channel)
+	if len(changes) == 0 {
+		return channel, nil
+	}
+
+	if err := s.channelRepo.Update(channel); err != nil {
+		return nil, err
+	}
+	s.recordChanges(channel, userID, changes)
+
+	return s.broadcastChannelUpdate(channelID, websocket.EventChannelUpdated)
+}
+
+func (s *channelService) DeleteChannel(userID uuid.UUID, channelID uuid.UUID, confirmName string) error {
+	channel, err := s.channelRepo.FindByID(channelID)
+	if err != nil {
+		return err
+	}
+	if channel == nil {
+		return ErrChannelNotFound
+	}
+
+	// Deleting destroys the history, so only the owner holds this permission
+	allowed, err := s.authz.Can(channel.WorkspaceID, userID, authz.ChannelDelete)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrUnauthorized
+	}
+	if confirmName != channel.Name {
+		return ErrConfirmationFailed
+	}
+
+	return s.channelRepo.Delete(channelID)
+}
+
+func (s *channelService) ArchiveChannel(userID uuid.UUID, channelID uuid.UUID) (*models.Channel, error) {
+	channel, err := s.channelRepo.FindByID(channelID)
+	if err != nil {
+		return nil, err
+	}
+	if channel == nil {
+		return nil, ErrChannelNotFound
+	}
+
+	// Same rule as updating: the creator, or anyone allowed to archive
+	grant, err := s.authz.Grant(channel.WorkspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if grant == nil || (!isCreator(channel, userID) && !grant.Has(authz.ChannelArchive)) {
+		return nil, ErrUnauthorized
+	}
+	if channel.ArchivedAt != nil {
+		return channel, nil
+	}
+
+	if err := s.channelRepo.Archive(channelID, userID); err != nil {
+		return nil, err
+	}
+	s.recordChanges(channel, userID, []*models.ChannelChange{boolChange(ChannelFieldArchived, false, true)})
+	return s.broadcastChannelUpdate(channelID, websocket.EventChannelArchived)
+}
+
+func (s *channelService) UnarchiveChannel(userID uuid.UUID, channelID uuid.UUID) (*models.Channel, error) {
+	channel, err := s.channelRepo.FindByID(channelID)
+	if err != nil {
+		return nil, err
+	}
+	if channel == nil {
+		return nil, ErrChannelNotFound
+	}
+
+	allowed, err := s.authz.Can(channel.WorkspaceID, userID, authz.ChannelArchive)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrUnauthorized
+	}
+	if channel.ArchivedAt == nil {
+		return channel, nil
+	}
+
+	if err := s.channelRepo.Unarchive(channelID); err != nil {
+		return nil, err
+	}
+	s.recordChanges(channel, userID, []*models.ChannelChange{boolChange(ChannelFieldArchived, true, false)})
+	return s.broadcastChannelUpdate(channelID, websocket.EventChannelUnarchived)
+}
+
+func (s *channelService) ConvertChannel(userID uuid.UUID, channelID uuid.UUID, isPrivate bool) (*models.Channel, error) {
+	channel, err := s.channelRepo.FindByID(channelID)
+	if err != nil {
+		return nil, err
+	}
+	if channel == nil {
+		return nil, ErrChannelNotFound
+	}
+
+	allowed, err := s.authz.Can(channel.WorkspaceID, userID, authz.ChannelConvert)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrUnauthorized
+	}
+	if channel.ArchivedAt != nil {
+		return nil, ErrChannelArchived
+	}
+	if channel.IsPrivate == isPrivate {
+		return channel, nil
+	}
+	if isPrivate && channel.IsDefault {
+		return nil, ErrPrivateDefault
+	}
+
+	before := *channel
+	channel.IsPrivate = isPrivate
+	if err := s.channelRepo.Update(channel); err != nil {
+		return nil, err
+	}
+
+	// Cut off sockets that can no longer see the channel before announcing
+	// the change in it
+	var revoked []uuid.UUID
+	if isPrivate {
+		members, err := s.channelRepo.ListMembers(channelID)
+		if err != nil {
+			return nil, err
+		}
+		allowed := make(map[uuid.UUID]bool, len(members))
+		for _, m := range members {
+			allowed[m.UserID] = true
+		}
+		revoked = s.hub.RestrictRoom("channel", channelID, allowed)
+
+		if err := s.channelRepo.PruneNonMembers(channelID); err != nil {
+			log.Printf("error pruning non-members of channel %s: %v", channelID, err)
+		}
+	}
+
+	s.recordChanges(channel, userID, channelChanges(&before, channel))
+
+	updated, err := s.broadcastChannelUpdate(channelID, websocket.EventChannelUpdated)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, _ := json.Marshal(updated)
+	if isPrivate {
+		// Tell users who lost access so their clients drop the channel
+		for _, revokedID := range revoked {
+			revokedID := revokedID
+			s.hub.Broadcast(&websocket.WSMessage{
+				Type:    websocket.EventChannelUpdated,
+				Payload: payload,
+				UserID:  &revokedID,
+			})
+		}
+	} else {
+		// Everyone in the workspace can now find and join it
+		s.hub.Broadcast(&websocket.WSMessage{
+			Type:        websocket.EventChannelUpdated,
+			Payload:     payload,
+			WorkspaceID: &updated.WorkspaceID,
+		})
+	}
+
+	return updated, nil
+}
+
+func (s *channelService) SetTopic(userID uuid.UUID, channelID uuid.UUID, topic string) (*models.Channel, error) {
+	channel, err := s.channelRepo.FindByID(channelID)
+	if err != nil {
+		return nil, err
+	}
+	if channel == nil {
+		return nil, ErrChannelNotFound
+	}
+
+	isMember, err := s.channelRepo.IsMember(channelID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !isMember {
+		return nil, ErrNotChannelMember
+	}
+	if channel.ArchivedAt != nil {
+		return nil, ErrChannelArchived
+	}
+
+	before := *channel
+	channel.Topic = nonEmpty(strings.TrimSpace(topic))
+
+	changes := channelChanges(&before, channel)
+	if len(changes) == 0 {
+		return channel, nil
 	}
 
 	if err := s.channelRepo.Update(channel); err != nil {
 		return nil, err
 	}
+	s.recordChanges(channel, userID, changes)
+
+	return s.broadcastChannelUpdate(channelID, websocket.EventChannelUpdated)
+}
+
+func (s *channelService) SetPostingPolicy(userID uuid.UUID, channelID uuid.UUID, req *dto.SetPostingPolicyRequest) (*models.Channel, error) {
+	channel, err := s.channelRepo.FindByID(channelID)
+	if err != nil {
+		return nil, err
+	}
+	if channel == nil {
+		return nil, ErrChannelNotFound
+	}
+
+	allowed, err := s.authz.Can(channel.WorkspaceID, userID, authz.ChannelManagePosting)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrUnauthorized
+	}
+	if channel.ArchivedAt != nil {
+		return nil, ErrChannelArchived
+	}
+
+	before := *channel
+	channel.PostingPolicy = req.PostingPolicy
+	if req.ReplyPolicy != "" {
+		channel.ReplyPolicy = req.ReplyPolicy
+	}
+
+	// The allowlist is kept only while a policy uses it
+	var posterIDs []uuid.UUID
+	if channel.PostingPolicy == PostingPolicyAllowlist || channel.ReplyPolicy == PostingPolicyAllowlist {
+		seen := make(map[uuid.UUID]bool)
+		for _, posterID := range req.UserIDs {
+			if seen[posterID] {
+				continue
+			}
+			seen[posterID] = true
+
+			member, err := s.workspaceRepo.GetMember(channel.WorkspaceID, posterID)
+			if err != nil {
+				return nil, err
+			}
+			if member == nil {
+				return nil, ErrNotWorkspaceMember
+			}
+			posterIDs = append(posterIDs, posterID)
+		}
+	}
+
+	if err := s.channelRepo.SetPostingPolicy(channelID, channel.PostingPolicy, channel.ReplyPolicy, posterIDs); err != nil {
+		return nil, err
+	}
+	s.recordChanges(channel, userID, channelChanges(&before, channel))
+
+	return s.broadcastChannelUpdate(channelID, websocket.EventChannelUpdated)
+}
+
+func (s *channelService) ListChanges(userID uuid.UUID, channelID uuid.UUID, limit, offset int) ([]*models.ChannelChange, error) {
+	channel, err := s.channelRepo.FindByID(channelID)
+	if err != nil {
+		return nil, err
+	}
+	if channel == nil {
+		return nil, ErrChannelNotFound
+	}
+
+	allowed, err := s.authz.Can(channel.WorkspaceID, userID, authz.ChannelViewHistory)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrUnauthorized
+	}
+
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	changes, err := s.channelRepo.ListChanges(channelID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	if changes == nil {
+		changes = []*models.ChannelChange{}
+	}
+	return changes, nil
+}
+
+// channelChangeMetadata is stored on the system message announcing a change
+// so clients can render it without parsing the text.
+type channelChangeMetadata struct {
+	ChangeID uuid.UUID `json:"change_id"`
+	Field    string    `json:"field"`
+	OldValue *string   `json:"old_value,omitempty"`
+	NewValue *string   `json:"new_value,omitempty"`
+}
+
+// recordChanges posts a system message to the channel for each change and
+// adds it to the change history. The update itself has already been saved,
+// so failures here are logged rather than returned.
+func (s *channelService) recordChanges(channel *models.Channel, actorID uuid.UUID, changes []*models.ChannelChange) {
+	for _, change := range changes {
+		change.ID = uuid.New()
+		change.ChannelID = channel.ID
+		change.ActorID = &actorID
+
+		metadata, _ := json.Marshal(channelChangeMetadata{
+			ChangeID: change.ID,
+			Field:    change.Field,
+			OldValue: change.OldValue,
+			NewValue: change.NewValue,
+		})
+		message := &models.Message{
+			ID:        uuid.New(),
+			Content:   systemMessageText(change),
+			SenderID:  &actorID,
+			ChannelID: &channel.ID,
+			Type:      MessageTypeSystem,
+			Metadata:  metadata,
+		}
+		if err := s.messageRepo.Create(message); err != nil {
+			log.Printf("error posting system message for channel %s: %v", channel.ID, err)
+		} else {
+			change.MessageID = &message.ID
+			payload, _ := json.Marshal(message)
+			s.hub.Broadcast(&websocket.WSMessage{
+				Type:      websocket.EventMessageNew,
+				Payload:   payload,
+				ChannelID: &channel.ID,
+			})
+		}
+
+		if err := s.channelRepo.AddChange(change); err != nil {
+			log.Printf("error recording change to channel %s: %v", channel.ID, err)
+		}
+	}
+}
+
+// channelChanges lists the metadata fields that differ between before and after.
+func channelChanges(before, after *models.Channel) []*models.ChannelChange {
+	var changes []*models.ChannelChange
+	if before.Name != after.Name {
+		changes = append(changes, &models.ChannelChange{Field: ChannelFieldName, OldValue: &before.Name, NewValue: &after.Name})
+	}
+	if !sameText(before.Description, after.Description) {
+		changes = append(changes, &models.ChannelChange{Field: ChannelFieldDescription, OldValue: before.Description, NewValue: after.Description})
+	}
+	if !sameText(before.Topic, after.Topic) {
+		changes = append(changes, &models.ChannelChange{Field: ChannelFieldTopic, OldValue: before.Topic, NewValue: after.Topic})
+	}
+	if before.IsPrivate != after.IsPrivate {
+		changes = append(changes, boolChange(ChannelFieldPrivacy, before.IsPrivate, after.IsPrivate))
+	}
+	if before.IsDefault != after.IsDefault {
+		changes = append(changes, boolChange(ChannelFieldDefault, before.IsDefault, after.IsDefault))
+	}
+	if before.PostingPolicy != after.PostingPolicy {
+		changes = append(changes, &models.ChannelChange{Field: ChannelFieldPosting, OldValue: &before.PostingPolicy, NewValue: &after.PostingPolicy})
+	}
+	if before.ReplyPolicy != after.ReplyPolicy {
+		changes = append(changes, &models.ChannelChange{Field: ChannelFieldReplies, OldValue: &before.ReplyPolicy, NewValue: &after.ReplyPolicy})
+	}
+	return changes
+}
+
+func boolChange(field string, oldValue, newValue bool) *models.ChannelChange {
+	oldStr, newStr := strconv.FormatBool(oldValue), strconv.FormatBool(newValue)
+	return &models.ChannelChange{Field: field, OldValue: &oldStr, NewValue: &newStr}
+}
+
+// systemMessageText is the plain-text fallback for clients that don't render
+// system messages from their metadata.
+func systemMessageText(change *models.ChannelChange) string {
+	newValue := ""
+	if change.NewValue != nil {
+		newValue = *change.NewValue
+	}
+
+	switch change.Field {
+	case ChannelFieldName:
+		return fmt.Sprintf("renamed the channel from #%s to #%s", *change.OldValue, newValue)
+	case ChannelFieldDescription, ChannelFieldTopic:
+		if newValue == "" {
+			return fmt.Sprintf("cleared the channel %s", change.Field)
+		}
+		return fmt.Sprintf("set the channel %s: %s", change.Field, newValue)
+	case ChannelFieldPrivacy:
+		if newValue == "true" {
+			return "made the channel private"
+		}
+		return "made the channel public"
+	case ChannelFieldDefault:
+		if newValue == "true" {
+			return "made this a default channel for new members"
+		}
+		return "removed this from the default channels"
+	case ChannelFieldArchived:
+		if newValue == "true" {
+			return "archived the channel"
+		}
+		return "unarchived the channel"
+	case ChannelFieldPosting, ChannelFieldReplies:
+		action := "posting"
+		if change.Field == ChannelFieldReplies {
+			action = "thread replies"
+		}
+		switch newValue {
+		case PostingPolicyAdmins:
+			return fmt.Sprintf("restricted %s to workspace admins", action)
+		case PostingPolicyAllowlist:
+			return fmt.Sprintf("restricted %s to selected members", action)
+		}
+		return fmt.Sprintf("opened %s to everyone", action)
+	}
+	return "updated the channel"
+}
+
+// sameText treats a nil and an empty string as the same value.
+func sameText(a, b *string) bool {
+	var av, bv string
+	if a != nil {
+		av = *a
+	}
+	if b != nil {
+		bv = *b
+	}
+	return av == bv
+}
+
+func nonEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// broadcastChannelUpdate reloads the channel and sends it to its members.
+func (s *channelService) broadcastChannelUpdate(channelID uuid.UUID, eventType string) (*models.Channel, error) {
+	channel, err := s.channelRepo.FindByID(channelID)
+	if err != nil {
+		return nil, err
+	}
+	if channel == nil {
+		return nil, ErrChannelNotFound
+	}
+
+	payload, _ := json.Marshal(channel)
+	s.hub.Broadcast(&websocket.WSMessage{
+		Type:      eventType,
+		Payload:   payload,
+		ChannelID: &channel.ID,
+	})
+	return channel, nil
+}
+
+func (s *channelService) JoinChannel(userID uuid.UUID, channelID uuid.UUID) (*models.Channel, error) {
+	channel, err := s.channelRepo.FindByID(channelID)
+	if err != nil {
+		return nil, err
+	}
+	if channel == nil {
+		return nil, ErrChannelNotFound
+	}
+
+	member, err := s.workspaceRepo.GetMember(channel.WorkspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, ErrUnauthorized
+	}
+
+	isMember, err := s.channelRepo.IsMember(channelID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if isMember {
+		return channel, nil
+	}
+	if channel.ArchivedAt != nil {
+		return nil, ErrChannelArchived
+	}
+	if channel.IsPrivate {
+		return nil, ErrPrivateChannel
+	}
+	if authz.IsGuest(member.Role) {
+		return nil, ErrGuestRestricted
+	}
+
+	if err := s.channelRepo.AddMember(channelID, userID); err != nil {
+		return nil, err
+	}
+	s.memberJoined(channel, userID, nil)
 
 	return channel, nil
 }
 
-func (s *channelService) DeleteChannel(userID uuid.UUID, channelID uuid.UUID) error {
+func (s *channelService) LeaveChannel(userID uuid.UUID, channelID uuid.UUID) error {
+	channel, err := s.channelRepo.FindByID(channelID)
+	if err != nil {
+		return err
+	}
+	if channel == nil {
+		return ErrChannelNotFound
+	}
+
+	isMember, err := s.channelRepo.IsMember(channelID, userID)
+	if err != nil {
+		return err
+	}
+	if !isMember {
+		return ErrNotChannelMember
+	}
+
+	if err := s.channelRepo.RemoveMember(channelID, userID); err != nil {
+		return err
+	}
+	s.memberLeft(channel, userID, nil)
+
+	return nil
+}
+
+func (s *channelService) AddMembers(userID uuid.UUID, channelID uuid.UUID, memberIDs []uuid.UUID) ([]*models.ChannelMember, error) {
+	channel, err := s.channelRepo.FindByID(channelID)
+	if err != nil {
+		return nil, err
+	}
+	if channel == nil {
+		return nil, ErrChannelNotFound
+	}
+
+	// Guests can't bring anyone into a channel
+	actor, err := s.authz.Grant(channel.WorkspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if actor == nil || actor.IsGuest() {
+		return nil, ErrUnauthorized
+	}
+
+	if channel.ArchivedAt != nil {
+		return nil, ErrChannelArchived
+	}
+
+	// Only existing members can invite people into a private channel
+	if channel.IsPrivate {
+		isMember, err := s.channelRepo.IsMember(channelID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if !isMember {
+			return nil, ErrUnauthorized
+		}
+	}
+
+	// Validate everyone before adding anyone
+	var toAdd []uuid.UUID
+	seen := make(map[uuid.UUID]bool)
+	for _, memberID := range memberIDs {
+		if seen[memberID] {
+			continue
+		}
+		seen[memberID] = true
+
+		wsMember, err := s.workspaceRepo.GetMember(channel.WorkspaceID, memberID)
+		if err != nil {
+			return nil, err
+		}
+		if wsMember == nil {
+			return nil, ErrNotWorkspaceMember
+		}
+
+		isMember, err := s.channelRepo.IsMember(channelID, memberID)
+		if err != nil {
+			return nil, err
+		}
+		if isMember {
+			continue
+		}
+		if authz.IsGuest(wsMember.Role) {
+			if err := s.checkGuestCanJoin(actor, wsMember); err != nil {
+				return nil, err
+			}
+		}
+		toAdd = append(toAdd, memberID)
+	}
+
+	for _, memberID := range toAdd {
+		if err := s.channelRepo.AddMember(channelID, memberID); err != nil {
+			return nil, err
+		}
+		s.memberJoined(channel, memberID, &userID)
+	}
+
+	return s.listMembers(channelID)
+}
+
+func (s *channelService) RemoveMember(userID uuid.UUID, channelID uuid.UUID, memberID uuid.UUID) error {
 	channel, err := s.channelRepo.FindByID(channelID)
 	if err != nil {
 		return err
@@ -157,22 +890,238 @@ func (s *channelService) DeleteChannel(userID uuid.UUID, channelID uuid.UUID) er
 		return ErrChannelNotFound
 	}
 
-	// Verify permissions (only creator or workspace owner can delete)
-	isWSMember, err := s.workspaceRepo.GetMember(channel.WorkspaceID, userID)
+	actor, err := s.authz.Grant(channel.WorkspaceID, userID)
 	if err != nil {
 		return err
 	}
+	if !actor.Has(authz.ChannelRemoveMembers) {
+		return ErrUnauthorized
+	}
+
+	// Only the owner can remove the owner
+	target, err := s.workspaceRepo.GetMember(channel.WorkspaceID, memberID)
+	if err != nil {
+		return err
+	}
+	if target != nil && target.Role == authz.RoleOwner && !actor.IsOwner() {
+		return ErrUnauthorized
+	}
+
+	isMember, err := s.channelRepo.IsMember(channelID, memberID)
+	if err != nil {
+		return err
+	}
+	if !isMember {
+		return ErrNotChannelMember
+	}
+
+	if err := s.channelRepo.RemoveMember(channelID, memberID); err != nil {
+		return err
+	}
+	s.memberLeft(channel, memberID, &userID)
+
+	return nil
+}
+
+func (s *channelService) ListMembers(userID uuid.UUID, channelID uuid.UUID) ([]*models.ChannelMember, error) {
+	// Same visibility rules as viewing the channel
+	if _, err := s.GetChannel(channelID, userID); err != nil {
+		return nil, err
+	}
+
+	return s.listMembers(channelID)
+}
+
+func (s *channelService) listMembers(channelID uuid.UUID) ([]*models.ChannelMember, error) {
+	members, err := s.channelRepo.ListMembers(channelID)
+	if err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		return []*models.ChannelMember{}, nil
+	}
+
+	userIDs := make([]uuid.UUID, 0, len(members))
+	for _, m := range members {
+		userIDs = append(userIDs, m.UserID)
+	}
+	users, err := s.userRepo.FindByIDs(userIDs)
+	if err != nil {
+		return nil, err
+	}
 
-	canDelete := false
-	if channel.CreatedBy != nil && *channel.CreatedBy == userID {
-		canDelete = true
-	} else if isWSMember != nil && isWSMember.Role == "owner" {
-		canDelete = true
+	usersByID := make(map[uuid.UUID]*models.User, len(users))
+	for _, u := range users {
+		usersByID[u.ID] = u
+	}
+	for _, m := range members {
+		m.User = usersByID[m.UserID]
 	}
 
-	if !canDelete {
+	return members, nil
+}
+
+// memberJoined subscribes the user's open sockets to the channel and tells
+// the channel, including the new member, that they joined.
+func (s *channelService) memberJoined(channel *models.Channel, userID uuid.UUID, actorID *uuid.UUID) {
+	s.hub.AddUserToRoom("channel", channel.ID, userID)
+
+	payload, _ := json.Marshal(websocket.ChannelMemberPayload{
+		ChannelID: channel.ID,
+		UserID:    userID,
+		ActorID:   actorID,
+	})
+	s.hub.Broadcast(&websocket.WSMessage{
+		Type:      websocket.EventChannelJoined,
+		Payload:   payload,
+		ChannelID: &channel.ID,
+	})
+}
+
+// memberLeft unsubscribes the user's sockets from the channel, then tells the
+// remaining members and the user who left.
+func (s *channelService) memberLeft(channel *models.Channel, userID uuid.UUID, actorID *uuid.UUID) {
+	s.hub.RemoveUserFromRoom("channel", channel.ID, userID)
+
+	payload, _ := json.Marshal(websocket.ChannelMemberPayload{
+		ChannelID: channel.ID,
+		UserID:    userID,
+		ActorID:   actorID,
+	})
+	s.hub.Broadcast(&websocket.WSMessage{
+		Type:      websocket.EventChannelLeft,
+		Payload:   payload,
+		ChannelID: &channel.ID,
+	})
+	s.hub.Broadcast(&websocket.WSMessage{
+		Type:    websocket.EventChannelLeft,
+		Payload: payload,
+		UserID:  &userID,
+	})
+}
+
+// encodeDirectoryCursor encodes the entry's sort key and ID. The key comes
+// first and may itself contain underscores; the ID never does.
+func encodeDirectoryCursor(sort string, e *models.ChannelDirectoryEntry) string {
+	var key string
+	switch sort {
+	case repository.ChannelSortMembers:
+		key = strconv.Itoa(e.MemberCount)
+	case repository.ChannelSortActivity:
+		key = strconv.FormatInt(e.LastActivityAt.UnixNano(), 10)
+	default:
+		key = strings.ToLower(e.Name)
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(key + "_" + e.ID.String()))
+}
+
+func decodeDirectoryCursor(sort, cursor string) (interface{}, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, uuid.Nil, ErrInvalidCursor
+	}
+
+	sep := strings.LastIndex(string(raw), "_")
+	if sep < 0 {
+		return nil, uuid.Nil, ErrInvalidCursor
+	}
+	key := string(raw[:sep])
+	id, err := uuid.Parse(string(raw[sep+1:]))
+	if err != nil {
+		return nil, uuid.Nil, ErrInvalidCursor
+	}
+
+	switch sort {
+	case repository.ChannelSortMembers:
+		count, err := strconv.Atoi(key)
+		if err != nil {
+			return nil, uuid.Nil, ErrInvalidCursor
+		}
+		return count, id, nil
+	case repository.ChannelSortActivity:
+		nanos, err := strconv.ParseInt(key, 10, 64)
+		if err != nil {
+			return nil, uuid.Nil, ErrInvalidCursor
+		}
+		return time.Unix(0, nanos).UTC(), id, nil
+	}
+	return key, id, nil
+}
+
+func defaultString(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
+
+// ensureChannelWritable rejects writes to archived channels.
+func ensureChannelWritable(channelRepo repository.ChannelRepository, channelID uuid.UUID) error {
+	_, err := writableChannel(channelRepo, channelID)
+	return err
+}
+
+// checkGuestCanJoin checks that actor may add the guest to another channel.
+func (s *channelService) checkGuestCanJoin(actor *authz.Grant, guest *models.WorkspaceMember) error {
+	if !actor.Has(authz.MemberManageGuests) {
 		return ErrUnauthorized
 	}
+	if guest.Role != authz.RoleSingleChannelGuest {
+		return nil
+	}
 
-	return s.channelRepo.Delete(channelID)
+	joined, err := s.channelRepo.CountJoined(guest.WorkspaceID, guest.UserID)
+	if err != nil {
+		return err
+	}
+	if joined > 0 {
+		return ErrGuestChannelLimit
+	}
+	return nil
+}
+
+// canReadPublicChannel reports whether a workspace member may read public
+// channels they haven't joined; guests only see the channels they are in.
+func canReadPublicChannel(member *models.WorkspaceMember) bool {
+	return member != nil && !authz.IsGuest(member.Role)
+}
+
+// writableChannel loads a channel that isn't archived.
+func writableChannel(channelRepo repository.ChannelRepository, channelID uuid.UUID) (*models.Channel, error) {
+	channel, err := channelRepo.FindByID(channelID)
+	if err != nil {
+		return nil, err
+	}
+	if channel == nil {
+		return nil, ErrChannelNotFound
+	}
+	if channel.ArchivedAt != nil {
+		return nil, ErrChannelArchived
+	}
+	return channel, nil
+}
+
+// isCreator reports whether userID created the channel.
+func isCreator(channel *models.Channel, userID uuid.UUID) bool {
+	return channel.CreatedBy != nil && *channel.CreatedBy == userID
+}
+
+// canPost reports whether a member with the given grant may post under policy
+// in channel.
+func canPost(channel *models.Channel, policy string, userID uuid.UUID, grant *authz.Grant) bool {
+	if grant.Has(authz.ChannelPostRestricted) {
+		return true
+	}
+	switch policy {
+	case PostingPolicyAdmins:
+		return false
+	case PostingPolicyAllowlist:
+		for _, posterID := range channel.PosterIDs {
+			if posterID == userID {
+				return true
+			}
+		}
+		return false
+	}
+	return true
 }
@@ -0,0 +1,401 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/authz"
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+	"github.com/DoDuy2004/slack-clone-backend/internal/models/dto"
+	"github.com/DoDuy2004/slack-clone-backend/internal/repository"
+	"github.com/DoDuy2004/slack-clone-backend/internal/websocket"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannelChanges(t *testing.T) {
+	empty := ""
+	before := &models.Channel{Name: "random", Description: &empty}
+	after := &models.Channel{Name: "watercooler", Topic: stringPtr("Lunch plans"), IsPrivate: true}
+
+	changes := channelChanges(before, after)
+	require.Len(t, changes, 3)
+
+	assert.Equal(t, ChannelFieldName, changes[0].Field)
+	assert.Equal(t, "renamed the channel from #random to #watercooler", systemMessageText(changes[0]))
+
+	assert.Equal(t, ChannelFieldTopic, changes[1].Field)
+	assert.Nil(t, changes[1].OldValue)
+	assert.Equal(t, "set the channel topic: Lunch plans", systemMessageText(changes[1]))
+
+	assert.Equal(t, ChannelFieldPrivacy, changes[2].Field)
+	assert.Equal(t, "true", *changes[2].NewValue)
+	assert.Equal(t, "made the channel private", systemMessageText(changes[2]))
+
+	// An empty description is the same as none
+	assert.Empty(t, channelChanges(before, &models.Channel{Name: "random"}))
+}
+
+func TestSystemMessageTextClearedTopic(t *testing.T) {
+	change := &models.ChannelChange{Field: ChannelFieldTopic, OldValue: stringPtr("Lunch plans")}
+	assert.Equal(t, "cleared the channel topic", systemMessageText(change))
+}
+
+func TestDirectoryCursorRoundTrip(t *testing.T) {
+	entry := &models.ChannelDirectoryEntry{
+		Channel:        models.Channel{ID: uuid.New(), Name: "Team_Design"},
+		MemberCount:    42,
+		LastActivityAt: time.Date(2024, 3, 1, 12, 30, 0, 123456000, time.UTC),
+	}
+
+	value, id, err := decodeDirectoryCursor(repository.ChannelSortName, encodeDirectoryCursor(repository.ChannelSortName, entry))
+	require.NoError(t, err)
+	assert.Equal(t, entry.ID, id)
+	assert.Equal(t, "team_design", value)
+
+	value, _, err = decodeDirectoryCursor(repository.ChannelSortMembers, encodeDirectoryCursor(repository.ChannelSortMembers, entry))
+	require.NoError(t, err)
+	assert.Equal(t, 42, value)
+
+	value, _, err = decodeDirectoryCursor(repository.ChannelSortActivity, encodeDirectoryCursor(repository.ChannelSortActivity, entry))
+	require.NoError(t, err)
+	assert.True(t, entry.LastActivityAt.Equal(value.(time.Time)))
+
+	_, _, err = decodeDirectoryCursor(repository.ChannelSortMembers, encodeDirectoryCursor(repository.ChannelSortName, entry))
+	assert.Equal(t, ErrInvalidCursor, err)
+}
+
+func TestCheckPostingPolicy(t *testing.T) {
+	mockWS := new(MockWorkspaceRepository)
+	svc := &messageService{workspaceRepo: mockWS, authz: authz.New(mockWS, nil)}
+
+	admin, poster, member := uuid.New(), uuid.New(), uuid.New()
+	channel := &models.Channel{
+		ID:            uuid.New(),
+		WorkspaceID:   uuid.New(),
+		PostingPolicy: PostingPolicyAdmins,
+		ReplyPolicy:   PostingPolicyEveryone,
+	}
+	mockWS.On("GetMember", channel.WorkspaceID, admin).Return(&models.WorkspaceMember{Role: "admin"}, nil)
+	mockWS.On("GetMember", channel.WorkspaceID, poster).Return(&models.WorkspaceMember{Role: "member"}, nil)
+	mockWS.On("GetMember", channel.WorkspaceID, member).Return(&models.WorkspaceMember{Role: "member"}, nil)
+
+	// Announcement channel: only admins post, everyone replies in threads
+	assert.NoError(t, svc.checkPostingPolicy(admin, channel, false, false))
+	assert.Equal(t, ErrPostingRestricted, svc.checkPostingPolicy(member, channel, false, false))
+	assert.NoError(t, svc.checkPostingPolicy(member, channel, true, false))
+	// Echoing a reply to the channel is posting to it
+	assert.Equal(t, ErrPostingRestricted, svc.checkPostingPolicy(member, channel, true, true))
+
+	channel.PostingPolicy = PostingPolicyAllowlist
+	channel.ReplyPolicy = PostingPolicyAllowlist
+	channel.PosterIDs = []uuid.UUID{poster}
+	assert.NoError(t, svc.checkPostingPolicy(poster, channel, true, true))
+	assert.NoError(t, svc.checkPostingPolicy(admin, channel, false, false))
+	assert.Equal(t, ErrReplyRestricted, svc.checkPostingPolicy(member, channel, true, false))
+}
+
+func TestSystemMessageTextPostingPolicy(t *testing.T) {
+	changes := channelChanges(
+		&models.Channel{PostingPolicy: PostingPolicyEveryone, ReplyPolicy: PostingPolicyAdmins},
+		&models.Channel{PostingPolicy: PostingPolicyAdmins, ReplyPolicy: PostingPolicyEveryone},
+	)
+	require.Len(t, changes, 2)
+	assert.Equal(t, "restricted posting to workspace admins", systemMessageText(changes[0]))
+	assert.Equal(t, "opened thread replies to everyone", systemMessageText(changes[1]))
+}
+
+func TestGuestsStayInTheirChannels(t *testing.T) {
+	mockWS := new(MockWorkspaceRepository)
+	channelSvc := NewChannelService(nil, mockWS, nil, nil, authz.New(mockWS, nil), nil)
+	searchSvc := NewSearchService(nil, mockWS)
+
+	wsID, guestID := uuid.New(), uuid.New()
+	guest := &models.WorkspaceMember{WorkspaceID: wsID, UserID: guestID, Role: authz.RoleMultiChannelGuest}
+	mockWS.On("GetMember", wsID, guestID).Return(guest, nil)
+
+	_, err := channelSvc.BrowseChannels(wsID, guestID, &dto.BrowseChannelsQuery{})
+	assert.Equal(t, ErrGuestRestricted, err)
+
+	_, err = searchSvc.SearchMessages(guestID, wsID, "roadmap", 20, 0)
+	assert.Equal(t, ErrGuestRestricted, err)
+
+	assert.False(t, canReadPublicChannel(guest))
+	assert.False(t, canReadPublicChannel(nil))
+	assert.True(t, canReadPublicChannel(&models.WorkspaceMember{Role: authz.RoleMember}))
+}
+
+// newRecordingHub runs a hub and records every message broadcast through it.
+func newRecordingHub() (*websocket.Hub, *[]*websocket.WSMessage) {
+	hub := websocket.NewHub()
+	go hub.Run()
+	var sent []*websocket.WSMessage
+	hub.AddListener(func(msg *websocket.WSMessage) {
+		sent = append(sent, msg)
+	})
+	return hub, &sent
+}
+
+func TestJoinChannel(t *testing.T) {
+	mockChannels := new(MockChannelRepository)
+	mockWS := new(MockWorkspaceRepository)
+	hub, sent := newRecordingHub()
+	svc := NewChannelService(mockChannels, mockWS, nil, nil, authz.New(mockWS, nil), hub)
+
+	wsID := uuid.New()
+	public := &models.Channel{ID: uuid.New(), WorkspaceID: wsID}
+	private := &models.Channel{ID: uuid.New(), WorkspaceID: wsID, IsPrivate: true}
+	member := &models.WorkspaceMember{WorkspaceID: wsID, UserID: uuid.New(), Role: authz.RoleMember}
+	guest := &models.WorkspaceMember{WorkspaceID: wsID, UserID: uuid.New(), Role: authz.RoleMultiChannelGuest}
+	mockChannels.On("FindByID", public.ID).Return(public, nil)
+	mockChannels.On("FindByID", private.ID).Return(private, nil)
+	mockWS.On("GetMember", wsID, member.UserID).Return(member, nil)
+	mockWS.On("GetMember", wsID, guest.UserID).Return(guest, nil)
+	mockChannels.On("IsMember", mock.Anything, mock.Anything).Return(false, nil)
+	mockChannels.On("AddMember", public.ID, member.UserID).Return(nil)
+
+	// Private channels are joined by invitation only, and guests only get in
+	// when someone adds them
+	_, err := svc.JoinChannel(member.UserID, private.ID)
+	assert.Equal(t, ErrPrivateChannel, err)
+	_, err = svc.JoinChannel(guest.UserID, public.ID)
+	assert.Equal(t, ErrGuestRestricted, err)
+	assert.Empty(t, *sent)
+
+	_, err = svc.JoinChannel(member.UserID, public.ID)
+	assert.NoError(t, err)
+	mockChannels.AssertNumberOfCalls(t, "AddMember", 1)
+	require.Len(t, *sent, 1)
+	assert.Equal(t, websocket.EventChannelJoined, (*sent)[0].Type)
+	assert.Equal(t, public.ID, *(*sent)[0].ChannelID)
+}
+
+func TestLeaveChannel(t *testing.T) {
+	mockChannels := new(MockChannelRepository)
+	hub, sent := newRecordingHub()
+	svc := NewChannelService(mockChannels, nil, nil, nil, nil, hub)
+
+	channel := &models.Channel{ID: uuid.New(), WorkspaceID: uuid.New()}
+	userID, outsider := uuid.New(), uuid.New()
+	mockChannels.On("FindByID", channel.ID).Return(channel, nil)
+	mockChannels.On("IsMember", channel.ID, userID).Return(true, nil)
+	mockChannels.On("IsMember", channel.ID, outsider).Return(false, nil)
+	mockChannels.On("RemoveMember", channel.ID, userID).Return(nil)
+
+	assert.Equal(t, ErrNotChannelMember, svc.LeaveChannel(outsider, channel.ID))
+
+	assert.NoError(t, svc.LeaveChannel(userID, channel.ID))
+	mockChannels.AssertNumberOfCalls(t, "RemoveMember", 1)
+
+	// The channel and the user who left both hear about it
+	require.Len(t, *sent, 2)
+	assert.Equal(t, websocket.EventChannelLeft, (*sent)[0].Type)
+	assert.Equal(t, channel.ID, *(*sent)[0].ChannelID)
+	assert.Equal(t, userID, *(*sent)[1].UserID)
+}
+
+func TestAddMembers(t *testing.T) {
+	mockChannels := new(MockChannelRepository)
+	mockWS := new(MockWorkspaceRepository)
+	hub, sent := newRecordingHub()
+	svc := NewChannelService(mockChannels, mockWS, nil, nil, authz.New(mockWS, nil), hub)
+
+	wsID := uuid.New()
+	private := &models.Channel{ID: uuid.New(), WorkspaceID: wsID, IsPrivate: true}
+	insider := &models.WorkspaceMember{WorkspaceID: wsID, UserID: uuid.New(), Role: authz.RoleMember}
+	outsider := &models.WorkspaceMember{WorkspaceID: wsID, UserID: uuid.New(), Role: authz.RoleMember}
+	guest := &models.WorkspaceMember{WorkspaceID: wsID, UserID: uuid.New(), Role: authz.RoleMultiChannelGuest}
+	invitee := &models.WorkspaceMember{WorkspaceID: wsID, UserID: uuid.New(), Role: authz.RoleMember}
+	mockChannels.On("FindByID", private.ID).Return(private, nil)
+	for _, m := range []*models.WorkspaceMember{insider, outsider, guest, invitee} {
+		mockWS.On("GetMember", wsID, m.UserID).Return(m, nil)
+	}
+	mockChannels.On("IsMember", private.ID, insider.UserID).Return(true, nil)
+	mockChannels.On("IsMember", private.ID, guest.UserID).Return(true, nil)
+	mockChannels.On("IsMember", private.ID, outsider.UserID).Return(false, nil)
+	mockChannels.On("IsMember", private.ID, invitee.UserID).Return(false, nil)
+	mockChannels.On("AddMember", private.ID, invitee.UserID).Return(nil)
+	mockChannels.On("ListMembers", private.ID).Return([]*models.ChannelMember{}, nil)
+
+	// Guests can't invite anyone, even into channels they are in
+	_, err := svc.AddMembers(guest.UserID, private.ID, []uuid.UUID{invitee.UserID})
+	assert.Equal(t, ErrUnauthorized, err)
+
+	// Only members of a private channel can invite people into it
+	_, err = svc.AddMembers(outsider.UserID, private.ID, []uuid.UUID{invitee.UserID})
+	assert.Equal(t, ErrUnauthorized, err)
+	mockChannels.AssertNotCalled(t, "AddMember", mock.Anything, mock.Anything)
+	assert.Empty(t, *sent)
+
+	_, err = svc.AddMembers(insider.UserID, private.ID, []uuid.UUID{invitee.UserID})
+	assert.NoError(t, err)
+	mockChannels.AssertNumberOfCalls(t, "AddMember", 1)
+	require.Len(t, *sent, 1)
+	assert.Equal(t, websocket.EventChannelJoined, (*sent)[0].Type)
+
+	var payload websocket.ChannelMemberPayload
+	require.NoError(t, json.Unmarshal((*sent)[0].Payload, &payload))
+	assert.Equal(t, invitee.UserID, payload.UserID)
+	assert.Equal(t, insider.UserID, *payload.ActorID)
+}
+
+func TestRemoveChannelMember(t *testing.T) {
+	mockChannels := new(MockChannelRepository)
+	mockWS := new(MockWorkspaceRepository)
+	hub, sent := newRecordingHub()
+	svc := NewChannelService(mockChannels, mockWS, nil, nil, authz.New(mockWS, nil), hub)
+
+	wsID := uuid.New()
+	channel := &models.Channel{ID: uuid.New(), WorkspaceID: wsID}
+	owner := &models.WorkspaceMember{WorkspaceID: wsID, UserID: uuid.New(), Role: authz.RoleOwner}
+	admin := &models.WorkspaceMember{WorkspaceID: wsID, UserID: uuid.New(), Role: authz.RoleAdmin}
+	member := &models.WorkspaceMember{WorkspaceID: wsID, UserID: uuid.New(), Role: authz.RoleMember}
+	mockChannels.On("FindByID", channel.ID).Return(channel, nil)
+	for _, m := range []*models.WorkspaceMember{owner, admin, member} {
+		mockWS.On("GetMember", wsID, m.UserID).Return(m, nil)
+	}
+	mockChannels.On("IsMember", channel.ID, mock.Anything).Return(true, nil)
+	mockChannels.On("RemoveMember", channel.ID, member.UserID).Return(nil)
+
+	// Members don't hold channel.remove_members
+	assert.Equal(t, ErrUnauthorized, svc.RemoveMember(member.UserID, channel.ID, admin.UserID))
+
+	// Admins do, but only the owner can remove the owner
+	assert.Equal(t, ErrUnauthorized, svc.RemoveMember(admin.UserID, channel.ID, owner.UserID))
+	mockChannels.AssertNotCalled(t, "RemoveMember", mock.Anything, mock.Anything)
+	assert.Empty(t, *sent)
+
+	assert.NoError(t, svc.RemoveMember(admin.UserID, channel.ID, member.UserID))
+	mockChannels.AssertNumberOfCalls(t, "RemoveMember", 1)
+	require.Len(t, *sent, 2)
+	assert.Equal(t, websocket.EventChannelLeft, (*sent)[0].Type)
+	assert.Equal(t, member.UserID, *(*sent)[1].UserID)
+}
+
+func TestConvertChannel(t *testing.T) {
+	mockChannels := new(MockChannelRepository)
+	mockWS := new(MockWorkspaceRepository)
+	mockMessages := new(MockMessageRepository)
+	hub, sent := newRecordingHub()
+	svc := NewChannelService(mockChannels, mockWS, nil, mockMessages, authz.New(mockWS, nil), hub)
+
+	wsID := uuid.New()
+	channel := &models.Channel{ID: uuid.New(), WorkspaceID: wsID, Name: "plans"}
+	general := &models.Channel{ID: uuid.New(), WorkspaceID: wsID, Name: "general", IsDefault: true}
+	admin := &models.WorkspaceMember{WorkspaceID: wsID, UserID: uuid.New(), Role: authz.RoleAdmin}
+	member := &models.WorkspaceMember{WorkspaceID: wsID, UserID: uuid.New(), Role: authz.RoleMember}
+	mockChannels.On("FindByID", channel.ID).Return(channel, nil)
+	mockChannels.On("FindByID", general.ID).Return(general, nil)
+	mockWS.On("GetMember", wsID, admin.UserID).Return(admin, nil)
+	mockWS.On("GetMember", wsID, member.UserID).Return(member, nil)
+	mockChannels.On("Update", channel).Return(nil)
+	mockChannels.On("ListMembers", channel.ID).Return([]*models.ChannelMember{{ChannelID: channel.ID, UserID: admin.UserID}}, nil)
+	mockChannels.On("PruneNonMembers", channel.ID).Return(nil)
+	mockChannels.On("AddChange", mock.Anything).Return(nil)
+	mockMessages.On("Create", mock.Anything).Return(nil)
+
+	// Only admins can convert channels, and default channels stay public
+	_, err := svc.ConvertChannel(member.UserID, channel.ID, true)
+	assert.Equal(t, ErrUnauthorized, err)
+	_, err = svc.ConvertChannel(admin.UserID, general.ID, true)
+	assert.Equal(t, ErrPrivateDefault, err)
+	mockChannels.AssertNotCalled(t, "Update", mock.Anything)
+	assert.Empty(t, *sent)
+
+	// Going private cuts off everyone who isn't a member
+	updated, err := svc.ConvertChannel(admin.UserID, channel.ID, true)
+	require.NoError(t, err)
+	assert.True(t, updated.IsPrivate)
+	mockChannels.AssertCalled(t, "PruneNonMembers", channel.ID)
+
+	require.Len(t, *sent, 2)
+	assert.Equal(t, websocket.EventMessageNew, (*sent)[0].Type)
+	assert.Equal(t, websocket.EventChannelUpdated, (*sent)[1].Type)
+	assert.Equal(t, channel.ID, *(*sent)[1].ChannelID)
+}
+
+func TestArchivedChannelIsReadOnly(t *testing.T) {
+	mockChannels := new(MockChannelRepository)
+	mockWS := new(MockWorkspaceRepository)
+	mockMessages := new(MockMessageRepository)
+	messageSvc := NewMessageService(mockMessages, mockChannels, mockWS, nil, nil, nil, nil, nil, authz.New(mockWS, nil), nil, nil, nil)
+	reactionSvc := NewReactionService(nil, mockMessages, mockChannels, nil, mockWS, messageSvc, nil)
+
+	wsID, userID := uuid.New(), uuid.New()
+	archivedAt := time.Now()
+	channel := &models.Channel{ID: uuid.New(), WorkspaceID: wsID, ArchivedAt: &archivedAt}
+	message := &models.Message{ID: uuid.New(), SenderID: &userID, ChannelID: &channel.ID}
+	mockChannels.On("FindByID", channel.ID).Return(channel, nil)
+	mockChannels.On("IsMember", channel.ID, userID).Return(true, nil)
+	mockWS.On("FindByID", wsID).Return(&models.Workspace{ID: wsID}, nil)
+	mockMessages.On("FindByID", message.ID).Return(message, nil)
+
+	// The history stays readable but nothing in it can change
+	_, err := messageSvc.SendChannelMessage(userID, channel.ID, "hello", nil, nil, false, false)
+	assert.Equal(t, ErrChannelArchived, err)
+	_, err = messageSvc.UpdateMessage(userID, message.ID, &dto.UpdateMessageRequest{Content: "edited"})
+	assert.Equal(t, ErrChannelArchived, err)
+	assert.Equal(t, ErrChannelArchived, messageSvc.DeleteMessage(userID, message.ID))
+	_, _, err = reactionSvc.AddReaction(userID, message.ID, "thumbsup")
+	assert.Equal(t, ErrChannelArchived, err)
+
+	mockMessages.AssertNotCalled(t, "Create", mock.Anything)
+	mockMessages.AssertNotCalled(t, "Update", mock.Anything)
+	mockMessages.AssertNotCalled(t, "SoftDelete", mock.Anything)
+}
+
+func TestListWorkspaceChannelsArchived(t *testing.T) {
+	mockChannels := new(MockChannelRepository)
+	mockWS := new(MockWorkspaceRepository)
+	svc := NewChannelService(mockChannels, mockWS, nil, nil, nil, nil)
+
+	wsID := uuid.New()
+	member := &models.WorkspaceMember{WorkspaceID: wsID, UserID: uuid.New(), Role: authz.RoleMember}
+	outsider := uuid.New()
+	archivedAt := time.Now()
+	open := &models.Channel{ID: uuid.New(), WorkspaceID: wsID}
+	archived := &models.Channel{ID: uuid.New(), WorkspaceID: wsID, ArchivedAt: &archivedAt}
+	mockWS.On("GetMember", wsID, member.UserID).Return(member, nil)
+	mockWS.On("GetMember", wsID, outsider).Return(nil, nil)
+	mockChannels.On("ListByWorkspaceID", wsID, member.UserID, false).Return([]*models.Channel{open}, nil)
+	mockChannels.On("ListByWorkspaceID", wsID, member.UserID, true).Return([]*models.Channel{open, archived}, nil)
+
+	_, err := svc.ListWorkspaceChannels(wsID, outsider, true)
+	assert.Equal(t, ErrUnauthorized, err)
+
+	// Archived channels are left out unless asked for
+	channels, err := svc.ListWorkspaceChannels(wsID, member.UserID, false)
+	require.NoError(t, err)
+	assert.Equal(t, []*models.Channel{open}, channels)
+
+	channels, err = svc.ListWorkspaceChannels(wsID, member.UserID, true)
+	require.NoError(t, err)
+	assert.Equal(t, []*models.Channel{open, archived}, channels)
+}
+
+func TestDeleteChannelConfirmation(t *testing.T) {
+	mockChannels := new(MockChannelRepository)
+	mockWS := new(MockWorkspaceRepository)
+	svc := NewChannelService(mockChannels, mockWS, nil, nil, authz.New(mockWS, nil), nil)
+
+	wsID := uuid.New()
+	channel := &models.Channel{ID: uuid.New(), WorkspaceID: wsID, Name: "plans"}
+	owner := &models.WorkspaceMember{WorkspaceID: wsID, UserID: uuid.New(), Role: authz.RoleOwner}
+	admin := &models.WorkspaceMember{WorkspaceID: wsID, UserID: uuid.New(), Role: authz.RoleAdmin}
+	mockChannels.On("FindByID", channel.ID).Return(channel, nil)
+	mockWS.On("GetMember", wsID, owner.UserID).Return(owner, nil)
+	mockWS.On("GetMember", wsID, admin.UserID).Return(admin, nil)
+	mockChannels.On("Delete", channel.ID).Return(nil)
+
+	// Only the owner deletes, and only after typing the channel's name
+	assert.Equal(t, ErrUnauthorized, svc.DeleteChannel(admin.UserID, channel.ID, "plans"))
+	assert.Equal(t, ErrConfirmationFailed, svc.DeleteChannel(owner.UserID, channel.ID, "plan"))
+	assert.Equal(t, ErrConfirmationFailed, svc.DeleteChannel(owner.UserID, channel.ID, ""))
+	mockChannels.AssertNotCalled(t, "Delete", mock.Anything)
+
+	assert.NoError(t, svc.DeleteChannel(owner.UserID, channel.ID, "plans"))
+	mockChannels.AssertNumberOfCalls(t, "Delete", 1)
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+	"github.com/DoDuy2004/slack-clone-backend/internal/models/dto"
+	"github.com/DoDuy2004/slack-clone-backend/internal/repository"
+	"github.com/DoDuy2004/slack-clone-backend/pkg/mailer"
+	"github.com/google/uuid"
+)
+
+const (
+	digestBatchSize    = 100
+	digestItemsPerMail = 20
+)
+
+type DigestService interface {
+	GetSettings(userID uuid.UUID) (*models.EmailDigestSettings, error)
+	UpdateSettings(userID uuid.UUID, req *dto.UpdateDigestSettingsRequest) (*models.EmailDigestSettings, error)
+	// SendDigests emails every idle user who is due a digest. It returns the
+	// number of digests sent.
+	SendDigests() (int, error)
+	RunDigests(interval time.Duration)
+}
+
+type digestService struct {
+	digestRepo repository.DigestRepository
+	mailer     mailer.Mailer
+	appURL     string
+	idleAfter  time.Duration
+}
+
+func NewDigestService(digestRepo repository.DigestRepository, mailer mailer.Mailer, appURL string, idleAfter time.Duration) DigestService {
+	return &digestService{
+		digestRepo: digestRepo,
+		mailer:     mailer,
+		appURL:     appURL,
+		idleAfter:  idleAfter,
+	}
+}
+
+func (s *digestService) GetSettings(userID uuid.UUID) (*models.EmailDigestSettings, error) {
+	settings, err := s.digestRepo.GetSettings(userID)
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		settings = &models.EmailDigestSettings{UserID: userID, Enabled: true, Frequency: "daily"}
+	}
+	return settings, nil
+}
+
+func (s *digestService) UpdateSettings(userID uuid.UUID, req *dto.UpdateDigestSettingsRequest) (*models.EmailDigestSettings, error) {
+	settings, err := s.GetSettings(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Enabled != nil {
+		settings.Enabled = *req.Enabled
+	}
+	if req.Frequency != nil {
+		settings.Frequency = *req.Frequency
+	}
+
+	if err := s.digestRepo.UpsertSettings(settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+func (s *digestService) SendDigests() (int, error) {
+	users, err := s.digestRepo.ListRecipients(s.idleAfter, digestBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, user := range users {
+		items, err := s.digestRepo.ListItems(user.ID, digestItemsPerMail)
+		if err != nil {
+			log.Printf("error listing digest items for user %s: %v", user.ID, err)
+			continue
+		}
+		if len(items) == 0 {
+			continue
+		}
+
+		msg, err := renderDigest(user, items, s.appURL)
+		if err != nil {
+			log.Printf("error rendering digest for user %s: %v", user.ID, err)
+			continue
+		}
+		if err := s.mailer.Send(msg); err != nil {
+			log.Printf("error sending digest to user %s: %v", user.ID, err)
+			continue
+		}
+		if err := s.digestRepo.MarkSent(user.ID); err != nil {
+			log.Printf("error marking digest sent for user %s: %v", user.ID, err)
+		}
+		sent++
+	}
+
+	return sent, nil
+}
+
+// RunDigests polls for users due a digest until the process exits.
+func (s *digestService) RunDigests(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := s.SendDigests(); err != nil {
+			log.Printf("error sending email digests: %v", err)
+		}
+	}
+}
+
+type digestView struct {
+	Name     string
+	AppURL   string
+	DMs      []digestEntry
+	Mentions []digestEntry
+}
+
+type digestEntry struct {
+	From      string
+	Where     string
+	Content   string
+	CreatedAt string
+}
+
+var digestHTMLTemplate = htmltemplate.Must(htmltemplate.New("digest").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif; color: #1d1c1d;">
+<p>Hi {{.Name}},</p>
+<p>Here's what you missed while you were away.</p>
+{{if .DMs}}<h3>Direct messages</h3>
+<ul>{{range .DMs}}
+<li><strong>{{.From}}</strong> <span style="color: #616061;">in {{.Where}} · {{.CreatedAt}}</span><br>{{.Content}}</li>{{end}}
+</ul>{{end}}
+{{if .Mentions}}<h3>Mentions</h3>
+<ul>{{range .Mentions}}
+<li><strong>{{.From}}</strong> <span style="color: #616061;">in {{.Where}} · {{.CreatedAt}}</span><br>{{.Content}}</li>{{end}}
+</ul>{{end}}
+<p><a href="{{.AppURL}}">Open the app</a> to reply.</p>
+<p style="color: #616061; font-size: 12px;">You can turn these emails off in your notification settings.</p>
+</body>
+</html>
+`))
+
+var digestTextTemplate = texttemplate.Must(texttemplate.New("digest").Parse(`Hi {{.Name}},
+
+Here's what you missed while you were away.
+{{if .DMs}}
+Direct messages
+{{range .DMs}}
+- {{.From}} in {{.Where}} ({{.CreatedAt}}): {{.Content}}{{end}}
+{{end}}{{if .Mentions}}
+Mentions
+{{range .Mentions}}
+- {{.From}} in {{.Where}} ({{.CreatedAt}}): {{.Content}}{{end}}
+{{end}}
+Open the app to reply: {{.AppURL}}
+
+You can turn these emails off in your notification settings.
+`))
+
+// renderDigest builds the digest email for a user from their unread items.
+func renderDigest(user *models.User, items []*models.DigestItem, appURL string) (*mailer.Message, error) {
+	view := digestView{Name: user.Username, AppURL: appURL}
+	if user.FullName != nil && *user.FullName != "" {
+		view.Name = *user.FullName
+	}
+
+	for _, item := range items {
+		entry := digestEntry{
+			From:      "Someone",
+			Where:     item.WorkspaceName,
+			Content:   item.Message.Content,
+			CreatedAt: item.Message.CreatedAt.UTC().Format("Jan 2, 15:04 MST"),
+		}
+		if item.Message.Sender != nil {
+			entry.From = item.Message.Sender.Username
+			if item.Message.Sender.FullName != nil && *item.Message.Sender.FullName != "" {
+				entry.From = *item.Message.Sender.FullName
+			}
+		}
+		if item.ChannelName != nil {
+			entry.Where = fmt.Sprintf("#%s (%s)", *item.ChannelName, item.WorkspaceName)
+		}
+
+		if item.Kind == "dm" {
+			view.DMs = append(view.DMs, entry)
+		} else {
+			view.Mentions = append(view.Mentions, entry)
+		}
+	}
+
+	var html, text bytes.Buffer
+	if err := digestHTMLTemplate.Execute(&html, view); err != nil {
+		return nil, err
+	}
+	if err := digestTextTemplate.Execute(&text, view); err != nil {
+		return nil, err
+	}
+
+	subject := fmt.Sprintf("You have %d unread messages", len(items))
+	if len(items) == 1 {
+		subject = "You have 1 unread message"
+	}
+
+	return &mailer.Message{
+		To:      user.Email,
+		Subject: subject,
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+	"github.com/DoDuy2004/slack-clone-backend/internal/models/dto"
+	"github.com/DoDuy2004/slack-clone-backend/pkg/mailer"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockDigestRepository is a mock implementation of DigestRepository
+type MockDigestRepository struct {
+	mock.Mock
+}
+
+func (m *MockDigestRepository) GetSettings(userID uuid.UUID) (*models.EmailDigestSettings, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.EmailDigestSettings), args.Error(1)
+}
+
+func (m *MockDigestRepository) UpsertSettings(settings *models.EmailDigestSettings) error {
+	args := m.Called(settings)
+	return args.Error(0)
+}
+
+func (m *MockDigestRepository) ListRecipients(idleFor time.Duration, limit int) ([]*models.User, error) {
+	args := m.Called(idleFor, limit)
+	return args.Get(0).([]*models.User), args.Error(1)
+}
+
+func (m *MockDigestRepository) ListItems(userID uuid.UUID, limit int) ([]*models.DigestItem, error) {
+	args := m.Called(userID, limit)
+	return args.Get(0).([]*models.DigestItem), args.Error(1)
+}
+
+func (m *MockDigestRepository) MarkSent(userID uuid.UUID) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func TestSendDigests(t *testing.T) {
+	mockRepo := new(MockDigestRepository)
+	sink := mailer.NewMemorySink()
+	svc := NewDigestService(mockRepo, sink, "https://chat.example.com", time.Hour)
+
+	fullName := "Alice Smith"
+	alice := &models.User{ID: uuid.New(), Email: "alice@example.com", Username: "alice", FullName: &fullName}
+	bob := &models.User{ID: uuid.New(), Email: "bob@example.com", Username: "bob"}
+	general := "general"
+	items := []*models.DigestItem{
+		{Kind: "dm", WorkspaceName: "Acme", Message: models.Message{Content: "are you around?", Sender: bob, CreatedAt: time.Now()}},
+		{Kind: "mention", WorkspaceName: "Acme", ChannelName: &general, Message: models.Message{Content: "@alice <b>please review</b>", Sender: bob, CreatedAt: time.Now()}},
+	}
+
+	mockRepo.On("ListRecipients", time.Hour, digestBatchSize).Return([]*models.User{alice}, nil)
+	mockRepo.On("ListItems", alice.ID, digestItemsPerMail).Return(items, nil)
+	mockRepo.On("MarkSent", alice.ID).Return(nil)
+
+	sent, err := svc.SendDigests()
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	messages := sink.Messages()
+	assert.Len(t, messages, 1)
+	assert.Equal(t, "alice@example.com", messages[0].To)
+	assert.Equal(t, "You have 2 unread messages", messages[0].Subject)
+	assert.Contains(t, messages[0].Text, "Hi Alice Smith")
+	assert.Contains(t, messages[0].Text, "bob in #general (Acme)")
+	assert.Contains(t, messages[0].HTML, "&lt;b&gt;please review&lt;/b&gt;")
+	assert.Contains(t, messages[0].HTML, "https://chat.example.com")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSendDigests_NothingToSend(t *testing.T) {
+	mockRepo := new(MockDigestRepository)
+	sink := mailer.NewMemorySink()
+	svc := NewDigestService(mockRepo, sink, "https://chat.example.com", time.Hour)
+
+	mockRepo.On("ListRecipients", time.Hour, digestBatchSize).Return([]*models.User{}, nil)
+
+	sent, err := svc.SendDigests()
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, sent)
+	assert.Empty(t, sink.Messages())
+}
+
+func TestUpdateDigestSettings_OptOut(t *testing.T) {
+	mockRepo := new(MockDigestRepository)
+	svc := NewDigestService(mockRepo, mailer.NewMemorySink(), "", time.Hour)
+	userID := uuid.New()
+	disabled := false
+
+	mockRepo.On("GetSettings", userID).Return(nil, nil)
+	mockRepo.On("UpsertSettings", mock.AnythingOfType("*models.EmailDigestSettings")).Return(nil)
+
+	settings, err := svc.UpdateSettings(userID, &dto.UpdateDigestSettingsRequest{Enabled: &disabled})
+
+	assert.NoError(t, err)
+	assert.False(t, settings.Enabled)
+	assert.Equal(t, "daily", settings.Frequency)
+	mockRepo.AssertExpectations(t)
+}
@@ -3,13 +3,15 @@ package service
 import (
 	"errors"
 
+	"github.com/DoDuy2004/slack-clone-backend/internal/authz"
 	"github.com/DoDuy2004/slack-clone-backend/internal/models"
 	"github.com/DoDuy2004/slack-clone-backend/internal/repository"
 	"github.com/google/uuid"
 )
 
 var (
-	ErrDMNotFound = errors.New("dm session not found")
+	ErrDMNotFound       = errors.New("dm session not found")
+	ErrGuestDMForbidden = errors.New("guests can only message people they share a channel with")
 )
 
 type DMService interface {
@@ -21,17 +23,20 @@ type dmService struct {
 	dmRepo        repository.DMRepository
 	workspaceRepo repository.WorkspaceRepository
 	userRepo      repository.UserRepository
+	channelRepo   repository.ChannelRepository
 }
 
 func NewDMService(
 	dmRepo repository.DMRepository,
 	workspaceRepo repository.WorkspaceRepository,
 	userRepo repository.UserRepository,
+	channelRepo repository.ChannelRepository,
 ) DMService {
 	return &dmService{
 		dmRepo:        dmRepo,
 		workspaceRepo: workspaceRepo,
 		userRepo:      userRepo,
+		channelRepo:   channelRepo,
 	}
 }
 
@@ -53,11 +58,31 @@ func (s *dmService) CreateDM(userID uuid.UUID, workspaceID uuid.UUID, participan
 	}
 
 	// 1. Verify all users are members of the workspace
+	var guests []uuid.UUID
 	for _, pID := range participantIDs {
 		member, err := s.workspaceRepo.GetMember(workspaceID, pID)
 		if err != nil || member == nil {
 			return nil, errors.New("all participants must be members of the workspace")
 		}
+		if authz.IsGuest(member.Role) {
+			guests = append(guests, pID)
+		}
+	}
+
+	// Guests can only be in DMs with people they share a channel with
+	for _, guestID := range guests {
+		for _, pID := range participantIDs {
+			if pID == guestID {
+				continue
+			}
+			shares, err := s.channelRepo.SharesChannel(workspaceID, guestID, pID)
+			if err != nil {
+				return nil, err
+			}
+			if !shares {
+				return nil, ErrGuestDMForbidden
+			}
+		}
 	}
 
 	// 2. Check if DM already exists
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+	"github.com/DoDuy2004/slack-clone-backend/internal/models/dto"
+	"github.com/DoDuy2004/slack-clone-backend/internal/repository"
+	"github.com/DoDuy2004/slack-clone-backend/internal/websocket"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidTimezone    = errors.New("invalid timezone")
+	ErrInvalidDNDSchedule = errors.New("invalid DND schedule")
+)
+
+type DNDService interface {
+	GetDND(userID uuid.UUID) (*models.DNDSettings, error)
+	UpdateDND(userID uuid.UUID, req *dto.UpdateDNDRequest) (*models.DNDSettings, error)
+	Snooze(userID uuid.UUID, duration time.Duration) (*models.DNDSettings, error)
+	EndSnooze(userID uuid.UUID) (*models.DNDSettings, error)
+	IsActive(userID uuid.UUID) (bool, error)
+}
+
+type dndService struct {
+	dndRepo repository.DNDRepository
+	hub     *websocket.Hub
+}
+
+func NewDNDService(dndRepo repository.DNDRepository, hub *websocket.Hub) DNDService {
+	return &dndService{
+		dndRepo: dndRepo,
+		hub:     hub,
+	}
+}
+
+func (s *dndService) GetDND(userID uuid.UUID) (*models.DNDSettings, error) {
+	settings, err := s.dndRepo.Get(userID)
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		settings = &models.DNDSettings{UserID: userID, Timezone: "UTC", Schedule: []models.DNDWindow{}}
+	}
+
+	settings.Active, settings.ActiveUntil = dndStatus(settings, time.Now())
+	return settings, nil
+}
+
+func (s *dndService) UpdateDND(userID uuid.UUID, req *dto.UpdateDNDRequest) (*models.DNDSettings, error) {
+	settings, err := s.GetDND(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Timezone != nil {
+		if _, err := time.LoadLocation(*req.Timezone); err != nil {
+			return nil, ErrInvalidTimezone
+		}
+		settings.Timezone = *req.Timezone
+	}
+	if req.Schedule != nil {
+		for _, window := range req.Schedule {
+			if err := validateDNDWindow(window); err != nil {
+				return nil, err
+			}
+		}
+		settings.Schedule = req.Schedule
+	}
+	if req.ScheduleEnabled != nil {
+		settings.ScheduleEnabled = *req.ScheduleEnabled
+	}
+
+	return s.save(settings)
+}
+
+func (s *dndService) Snooze(userID uuid.UUID, duration time.Duration) (*models.DNDSettings, error) {
+	settings, err := s.GetDND(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	until := time.Now().UTC().Add(duration)
+	settings.SnoozeUntil = &until
+
+	return s.save(settings)
+}
+
+func (s *dndService) EndSnooze(userID uuid.UUID) (*models.DNDSettings, error) {
+	settings, err := s.GetDND(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	settings.SnoozeUntil = nil
+
+	return s.save(settings)
+}
+
+func (s *dndService) IsActive(userID uuid.UUID) (bool, error) {
+	settings, err := s.dndRepo.Get(userID)
+	if err != nil {
+		return false, err
+	}
+	if settings == nil {
+		return false, nil
+	}
+
+	active, _ := dndStatus(settings, time.Now())
+	return active, nil
+}
+
+// interruptible reports whether the user can be alerted now. Urgent alerts
+// get through DND; if DND can't be checked the alert goes out.
+func interruptible(dndService DNDService, userID uuid.UUID, urgent bool) bool {
+	if urgent {
+		return true
+	}
+	dnd, err := dndService.IsActive(userID)
+	if err != nil {
+		log.Printf("error checking DND for user %s: %v", userID, err)
+	}
+	return !dnd
+}
+
+// save stores the settings and tells the user's other devices.
+func (s *dndService) save(settings *models.DNDSettings) (*models.DNDSettings, error) {
+	if err := s.dndRepo.Upsert(settings); err != nil {
+		return nil, err
+	}
+	settings.Active, settings.ActiveUntil = dndStatus(settings, time.Now())
+
+	payload, err := json.Marshal(settings)
+	if err != nil {
+		log.Printf("error marshaling DND payload: %v", err)
+		return settings, nil
+	}
+
+	userID := settings.UserID
+	s.hub.Broadcast(&websocket.WSMessage{
+		Type:    websocket.EventDNDUpdated,
+		Payload: payload,
+		UserID:  &userID,
+	})
+
+	return settings, nil
+}
+
+// dndStatus reports whether DND is on at now, either because of a snooze or
+// because now falls in one of the weekly windows, and when it ends.
+func dndStatus(settings *models.DNDSettings, now time.Time) (bool, *time.Time) {
+	if settings.SnoozeUntil != nil && settings.SnoozeUntil.After(now) {
+		until := *settings.SnoozeUntil
+		return true, &until
+	}
+	if !settings.ScheduleEnabled {
+		return false, nil
+	}
+
+	loc, err := time.LoadLocation(settings.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	local := now.In(loc)
+
+	var until *time.Time
+	for _, window := range settings.Schedule {
+		startH, startM, err := parseClock(window.Start)
+		if err != nil {
+			continue
+		}
+		endH, endM, err := parseClock(window.End)
+		if err != nil {
+			continue
+		}
+
+		// A window that started yesterday may still be running
+		for _, offset := range []int{-1, 0} {
+			day := local.AddDate(0, 0, offset)
+			if int(day.Weekday()) != window.Day {
+				continue
+			}
+
+			start := time.Date(day.Year(), day.Month(), day.Day(), startH, startM, 0, 0, loc)
+			end := time.Date(day.Year(), day.Month(), day.Day(), endH, endM, 0, 0, loc)
+			if !end.After(start) {
+				end = end.AddDate(0, 0, 1)
+			}
+
+			if !local.Before(start) && local.Before(end) && (until == nil || end.After(*until)) {
+				end = end.UTC()
+				until = &end
+			}
+		}
+	}
+
+	return until != nil, until
+}
+
+func validateDNDWindow(window models.DNDWindow) error {
+	if window.Day < 0 || window.Day > 6 {
+		return ErrInvalidDNDSchedule
+	}
+	if _, _, err := parseClock(window.Start); err != nil {
+		return ErrInvalidDNDSchedule
+	}
+	if _, _, err := parseClock(window.End); err != nil {
+		return ErrInvalidDNDSchedule
+	}
+	if window.Start == window.End {
+		return ErrInvalidDNDSchedule
+	}
+	return nil
+}
+
+// parseClock parses an "HH:MM" time of day.
+func parseClock(clock string) (int, int, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid time of day %q", clock)
+	}
+	return t.Hour(), t.Minute(), nil
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDNDStatus_Snooze(t *testing.T) {
+	now := time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)
+	until := now.Add(30 * time.Minute)
+
+	active, activeUntil := dndStatus(&models.DNDSettings{Timezone: "UTC", SnoozeUntil: &until}, now)
+	assert.True(t, active)
+	assert.Equal(t, until, *activeUntil)
+
+	active, _ = dndStatus(&models.DNDSettings{Timezone: "UTC", SnoozeUntil: &until}, until.Add(time.Second))
+	assert.False(t, active)
+}
+
+func TestDNDStatus_OvernightWindow(t *testing.T) {
+	// Wednesday 22:00 until Thursday 07:00
+	settings := &models.DNDSettings{
+		Timezone:        "UTC",
+		ScheduleEnabled: true,
+		Schedule:        []models.DNDWindow{{Day: int(time.Wednesday), Start: "22:00", End: "07:00"}},
+	}
+
+	wednesdayNight := time.Date(2024, 3, 6, 23, 0, 0, 0, time.UTC)
+	thursdayMorning := time.Date(2024, 3, 7, 6, 59, 0, 0, time.UTC)
+	thursdayDay := time.Date(2024, 3, 7, 7, 0, 0, 0, time.UTC)
+
+	active, until := dndStatus(settings, wednesdayNight)
+	assert.True(t, active)
+	assert.Equal(t, time.Date(2024, 3, 7, 7, 0, 0, 0, time.UTC), *until)
+
+	active, _ = dndStatus(settings, thursdayMorning)
+	assert.True(t, active)
+
+	active, _ = dndStatus(settings, thursdayDay)
+	assert.False(t, active)
+
+	settings.ScheduleEnabled = false
+	active, _ = dndStatus(settings, wednesdayNight)
+	assert.False(t, active)
+}
+
+func TestValidateDNDWindow(t *testing.T) {
+	assert.NoError(t, validateDNDWindow(models.DNDWindow{Day: 1, Start: "09:00", End: "17:30"}))
+	assert.Equal(t, ErrInvalidDNDSchedule, validateDNDWindow(models.DNDWindow{Day: 7, Start: "09:00", End: "17:00"}))
+	assert.Equal(t, ErrInvalidDNDSchedule, validateDNDWindow(models.DNDWindow{Day: 1, Start: "25:00", End: "17:00"}))
+	assert.Equal(t, ErrInvalidDNDSchedule, validateDNDWindow(models.DNDWindow{Day: 1, Start: "09:00", End: "09:00"}))
+}
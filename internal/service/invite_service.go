@@ -1,42 +1,112 @@
 package service
 
 import (
+	"bytes"
 	"crypto/rand"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"strings"
+	texttemplate "text/template"
 	"time"
 
+	"github.com/DoDuy2004/slack-clone-backend/internal/authz"
 	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+	"github.com/DoDuy2004/slack-clone-backend/internal/models/dto"
 	"github.com/DoDuy2004/slack-clone-backend/internal/repository"
+	"github.com/DoDuy2004/slack-clone-backend/internal/websocket"
+	"github.com/DoDuy2004/slack-clone-backend/pkg/mailer"
 	"github.com/google/uuid"
 )
 
+var (
+	ErrGuestInviteChannels     = errors.New("single-channel guests need exactly one channel and multi-channel guests at least one")
+	ErrMemberInviteGuestFields = errors.New("guest_expires_at is only for guest invites")
+	ErrInviteNotFound          = errors.New("invite not found")
+	ErrInviteRevoked           = errors.New("invite has been revoked")
+	ErrInviteEmailMismatch     = errors.New("this invite was sent to a different email address")
+	ErrInviteUnavailable       = errors.New("invite code is no longer valid")
+	ErrInvitePrivateChannel    = errors.New("you can only invite people to private channels you are in")
+)
+
 type InviteService interface {
-	GenerateInvite(userID, workspaceID uuid.UUID, expiresAt *time.Time, maxUses *int) (*models.WorkspaceInvite, error)
+	// GenerateInvite creates an invite link. Guest invites also take the
+	// right to manage guests.
+	GenerateInvite(userID, workspaceID uuid.UUID, req *dto.CreateInviteRequest) (*models.WorkspaceInvite, error)
+	ListInvites(userID, workspaceID uuid.UUID) ([]*models.WorkspaceInvite, error)
+	RevokeInvite(userID, workspaceID, inviteID uuid.UUID) error
+	// ListRedemptions returns who joined through an invite
+	ListRedemptions(userID, workspaceID, inviteID uuid.UUID) ([]*models.InviteRedemption, error)
 	JoinWorkspace(userID uuid.UUID, code string) (*models.Workspace, error)
 }
 
 type inviteService struct {
 	inviteRepo    repository.InviteRepository
 	workspaceRepo repository.WorkspaceRepository
+	channelRepo   repository.ChannelRepository
+	userRepo      repository.UserRepository
+	authz         *authz.Authorizer
+
+	notificationService NotificationService
+	mailer              mailer.Mailer
+	appURL              string
+	hub                 *websocket.Hub
 }
 
-func NewInviteService(inviteRepo repository.InviteRepository, workspaceRepo repository.WorkspaceRepository) InviteService {
+func NewInviteService(inviteRepo repository.InviteRepository, workspaceRepo repository.WorkspaceRepository, channelRepo repository.ChannelRepository, userRepo repository.UserRepository, authorizer *authz.Authorizer, notificationService NotificationService, mail mailer.Mailer, appURL string, hub *websocket.Hub) InviteService {
 	return &inviteService{
-		inviteRepo:    inviteRepo,
-		workspaceRepo: workspaceRepo,
+		inviteRepo:          inviteRepo,
+		workspaceRepo:       workspaceRepo,
+		channelRepo:         channelRepo,
+		userRepo:            userRepo,
+		authz:               authorizer,
+		notificationService: notificationService,
+		mailer:              mail,
+		appURL:              appURL,
+		hub:                 hub,
 	}
 }
 
-func (s *inviteService) GenerateInvite(userID, workspaceID uuid.UUID, expiresAt *time.Time, maxUses *int) (*models.WorkspaceInvite, error) {
-	// 1. Verify user is admin/owner
-	member, err := s.workspaceRepo.GetMember(workspaceID, userID)
+func (s *inviteService) GenerateInvite(userID, workspaceID uuid.UUID, req *dto.CreateInviteRequest) (*models.WorkspaceInvite, error) {
+	role := req.Role
+	if role == "" {
+		role = authz.RoleMember
+	}
+
+	// 1. Verify user may invite people, and guests if this is a guest invite
+	grant, err := s.authz.Grant(workspaceID, userID)
 	if err != nil {
 		return nil, err
 	}
-	if member == nil || (member.Role != "owner" && member.Role != "admin") {
+	if !grant.Has(authz.InviteCreate) {
 		return nil, ErrUnauthorized
 	}
+	if authz.IsGuest(role) {
+		if !grant.Has(authz.MemberManageGuests) {
+			return nil, ErrUnauthorized
+		}
+		if err := s.validateGuestInvite(role, req); err != nil {
+			return nil, err
+		}
+	} else if req.GuestExpiresAt != nil {
+		return nil, ErrMemberInviteGuestFields
+	}
+	if err := s.validateInviteChannels(userID, workspaceID, req.ChannelIDs); err != nil {
+		return nil, err
+	}
+
+	// Email invites are for one person
+	maxUses := req.MaxUses
+	var email *string
+	if req.Email != nil {
+		normalized := strings.ToLower(strings.TrimSpace(*req.Email))
+		email = &normalized
+		one := 1
+		maxUses = &one
+	}
 
 	// 2. Generate random code
 	code, err := generateRandomCode(12)
@@ -49,18 +119,72 @@ func (s *inviteService) GenerateInvite(userID, workspaceID uuid.UUID, expiresAt
 		WorkspaceID: workspaceID,
 		InviterID:   userID,
 		Code:        code,
-		ExpiresAt:   expiresAt,
+		ExpiresAt:   req.ExpiresAt,
 		MaxUses:     maxUses,
 		Uses:        0,
+
+		Role:           role,
+		ChannelIDs:     req.ChannelIDs,
+		GuestExpiresAt: req.GuestExpiresAt,
+		Email:          email,
 	}
 
 	if err := s.inviteRepo.Create(invite); err != nil {
 		return nil, err
 	}
 
+	// 3. Mail email invites; the invite stands even if sending fails, since
+	// its link can still be shared by hand
+	if invite.Email != nil {
+		s.sendInviteEmail(invite)
+	}
+
 	return invite, nil
 }
 
+func (s *inviteService) ListInvites(userID, workspaceID uuid.UUID) ([]*models.WorkspaceInvite, error) {
+	allowed, err := s.authz.Can(workspaceID, userID, authz.InviteCreate)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrUnauthorized
+	}
+
+	return s.inviteRepo.ListByWorkspace(workspaceID)
+}
+
+func (s *inviteService) RevokeInvite(userID, workspaceID, inviteID uuid.UUID) error {
+	allowed, err := s.authz.Can(workspaceID, userID, authz.InviteCreate)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrUnauthorized
+	}
+
+	revoked, err := s.inviteRepo.Revoke(workspaceID, inviteID, userID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrInviteNotFound
+	}
+	return nil
+}
+
+func (s *inviteService) ListRedemptions(userID, workspaceID, inviteID uuid.UUID) ([]*models.InviteRedemption, error) {
+	allowed, err := s.authz.Can(workspaceID, userID, authz.InviteCreate)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrUnauthorized
+	}
+
+	return s.inviteRepo.ListRedemptions(workspaceID, inviteID)
+}
+
 func (s *inviteService) JoinWorkspace(userID uuid.UUID, code string) (*models.Workspace, error) {
 	// 1. Find invite
 	invite, err := s.inviteRepo.FindByCode(code)
@@ -72,12 +196,24 @@ func (s *inviteService) JoinWorkspace(userID uuid.UUID, code string) (*models.Wo
 	}
 
 	// 2. Validate invite
+	if invite.RevokedAt != nil {
+		return nil, ErrInviteRevoked
+	}
 	if invite.ExpiresAt != nil && invite.ExpiresAt.Before(time.Now()) {
 		return nil, errors.New("invite code has expired")
 	}
 	if invite.MaxUses != nil && invite.Uses >= *invite.MaxUses {
 		return nil, errors.New("invite code has reached maximum uses")
 	}
+	if invite.Email != nil {
+		user, err := s.userRepo.FindByID(userID)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil || !strings.EqualFold(user.Email, *invite.Email) {
+			return nil, ErrInviteEmailMismatch
+		}
+	}
 
 	// 3. Check if already a member
 	existing, err := s.workspaceRepo.GetMember(invite.WorkspaceID, userID)
@@ -88,19 +224,133 @@ func (s *inviteService) JoinWorkspace(userID uuid.UUID, code string) (*models.Wo
 		return s.workspaceRepo.FindByID(invite.WorkspaceID) // Already a member, just return workspace
 	}
 
-	// 4. Add member
-	if err := s.workspaceRepo.AddMember(invite.WorkspaceID, userID, "member"); err != nil {
+	// 4. Add the member, count the use and record who used it in one go;
+	// members join the default channels too, guests only the invite's
+	if authz.IsGuest(invite.Role) && invite.GuestExpiresAt != nil && invite.GuestExpiresAt.Before(time.Now()) {
+		return nil, errors.New("guest access from this invite has expired")
+	}
+	redeemed, err := s.inviteRepo.Redeem(invite, userID, !authz.IsGuest(invite.Role))
+	if err != nil {
 		return nil, err
 	}
-
-	// 5. Increment usage
-	if err := s.inviteRepo.IncrementUses(invite.ID); err != nil {
-		// Log error but don't fail join
+	if !redeemed {
+		// Either other joins used the invite up first or the user joined
+		// some other way in the meantime
+		existing, err := s.workspaceRepo.GetMember(invite.WorkspaceID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			return s.workspaceRepo.FindByID(invite.WorkspaceID)
+		}
+		return nil, ErrInviteUnavailable
 	}
 
+	// 5. Subscribe the new member's sockets and tell the workspace
+	s.memberJoined(invite.WorkspaceID, userID, invite.Role)
+
+	// 6. Let the inviter know
+	s.notificationService.Notify(&models.Notification{
+		UserID:      invite.InviterID,
+		WorkspaceID: &invite.WorkspaceID,
+		Type:        NotificationTypeInviteAccepted,
+		ActorID:     &userID,
+	})
+
 	return s.workspaceRepo.FindByID(invite.WorkspaceID)
 }
 
+// validateGuestInvite checks the guest's channel count and access expiry.
+func (s *inviteService) validateGuestInvite(role string, req *dto.CreateInviteRequest) error {
+	if len(req.ChannelIDs) == 0 || (role == authz.RoleSingleChannelGuest && len(req.ChannelIDs) > 1) {
+		return ErrGuestInviteChannels
+	}
+	if req.GuestExpiresAt != nil && !req.GuestExpiresAt.After(time.Now()) {
+		return ErrGuestExpiry
+	}
+	return nil
+}
+
+// validateInviteChannels checks that the channels an invite joins are open
+// channels of the workspace, and that the inviter is in any private ones.
+func (s *inviteService) validateInviteChannels(inviterID, workspaceID uuid.UUID, channelIDs []uuid.UUID) error {
+	for _, channelID := range channelIDs {
+		channel, err := s.channelRepo.FindByID(channelID)
+		if err != nil {
+			return err
+		}
+		if channel == nil || channel.WorkspaceID != workspaceID {
+			return ErrChannelNotFound
+		}
+		if channel.ArchivedAt != nil {
+			return ErrChannelArchived
+		}
+		if channel.IsPrivate {
+			isMember, err := s.channelRepo.IsMember(channelID, inviterID)
+			if err != nil {
+				return err
+			}
+			if !isMember {
+				return ErrInvitePrivateChannel
+			}
+		}
+	}
+	return nil
+}
+
+// sendInviteEmail mails the invite link to the invite's address, logging
+// rather than returning a failure.
+func (s *inviteService) sendInviteEmail(invite *models.WorkspaceInvite) {
+	workspace, err := s.workspaceRepo.FindByID(invite.WorkspaceID)
+	if err != nil || workspace == nil {
+		log.Printf("error loading workspace %s for invite %s: %v", invite.WorkspaceID, invite.ID, err)
+		return
+	}
+	inviter, err := s.userRepo.FindByID(invite.InviterID)
+	if err != nil || inviter == nil {
+		log.Printf("error loading inviter %s for invite %s: %v", invite.InviterID, invite.ID, err)
+		return
+	}
+
+	msg, err := renderInviteEmail(invite, workspace, inviter, s.appURL)
+	if err != nil {
+		log.Printf("error rendering invite %s: %v", invite.ID, err)
+		return
+	}
+	if err := s.mailer.Send(msg); err != nil {
+		log.Printf("error sending invite %s: %v", invite.ID, err)
+	}
+}
+
+// memberJoined subscribes the user's open sockets to the workspace and the
+// channels they are in, and tells everyone in it, including the new member,
+// that they joined.
+func (s *inviteService) memberJoined(workspaceID, userID uuid.UUID, role string) {
+	s.hub.AddUserToRoom("workspace", workspaceID, userID)
+
+	// The invite's channels and the default ones
+	memberships, err := s.channelRepo.ListWorkspaceMemberships(workspaceID)
+	if err != nil {
+		log.Printf("error listing channel memberships of workspace %s: %v", workspaceID, err)
+	}
+	for _, m := range memberships {
+		if m.UserID == userID {
+			s.hub.AddUserToRoom("channel", m.ChannelID, userID)
+		}
+	}
+
+	payload, _ := json.Marshal(websocket.WorkspaceMemberPayload{
+		WorkspaceID: workspaceID,
+		UserID:      userID,
+		Role:        role,
+	})
+	s.hub.Broadcast(&websocket.WSMessage{
+		Type:        websocket.EventWorkspaceJoined,
+		Payload:     payload,
+		WorkspaceID: &workspaceID,
+	})
+}
+
 func generateRandomCode(length int) (string, error) {
 	b := make([]byte, length)
 	if _, err := rand.Read(b); err != nil {
@@ -108,3 +358,54 @@ func generateRandomCode(length int) (string, error) {
 	}
 	return hex.EncodeToString(b), nil
 }
+
+type inviteView struct {
+	Inviter   string
+	Workspace string
+	Guest     bool
+	Link      string
+}
+
+func renderInviteEmail(invite *models.WorkspaceInvite, workspace *models.Workspace, inviter *models.User, appURL string) (*mailer.Message, error) {
+	view := inviteView{
+		Inviter:   inviter.Username,
+		Workspace: workspace.Name,
+		Guest:     authz.IsGuest(invite.Role),
+		Link:      fmt.Sprintf("%s/invite/%s", strings.TrimSuffix(appURL, "/"), invite.Code),
+	}
+	if inviter.FullName != nil && *inviter.FullName != "" {
+		view.Inviter = *inviter.FullName
+	}
+
+	var html, text bytes.Buffer
+	if err := inviteHTMLTemplate.Execute(&html, view); err != nil {
+		return nil, err
+	}
+	if err := inviteTextTemplate.Execute(&text, view); err != nil {
+		return nil, err
+	}
+
+	return &mailer.Message{
+		To:      *invite.Email,
+		Subject: fmt.Sprintf("%s invited you to join %s", view.Inviter, view.Workspace),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
+
+var inviteHTMLTemplate = htmltemplate.Must(htmltemplate.New("invite").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif; color: #1d1c1d;">
+<p><strong>{{.Inviter}}</strong> invited you to join <strong>{{.Workspace}}</strong>{{if .Guest}} as a guest{{end}}.</p>
+<p><a href="{{.Link}}">Join {{.Workspace}}</a></p>
+<p style="color: #616061; font-size: 12px;">This invite can only be used once, by this email address.</p>
+</body>
+</html>
+`))
+
+var inviteTextTemplate = texttemplate.Must(texttemplate.New("invite").Parse(`{{.Inviter}} invited you to join {{.Workspace}}{{if .Guest}} as a guest{{end}}.
+
+Join here: {{.Link}}
+
+This invite can only be used once, by this email address.
+`))
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/authz"
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+	"github.com/DoDuy2004/slack-clone-backend/internal/models/dto"
+	"github.com/DoDuy2004/slack-clone-backend/pkg/mailer"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockInviteRepository is a mock implementation of InviteRepository
+type MockInviteRepository struct {
+	mock.Mock
+}
+
+func (m *MockInviteRepository) Create(invite *models.WorkspaceInvite) error {
+	args := m.Called(invite)
+	return args.Error(0)
+}
+
+func (m *MockInviteRepository) FindByCode(code string) (*models.WorkspaceInvite, error) {
+	args := m.Called(code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WorkspaceInvite), args.Error(1)
+}
+
+func (m *MockInviteRepository) ListByWorkspace(workspaceID uuid.UUID) ([]*models.WorkspaceInvite, error) {
+	args := m.Called(workspaceID)
+	return args.Get(0).([]*models.WorkspaceInvite), args.Error(1)
+}
+
+func (m *MockInviteRepository) Revoke(workspaceID, id, revokedBy uuid.UUID) (bool, error) {
+	args := m.Called(workspaceID, id, revokedBy)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockInviteRepository) Redeem(invite *models.WorkspaceInvite, userID uuid.UUID, joinDefaults bool) (bool, error) {
+	args := m.Called(invite, userID, joinDefaults)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockInviteRepository) ListRedemptions(workspaceID, inviteID uuid.UUID) ([]*models.InviteRedemption, error) {
+	args := m.Called(workspaceID, inviteID)
+	return args.Get(0).([]*models.InviteRedemption), args.Error(1)
+}
+
+func TestGenerateGuestInvite(t *testing.T) {
+	mockWS := new(MockWorkspaceRepository)
+	svc := NewInviteService(nil, mockWS, nil, nil, authz.New(mockWS, nil), nil, nil, "", nil)
+
+	wsID, adminID := uuid.New(), uuid.New()
+	mockWS.On("GetMember", wsID, adminID).Return(&models.WorkspaceMember{WorkspaceID: wsID, UserID: adminID, Role: "admin"}, nil)
+
+	_, err := svc.GenerateInvite(adminID, wsID, &dto.CreateInviteRequest{Role: authz.RoleMultiChannelGuest})
+	assert.Equal(t, ErrGuestInviteChannels, err)
+
+	_, err = svc.GenerateInvite(adminID, wsID, &dto.CreateInviteRequest{
+		Role:       authz.RoleSingleChannelGuest,
+		ChannelIDs: []uuid.UUID{uuid.New(), uuid.New()},
+	})
+	assert.Equal(t, ErrGuestInviteChannels, err)
+
+	guestExpiresAt := time.Now().Add(time.Hour)
+	_, err = svc.GenerateInvite(adminID, wsID, &dto.CreateInviteRequest{GuestExpiresAt: &guestExpiresAt})
+	assert.Equal(t, ErrMemberInviteGuestFields, err)
+}
+
+func TestGenerateEmailInvite(t *testing.T) {
+	mockInvites := new(MockInviteRepository)
+	mockWS := new(MockWorkspaceRepository)
+	mockUsers := new(MockUserRepository)
+	sink := mailer.NewMemorySink()
+	svc := NewInviteService(mockInvites, mockWS, nil, mockUsers, authz.New(mockWS, nil), nil, sink, "https://chat.example.com/", nil)
+
+	wsID, adminID := uuid.New(), uuid.New()
+	mockWS.On("GetMember", wsID, adminID).Return(&models.WorkspaceMember{WorkspaceID: wsID, UserID: adminID, Role: "admin"}, nil)
+	mockWS.On("FindByID", wsID).Return(&models.Workspace{ID: wsID, Name: "Acme"}, nil)
+	mockUsers.On("FindByID", adminID).Return(&models.User{ID: adminID, Username: "alice"}, nil)
+	mockInvites.On("Create", mock.Anything).Return(nil)
+
+	email := " Bob@Example.com"
+	maxUses := 10
+	invite, err := svc.GenerateInvite(adminID, wsID, &dto.CreateInviteRequest{Email: &email, MaxUses: &maxUses})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "bob@example.com", *invite.Email)
+	assert.Equal(t, 1, *invite.MaxUses)
+
+	messages := sink.Messages()
+	assert.Len(t, messages, 1)
+	assert.Equal(t, "bob@example.com", messages[0].To)
+	assert.Equal(t, "alice invited you to join Acme", messages[0].Subject)
+	assert.Contains(t, messages[0].Text, "https://chat.example.com/invite/"+invite.Code)
+}
+
+func TestGenerateInviteToPrivateChannel(t *testing.T) {
+	mockInvites := new(MockInviteRepository)
+	mockWS := new(MockWorkspaceRepository)
+	mockChannels := new(MockChannelRepository)
+	svc := NewInviteService(mockInvites, mockWS, mockChannels, nil, authz.New(mockWS, nil), nil, nil, "", nil)
+
+	wsID, adminID := uuid.New(), uuid.New()
+	joinedID, otherID := uuid.New(), uuid.New()
+	mockWS.On("GetMember", wsID, adminID).Return(&models.WorkspaceMember{WorkspaceID: wsID, UserID: adminID, Role: "admin"}, nil)
+	mockChannels.On("FindByID", joinedID).Return(&models.Channel{ID: joinedID, WorkspaceID: wsID, IsPrivate: true}, nil)
+	mockChannels.On("FindByID", otherID).Return(&models.Channel{ID: otherID, WorkspaceID: wsID, IsPrivate: true}, nil)
+	mockChannels.On("IsMember", joinedID, adminID).Return(true, nil)
+	mockChannels.On("IsMember", otherID, adminID).Return(false, nil)
+	mockInvites.On("Create", mock.Anything).Return(nil)
+
+	// Being an admin isn't enough to hand out a private channel
+	_, err := svc.GenerateInvite(adminID, wsID, &dto.CreateInviteRequest{ChannelIDs: []uuid.UUID{joinedID, otherID}})
+	assert.Equal(t, ErrInvitePrivateChannel, err)
+	mockInvites.AssertNotCalled(t, "Create", mock.Anything)
+
+	invite, err := svc.GenerateInvite(adminID, wsID, &dto.CreateInviteRequest{ChannelIDs: []uuid.UUID{joinedID}})
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{joinedID}, invite.ChannelIDs)
+}
+
+func TestJoinWithRestrictedInvite(t *testing.T) {
+	mockInvites := new(MockInviteRepository)
+	mockWS := new(MockWorkspaceRepository)
+	mockUsers := new(MockUserRepository)
+	svc := NewInviteService(mockInvites, mockWS, nil, mockUsers, authz.New(mockWS, nil), nil, nil, "", nil)
+
+	userID := uuid.New()
+	mockUsers.On("FindByID", userID).Return(&models.User{ID: userID, Email: "carol@example.com"}, nil)
+
+	revokedAt := time.Now()
+	mockInvites.On("FindByCode", "revoked").Return(&models.WorkspaceInvite{WorkspaceID: uuid.New(), Role: authz.RoleMember, RevokedAt: &revokedAt}, nil)
+	_, err := svc.JoinWorkspace(userID, "revoked")
+	assert.Equal(t, ErrInviteRevoked, err)
+
+	email := "bob@example.com"
+	mockInvites.On("FindByCode", "for-bob").Return(&models.WorkspaceInvite{WorkspaceID: uuid.New(), Role: authz.RoleMember, Email: &email}, nil)
+	_, err = svc.JoinWorkspace(userID, "for-bob")
+	assert.Equal(t, ErrInviteEmailMismatch, err)
+
+	mockWS.AssertNotCalled(t, "AddMember", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestJoinWithUsedUpInvite(t *testing.T) {
+	mockInvites := new(MockInviteRepository)
+	mockWS := new(MockWorkspaceRepository)
+	svc := NewInviteService(mockInvites, mockWS, nil, nil, authz.New(mockWS, nil), nil, nil, "", nil)
+
+	wsID, userID, racerID := uuid.New(), uuid.New(), uuid.New()
+	maxUses := 1
+	invite := &models.WorkspaceInvite{ID: uuid.New(), WorkspaceID: wsID, Role: authz.RoleMember, MaxUses: &maxUses}
+	mockInvites.On("FindByCode", "last-seat").Return(invite, nil)
+	mockInvites.On("Redeem", invite, mock.Anything, true).Return(false, nil)
+
+	// Another join took the last use between the check and the redemption
+	mockWS.On("GetMember", wsID, userID).Return(nil, nil)
+	_, err := svc.JoinWorkspace(userID, "last-seat")
+	assert.Equal(t, ErrInviteUnavailable, err)
+
+	// The user joined some other way in the meantime
+	mockWS.On("GetMember", wsID, racerID).Return(nil, nil).Once()
+	mockWS.On("GetMember", wsID, racerID).Return(&models.WorkspaceMember{WorkspaceID: wsID, UserID: racerID, Role: authz.RoleMember}, nil)
+	mockWS.On("FindByID", wsID).Return(&models.Workspace{ID: wsID}, nil)
+	workspace, err := svc.JoinWorkspace(racerID, "last-seat")
+	assert.NoError(t, err)
+	assert.Equal(t, wsID, workspace.ID)
+}
+
+func TestRevokeInvite(t *testing.T) {
+	mockInvites := new(MockInviteRepository)
+	mockWS := new(MockWorkspaceRepository)
+	svc := NewInviteService(mockInvites, mockWS, nil, nil, authz.New(mockWS, nil), nil, nil, "", nil)
+
+	wsID, adminID, memberID, inviteID := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	mockWS.On("GetMember", wsID, adminID).Return(&models.WorkspaceMember{WorkspaceID: wsID, UserID: adminID, Role: "admin"}, nil)
+	mockWS.On("GetMember", wsID, memberID).Return(&models.WorkspaceMember{WorkspaceID: wsID, UserID: memberID, Role: "member"}, nil)
+
+	assert.Equal(t, ErrUnauthorized, svc.RevokeInvite(memberID, wsID, inviteID))
+
+	mockInvites.On("Revoke", wsID, inviteID, adminID).Return(true, nil).Once()
+	assert.NoError(t, svc.RevokeInvite(adminID, wsID, inviteID))
+
+	mockInvites.On("Revoke", wsID, inviteID, adminID).Return(false, nil).Once()
+	assert.Equal(t, ErrInviteNotFound, svc.RevokeInvite(adminID, wsID, inviteID))
+}
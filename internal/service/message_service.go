@@ -1,27 +1,54 @@
 package service
 
 import (
+	"encoding/json"
 	"errors"
+	"log"
 	"regexp"
+	"strings"
 
+	"github.com/DoDuy2004/slack-clone-backend/internal/authz"
 	"github.com/DoDuy2004/slack-clone-backend/internal/models"
 	"github.com/DoDuy2004/slack-clone-backend/internal/models/dto"
 	"github.com/DoDuy2004/slack-clone-backend/internal/repository"
+	"github.com/DoDuy2004/slack-clone-backend/internal/websocket"
 	"github.com/google/uuid"
 )
 
 var (
-	ErrMessageNotFound = errors.New("message not found")
+	ErrMessageNotFound           = errors.New("message not found")
+	ErrBroadcastMentionForbidden = errors.New("not allowed to use @channel, @here or @everyone")
+	ErrUrgentOverrideForbidden   = errors.New("not allowed to send urgent messages")
+	ErrSystemMessage             = errors.New("system messages cannot be edited or deleted")
 )
 
+// Message types stored in messages.type
+const (
+	MessageTypeUser   = "user"
+	MessageTypeSystem = "system"
+)
+
+// Mention types stored in message_mentions
+const (
+	MentionTypeUser     = "user"
+	MentionTypeChannel  = "channel"
+	MentionTypeHere     = "here"
+	MentionTypeEveryone = "everyone"
+)
+
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w])@(\w+)`)
+
 type MessageService interface {
 	GetChannelMessages(userID uuid.UUID, channelID uuid.UUID, limit, offset int) ([]*models.Message, error)
 	GetDMMessages(userID uuid.UUID, dmID uuid.UUID, limit, offset int) ([]*models.Message, error)
 	GetThreads(userID uuid.UUID, parentID uuid.UUID) ([]*models.Message, error)
 	UpdateMessage(userID uuid.UUID, messageID uuid.UUID, req *dto.UpdateMessageRequest) (*models.Message, error)
 	DeleteMessage(userID uuid.UUID, messageID uuid.UUID) error
-	SendChannelMessage(userID, channelID uuid.UUID, content string, parentID *uuid.UUID, attachmentIDs []uuid.UUID) (*models.Message, error)
-	SendDMMessage(userID, dmID uuid.UUID, content string, parentID *uuid.UUID, attachmentIDs []uuid.UUID) (*models.Message, error)
+	SendChannelMessage(userID, channelID uuid.UUID, content string, parentID *uuid.UUID, attachmentIDs []uuid.UUID, alsoSendToChannel bool, urgent bool) (*models.Message, error)
+	SendDMMessage(userID, dmID uuid.UUID, content string, parentID *uuid.UUID, attachmentIDs []uuid.UUID, alsoSendToChannel bool, urgent bool) (*models.Message, error)
+	GetMessage(userID uuid.UUID, messageID uuid.UUID) (*models.Message, error)
+	VerifyMessageAccess(userID uuid.UUID, message *models.Message) error
+	MessageWorkspaceID(message *models.Message) (uuid.UUID, error)
 }
 
 type messageService struct {
@@ -31,6 +58,13 @@ type messageService struct {
 	dmRepo         repository.DMRepository
 	attachmentRepo repository.AttachmentRepository
 	userRepo       repository.UserRepository
+	threadRepo     repository.ThreadRepository
+	mentionRepo    repository.MentionRepository
+	authz          *authz.Authorizer
+	hub            *websocket.Hub
+
+	notificationService NotificationService
+	dndService          DNDService
 }
 
 func NewMessageService(
@@ -40,6 +74,12 @@ func NewMessageService(
 	dmRepo repository.DMRepository,
 	attachmentRepo repository.AttachmentRepository,
 	userRepo repository.UserRepository,
+	threadRepo repository.ThreadRepository,
+	mentionRepo repository.MentionRepository,
+	authorizer *authz.Authorizer,
+	hub *websocket.Hub,
+	notificationService NotificationService,
+	dndService DNDService,
 ) MessageService {
 	return &messageService{
 		messageRepo:    messageRepo,
@@ -48,42 +88,33 @@ func NewMessageService(
 		dmRepo:         dmRepo,
 		attachmentRepo: attachmentRepo,
 		userRepo:       userRepo,
+		threadRepo:     threadRepo,
+		mentionRepo:    mentionRepo,
+		authz:          authorizer,
+		hub:            hub,
+
+		notificationService: notificationService,
+		dndService:          dndService,
 	}
 }
 
-func (s *messageService) SendChannelMessage(userID, channelID uuid.UUID, content string, parentID *uuid.UUID, attachmentIDs []uuid.UUID) (*models.Message, error) {
+func (s *messageService) SendChannelMessage(userID, channelID uuid.UUID, content string, parentID *uuid.UUID, attachmentIDs []uuid.UUID, alsoSendToChannel bool, urgent bool) (*models.Message, error) {
 	// Verify channel membership
-	isMember, err := s.channelRepo.IsMember(channelID, userID)
+	if err := s.verifyChannelAccess(userID, channelID); err != nil {
+		return nil, err
+	}
+	channel, err := writableChannel(s.channelRepo, channelID)
 	if err != nil {
 		return nil, err
 	}
-	if !isMember {
-		// If not direct member, check if it's a public channel and user is in workspace
-		channel, err := s.channelRepo.FindByID(channelID)
-		if err != nil {
-			return nil, err
-		}
-		if channel == nil {
-			return nil, ErrChannelNotFound
-		}
-
-		if channel.IsPrivate {
-			return nil, ErrUnauthorized
-		}
-
-		// Check workspace membership
-		wsMember, err := s.workspaceRepo.GetMember(channel.WorkspaceID, userID)
-		if err != nil {
-			return nil, err
-		}
-		if wsMember == nil {
-			return nil, ErrUnauthorized
-		}
+	if err := s.checkPostingPolicy(userID, channel, parentID != nil, alsoSendToChannel); err != nil {
+		return nil, err
 	}
 
 	// If it's a reply, verify parent exists and belongs to the same channel
+	var parent *models.Message
 	if parentID != nil {
-		parent, err := s.messageRepo.FindByID(*parentID)
+		parent, err = s.messageRepo.FindByID(*parentID)
 		if err != nil {
 			return nil, err
 		}
@@ -92,18 +123,38 @@ func (s *messageService) SendChannelMessage(userID, channelID uuid.UUID, content
 		}
 	}
 
+	mentions, err := s.detectMentions(userID, content, &channelID, nil)
+	if err != nil {
+		return nil, err
+	}
+
 	message := &models.Message{
 		ID:              uuid.New(),
 		Content:         content,
 		SenderID:        &userID,
 		ChannelID:       &channelID,
 		ParentMessageID: parentID,
+		// Only thread replies can be echoed to the channel
+		AlsoSentToChannel: parentID != nil && alsoSendToChannel,
+		Urgent:            urgent,
+	}
+
+	if urgent {
+		if err := s.checkUrgentOverride(userID, message); err != nil {
+			return nil, err
+		}
 	}
 
 	if err := s.messageRepo.Create(message); err != nil {
 		return nil, err
 	}
 
+	if parent != nil {
+		s.followThread(parent, userID)
+	}
+	s.storeMentions(message, mentions)
+	s.notifyNewMessage(message, mentions)
+
 	// Link attachments
 	for _, attachmentID := range attachmentIDs {
 		if err := s.attachmentRepo.LinkToMessage(attachmentID, message.ID); err != nil {
@@ -126,37 +177,119 @@ func (s *messageService) SendChannelMessage(userID, channelID uuid.UUID, content
 
 func (s *messageService) GetChannelMessages(userID uuid.UUID, channelID uuid.UUID, limit, offset int) ([]*models.Message, error) {
 	// Verify access
+	if err := s.verifyChannelAccess(userID, channelID); err != nil {
+		return nil, err
+	}
+
+	return s.messageRepo.ListByChannelID(channelID, limit, offset)
+}
+
+// verifyChannelAccess allows channel members, and workspace members for public channels.
+func (s *messageService) verifyChannelAccess(userID, channelID uuid.UUID) error {
+	channel, err := s.channelRepo.FindByID(channelID)
+	if err != nil {
+		return err
+	}
+	if channel == nil {
+		return ErrChannelNotFound
+	}
+
+	// Nobody reads or posts in a deleted workspace, members included
+	ws, err := s.workspaceRepo.FindByID(channel.WorkspaceID)
+	if err != nil {
+		return err
+	}
+	if ws == nil || ws.DeletedAt != nil {
+		return ErrUnauthorized
+	}
+
 	isMember, err := s.channelRepo.IsMember(channelID, userID)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if isMember {
+		return nil
 	}
 
-	if !isMember {
-		channel, err := s.channelRepo.FindByID(channelID)
+	// If not direct member, check if it's a public channel and user is in workspace
+	if channel.IsPrivate {
+		return ErrUnauthorized
+	}
+
+	wsMember, err := s.workspaceRepo.GetMember(channel.WorkspaceID, userID)
+	if err != nil {
+		return err
+	}
+	if !canReadPublicChannel(wsMember) {
+		return ErrUnauthorized
+	}
+	return nil
+}
+
+// VerifyMessageAccess checks that the user can see the channel or DM the message belongs to.
+func (s *messageService) VerifyMessageAccess(userID uuid.UUID, message *models.Message) error {
+	if message.ChannelID != nil {
+		return s.verifyChannelAccess(userID, *message.ChannelID)
+	}
+
+	if message.DMID != nil {
+		isParticipant, err := s.dmRepo.IsParticipant(*message.DMID, userID)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		if channel == nil {
-			return nil, ErrChannelNotFound
+		if !isParticipant {
+			return ErrUnauthorized
 		}
+		return nil
+	}
 
-		if channel.IsPrivate {
-			return nil, ErrUnauthorized
+	return ErrUnauthorized
+}
+
+func (s *messageService) GetMessage(userID uuid.UUID, messageID uuid.UUID) (*models.Message, error) {
+	message, err := s.messageRepo.FindByID(messageID)
+	if err != nil {
+		return nil, err
+	}
+	if message == nil || message.DeletedAt != nil {
+		return nil, ErrMessageNotFound
+	}
+
+	if err := s.VerifyMessageAccess(userID, message); err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
+
+// MessageWorkspaceID resolves the workspace a channel or DM message belongs to.
+func (s *messageService) MessageWorkspaceID(message *models.Message) (uuid.UUID, error) {
+	if message.ChannelID != nil {
+		channel, err := s.channelRepo.FindByID(*message.ChannelID)
+		if err != nil {
+			return uuid.Nil, err
 		}
+		if channel == nil {
+			return uuid.Nil, ErrChannelNotFound
+		}
+		return channel.WorkspaceID, nil
+	}
 
-		wsMember, err := s.workspaceRepo.GetMember(channel.WorkspaceID, userID)
+	if message.DMID != nil {
+		dm, err := s.dmRepo.GetByID(*message.DMID)
 		if err != nil {
-			return nil, err
+			return uuid.Nil, err
 		}
-		if wsMember == nil {
-			return nil, ErrUnauthorized
+		if dm == nil {
+			return uuid.Nil, ErrDMNotFound
 		}
+		return dm.WorkspaceID, nil
 	}
 
-	return s.messageRepo.ListByChannelID(channelID, limit, offset)
+	return uuid.Nil, ErrMessageNotFound
 }
 
-func (s *messageService) SendDMMessage(userID, dmID uuid.UUID, content string, parentID *uuid.UUID, attachmentIDs []uuid.UUID) (*models.Message, error) {
+func (s *messageService) SendDMMessage(userID, dmID uuid.UUID, content string, parentID *uuid.UUID, attachmentIDs []uuid.UUID, alsoSendToChannel bool, urgent bool) (*models.Message, error) {
 	// 1. Verify user is participant in DM
 	isParticipant, err := s.dmRepo.IsParticipant(dmID, userID)
 	if err != nil {
@@ -167,8 +300,9 @@ func (s *messageService) SendDMMessage(userID, dmID uuid.UUID, content string, p
 	}
 
 	// Verify parent message
+	var parent *models.Message
 	if parentID != nil {
-		parent, err := s.messageRepo.FindByID(*parentID)
+		parent, err = s.messageRepo.FindByID(*parentID)
 		if err != nil {
 			return nil, err
 		}
@@ -177,18 +311,38 @@ func (s *messageService) SendDMMessage(userID, dmID uuid.UUID, content string, p
 		}
 	}
 
+	mentions, err := s.detectMentions(userID, content, nil, &dmID)
+	if err != nil {
+		return nil, err
+	}
+
 	message := &models.Message{
 		ID:              uuid.New(),
 		Content:         content,
 		SenderID:        &userID,
 		DMID:            &dmID,
 		ParentMessageID: parentID,
+		// Only thread replies can be echoed to the conversation
+		AlsoSentToChannel: parentID != nil && alsoSendToChannel,
+		Urgent:            urgent,
+	}
+
+	if urgent {
+		if err := s.checkUrgentOverride(userID, message); err != nil {
+			return nil, err
+		}
 	}
 
 	if err := s.messageRepo.Create(message); err != nil {
 		return nil, err
 	}
 
+	if parent != nil {
+		s.followThread(parent, userID)
+	}
+	s.storeMentions(message, mentions)
+	s.notifyNewMessage(message, mentions)
+
 	// Link attachments
 	for _, attachmentID := range attachmentIDs {
 		s.attachmentRepo.LinkToMessage(attachmentID, message.ID)
@@ -224,19 +378,34 @@ func (s *messageService) GetThreads(userID uuid.UUID, parentID uuid.UUID) ([]*mo
 	if err != nil {
 		return nil, err
 	}
-	if parent == nil || parent.ChannelID == nil {
+	if parent == nil {
 		return nil, ErrMessageNotFound
 	}
 
-	// Verify access to channel
-	_, err = s.GetChannelMessages(userID, *parent.ChannelID, 1, 0)
-	if err != nil {
+	// Verify access to the channel or DM
+	if err := s.VerifyMessageAccess(userID, parent); err != nil {
 		return nil, err
 	}
 
 	return s.messageRepo.ListReplies(parentID)
 }
 
+// followThread subscribes the replier and the thread author to a thread.
+func (s *messageService) followThread(parent *models.Message, replierID uuid.UUID) {
+	if err := s.threadRepo.Follow(parent.ID, replierID); err != nil {
+		log.Printf("error following thread %s: %v", parent.ID, err)
+	}
+	if err := s.threadRepo.MarkRead(parent.ID, replierID); err != nil {
+		log.Printf("error marking thread %s as read: %v", parent.ID, err)
+	}
+
+	if parent.SenderID != nil && *parent.SenderID != replierID {
+		if err := s.threadRepo.AutoFollow(parent.ID, *parent.SenderID); err != nil {
+			log.Printf("error following thread %s: %v", parent.ID, err)
+		}
+	}
+}
+
 func (s *messageService) UpdateMessage(userID uuid.UUID, messageID uuid.UUID, req *dto.UpdateMessageRequest) (*models.Message, error) {
 	message, err := s.messageRepo.FindByID(messageID)
 	if err != nil {
@@ -250,12 +419,28 @@ func (s *messageService) UpdateMessage(userID uuid.UUID, messageID uuid.UUID, re
 	if message.SenderID == nil || *message.SenderID != userID {
 		return nil, ErrUnauthorized
 	}
+	if message.Type == MessageTypeSystem {
+		return nil, ErrSystemMessage
+	}
+	if message.ChannelID != nil {
+		if err := ensureChannelWritable(s.channelRepo, *message.ChannelID); err != nil {
+			return nil, err
+		}
+	}
+
+	mentions, err := s.detectMentions(userID, req.Content, message.ChannelID, message.DMID)
+	if err != nil {
+		return nil, err
+	}
 
 	message.Content = req.Content
 	if err := s.messageRepo.Update(message); err != nil {
 		return nil, err
 	}
 
+	// Only users who were not mentioned before are notified
+	s.storeMentions(message, mentions)
+
 	return message, nil
 }
 
@@ -269,57 +454,368 @@ func (s *messageService) DeleteMessage(userID uuid.UUID, messageID uuid.UUID) er
 	}
 
 	// Check permissions
-	// Only sender can delete, OR workspace owner/admin (for management)
+	// Only sender can delete, OR someone allowed to delete any message (for management).
+	// System messages record channel changes, so only the latter can remove them
+	isSystem := message.Type == MessageTypeSystem
 	isOwner := false
-	if message.SenderID != nil && *message.SenderID == userID {
+	if !isSystem && message.SenderID != nil && *message.SenderID == userID {
 		isOwner = true
 	} else if message.ChannelID != nil {
 		channel, _ := s.channelRepo.FindByID(*message.ChannelID)
 		if channel != nil {
-			wsMember, _ := s.workspaceRepo.GetMember(channel.WorkspaceID, userID)
-			if wsMember != nil && (wsMember.Role == "owner" || wsMember.Role == "admin") {
-				isOwner = true
-			}
+			isOwner, _ = s.authz.Can(channel.WorkspaceID, userID, authz.MessageDeleteAny)
 		}
 	}
 
 	if !isOwner {
+		if isSystem {
+			return ErrSystemMessage
+		}
 		return ErrUnauthorized
 	}
+	if message.ChannelID != nil {
+		if err := ensureChannelWritable(s.channelRepo, *message.ChannelID); err != nil {
+			return err
+		}
+	}
 
 	return s.messageRepo.SoftDelete(messageID)
 }
 
-func (s *messageService) detectMentions(workspaceID uuid.UUID, content string) ([]uuid.UUID, error) {
-	// Simple regex to find @username
-	re := regexp.MustCompile(`@(\w+)`)
-	matches := re.FindAllStringSubmatch(content, -1)
+// parseMentions splits the @-mentions in content into usernames and
+// broadcast keywords (@channel, @here, @everyone).
+func parseMentions(content string) ([]string, map[string]bool) {
+	var usernames []string
+	broadcasts := make(map[string]bool)
+	seen := make(map[string]bool)
+
+	for _, match := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		name := strings.ToLower(match[1])
+		switch name {
+		case MentionTypeChannel, MentionTypeHere, MentionTypeEveryone:
+			broadcasts[name] = true
+			continue
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		usernames = append(usernames, name)
+	}
+
+	return usernames, broadcasts
+}
 
-	if len(matches) == 0 {
+// detectMentions resolves the mentions in content to the users who can see the
+// conversation. Usernames are resolved with one query, and broadcast mentions
+// are checked against the workspace's mention policy.
+func (s *messageService) detectMentions(senderID uuid.UUID, content string, channelID, dmID *uuid.UUID) ([]*models.MessageMention, error) {
+	usernames, broadcasts := parseMentions(content)
+	if len(usernames) == 0 && len(broadcasts) == 0 {
 		return nil, nil
 	}
 
-	var mentionedIDs []uuid.UUID
-	seen := make(map[string]bool)
+	// 1. Work out the workspace and who can see the conversation
+	var workspaceID uuid.UUID
+	var audience []uuid.UUID
+	restricted := true
+	if channelID != nil {
+		channel, err := s.channelRepo.FindByID(*channelID)
+		if err != nil {
+			return nil, err
+		}
+		if channel == nil {
+			return nil, ErrChannelNotFound
+		}
+		workspaceID = channel.WorkspaceID
+		restricted = channel.IsPrivate
+
+		members, err := s.channelRepo.ListMembers(*channelID)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range members {
+			audience = append(audience, m.UserID)
+		}
+	} else if dmID != nil {
+		dm, err := s.dmRepo.GetByID(*dmID)
+		if err != nil {
+			return nil, err
+		}
+		if dm == nil {
+			return nil, ErrDMNotFound
+		}
+		workspaceID = dm.WorkspaceID
+
+		audience, err = s.dmRepo.ListParticipantIDs(*dmID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	canSee := make(map[uuid.UUID]bool)
+	for _, id := range audience {
+		canSee[id] = true
+	}
+
+	var mentions []*models.MessageMention
+	added := map[uuid.UUID]bool{senderID: true}
+	add := func(userID uuid.UUID, mentionType string) {
+		if added[userID] {
+			return
+		}
+		added[userID] = true
+		mentions = append(mentions, &models.MessageMention{UserID: userID, MentionType: mentionType})
+	}
+
+	// 2. Direct @username mentions, resolved in one batch
+	if len(usernames) > 0 {
+		users, err := s.userRepo.FindWorkspaceMembersByUsernames(workspaceID, usernames)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range users {
+			if !canSee[u.ID] {
+				if restricted {
+					continue
+				}
+				// Guests only see public channels they are in
+				member, err := s.workspaceRepo.GetMember(workspaceID, u.ID)
+				if err != nil {
+					return nil, err
+				}
+				if !canReadPublicChannel(member) {
+					continue
+				}
+			}
+			add(u.ID, MentionTypeUser)
+		}
+	}
+
+	if len(broadcasts) == 0 {
+		return mentions, nil
+	}
+
+	// 3. Broadcast mentions need permission
+	if err := s.checkBroadcastMention(workspaceID, senderID, broadcasts); err != nil {
+		return nil, err
+	}
+
+	if broadcasts[MentionTypeHere] {
+		users, err := s.userRepo.FindByIDs(audience)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range users {
+			if u.Status == "online" {
+				add(u.ID, MentionTypeHere)
+			}
+		}
+	}
+	if broadcasts[MentionTypeChannel] {
+		for _, id := range audience {
+			add(id, MentionTypeChannel)
+		}
+	}
+	if broadcasts[MentionTypeEveryone] {
+		if restricted {
+			// Private conversations never reach past their members
+			for _, id := range audience {
+				add(id, MentionTypeEveryone)
+			}
+		} else {
+			members, err := s.workspaceRepo.ListMembers(workspaceID)
+			if err != nil {
+				return nil, err
+			}
+			for _, m := range members {
+				if !canReadPublicChannel(m) && !canSee[m.UserID] {
+					continue
+				}
+				add(m.UserID, MentionTypeEveryone)
+			}
+		}
+	}
+
+	return mentions, nil
+}
 
-	for _, match := range matches {
-		username := match[1]
-		if seen[username] {
+// checkBroadcastMention enforces who may use @channel, @here and @everyone.
+// @everyone needs its own permission; the others follow the workspace setting.
+func (s *messageService) checkBroadcastMention(workspaceID, senderID uuid.UUID, broadcasts map[string]bool) error {
+	grant, err := s.authz.Grant(workspaceID, senderID)
+	if err != nil {
+		return err
+	}
+	if grant == nil {
+		return ErrUnauthorized
+	}
+	if grant.Has(authz.MessageMentionEveryone) {
+		return nil
+	}
+
+	if broadcasts[MentionTypeEveryone] {
+		return ErrBroadcastMentionForbidden
+	}
+
+	settings, err := s.workspaceRepo.GetSettings(workspaceID)
+	if err != nil {
+		return err
+	}
+	if settings.BroadcastMentionPolicy == "admins" {
+		return ErrBroadcastMentionForbidden
+	}
+	return nil
+}
+
+// storeMentions saves the message's mentions and sends mention.new to each
+// newly mentioned user who isn't in DND, unless the message is urgent.
+func (s *messageService) storeMentions(message *models.Message, mentions []*models.MessageMention) {
+	added, err := s.mentionRepo.ReplaceForMessage(message.ID, mentions)
+	if err != nil {
+		log.Printf("error storing mentions for message %s: %v", message.ID, err)
+		return
+	}
+
+	if len(added) == 0 {
+		return
+	}
+
+	workspaceID, err := s.MessageWorkspaceID(message)
+	if err != nil {
+		log.Printf("error resolving workspace of message %s: %v", message.ID, err)
+		return
+	}
+
+	for _, mention := range added {
+		s.notificationService.Notify(&models.Notification{
+			UserID:      mention.UserID,
+			WorkspaceID: &workspaceID,
+			Type:        NotificationTypeMention,
+			ActorID:     message.SenderID,
+			MessageID:   &message.ID,
+			ChannelID:   message.ChannelID,
+			DMID:        message.DMID,
+			Data:        notificationData(map[string]string{"mention_type": mention.MentionType}),
+			Urgent:      message.Urgent,
+		})
+
+		if !interruptible(s.dndService, mention.UserID, message.Urgent) {
 			continue
 		}
-		seen[username] = true
 
-		user, err := s.userRepo.FindByUsername(username)
-		if err != nil || user == nil {
-			continue // User doesn't exist
+		payload, err := json.Marshal(websocket.MentionPayload{
+			MentionType: mention.MentionType,
+			Message:     message,
+		})
+		if err != nil {
+			log.Printf("error marshaling mention payload: %v", err)
+			continue
 		}
 
-		// Verify workspace membership
-		member, _ := s.workspaceRepo.GetMember(workspaceID, user.ID)
-		if member != nil {
-			mentionedIDs = append(mentionedIDs, user.ID)
+		userID := mention.UserID
+		s.hub.Broadcast(&websocket.WSMessage{
+			Type:    websocket.EventMentionNew,
+			Payload: payload,
+			UserID:  &userID,
+		})
+	}
+}
+
+// checkPostingPolicy applies the channel's reply policy to thread replies and
+// its posting policy to top-level messages. A reply also sent to the channel
+// has to pass both.
+func (s *messageService) checkPostingPolicy(userID uuid.UUID, channel *models.Channel, isReply, alsoSendToChannel bool) error {
+	checkReply := isReply && channel.ReplyPolicy != PostingPolicyEveryone
+	checkPosting := (!isReply || alsoSendToChannel) && channel.PostingPolicy != PostingPolicyEveryone
+	if !checkReply && !checkPosting {
+		return nil
+	}
+
+	grant, err := s.authz.Grant(channel.WorkspaceID, userID)
+	if err != nil {
+		return err
+	}
+	if grant == nil {
+		return ErrUnauthorized
+	}
+
+	if checkReply && !canPost(channel, channel.ReplyPolicy, userID, grant) {
+		return ErrReplyRestricted
+	}
+	if checkPosting && !canPost(channel, channel.PostingPolicy, userID, grant) {
+		return ErrPostingRestricted
+	}
+	return nil
+}
+
+// checkUrgentOverride enforces the workspace policy on who may send urgent
+// messages, whose notifications break through DND.
+func (s *messageService) checkUrgentOverride(userID uuid.UUID, message *models.Message) error {
+	workspaceID, err := s.MessageWorkspaceID(message)
+	if err != nil {
+		return err
+	}
+
+	settings, err := s.workspaceRepo.GetSettings(workspaceID)
+	if err != nil {
+		return err
+	}
+
+	switch settings.UrgentOverridePolicy {
+	case "everyone":
+		return nil
+	case "admins":
+		allowed, err := s.authz.Can(workspaceID, userID, authz.MessageSendUrgent)
+		if err != nil {
+			return err
+		}
+		if allowed {
+			return nil
+		}
+	}
+
+	return ErrUrgentOverrideForbidden
+}
+
+// notifyNewMessage fans a new message out to the activity feed. Mentioned
+// users already got a mention item; thread followers get a thread_reply item;
+// everyone else is left to their notification preferences.
+func (s *messageService) notifyNewMessage(message *models.Message, mentions []*models.MessageMention) {
+	notified := make(map[uuid.UUID]bool, len(mentions))
+	for _, mention := range mentions {
+		notified[mention.UserID] = true
+	}
+
+	workspaceID, err := s.MessageWorkspaceID(message)
+	if err != nil {
+		log.Printf("error resolving workspace of message %s: %v", message.ID, err)
+		return
+	}
+
+	if message.ParentMessageID != nil {
+		followerIDs, err := s.threadRepo.ListFollowerIDs(*message.ParentMessageID)
+		if err != nil {
+			log.Printf("error listing followers of thread %s: %v", *message.ParentMessageID, err)
+		}
+		for _, followerID := range followerIDs {
+			if notified[followerID] {
+				continue
+			}
+			notified[followerID] = true
+			s.notificationService.Notify(&models.Notification{
+				UserID:      followerID,
+				WorkspaceID: &workspaceID,
+				Type:        NotificationTypeThreadReply,
+				ActorID:     message.SenderID,
+				MessageID:   &message.ID,
+				ChannelID:   message.ChannelID,
+				DMID:        message.DMID,
+				Data:        notificationData(map[string]string{"thread_id": message.ParentMessageID.String()}),
+				Urgent:      message.Urgent,
+			})
 		}
 	}
 
-	return mentionedIDs, nil
+	s.notificationService.NotifyMessage(message, workspaceID, notified)
 }
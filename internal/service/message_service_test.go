@@ -0,0 +1,270 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/authz"
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+	"github.com/DoDuy2004/slack-clone-backend/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockChannelRepository is a mock implementation of ChannelRepository
+type MockChannelRepository struct {
+	mock.Mock
+}
+
+func (m *MockChannelRepository) Create(channel *models.Channel) error {
+	args := m.Called(channel)
+	return args.Error(0)
+}
+
+func (m *MockChannelRepository) FindByID(id uuid.UUID) (*models.Channel, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Channel), args.Error(1)
+}
+
+func (m *MockChannelRepository) ListByWorkspaceID(workspaceID uuid.UUID, userID uuid.UUID, includeArchived bool) ([]*models.Channel, error) {
+	args := m.Called(workspaceID, userID, includeArchived)
+	return args.Get(0).([]*models.Channel), args.Error(1)
+}
+
+func (m *MockChannelRepository) Browse(workspaceID uuid.UUID, userID uuid.UUID, filter *repository.ChannelBrowseFilter) ([]*models.ChannelDirectoryEntry, error) {
+	args := m.Called(workspaceID, userID, filter)
+	return args.Get(0).([]*models.ChannelDirectoryEntry), args.Error(1)
+}
+
+func (m *MockChannelRepository) Update(channel *models.Channel) error {
+	args := m.Called(channel)
+	return args.Error(0)
+}
+
+func (m *MockChannelRepository) Delete(id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockChannelRepository) Archive(id uuid.UUID, archivedBy uuid.UUID) error {
+	args := m.Called(id, archivedBy)
+	return args.Error(0)
+}
+
+func (m *MockChannelRepository) Unarchive(id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockChannelRepository) SetPostingPolicy(channelID uuid.UUID, postingPolicy, replyPolicy string, posterIDs []uuid.UUID) error {
+	args := m.Called(channelID, postingPolicy, replyPolicy, posterIDs)
+	return args.Error(0)
+}
+
+func (m *MockChannelRepository) AddChange(change *models.ChannelChange) error {
+	args := m.Called(change)
+	return args.Error(0)
+}
+
+func (m *MockChannelRepository) ListChanges(channelID uuid.UUID, limit, offset int) ([]*models.ChannelChange, error) {
+	args := m.Called(channelID, limit, offset)
+	return args.Get(0).([]*models.ChannelChange), args.Error(1)
+}
+
+func (m *MockChannelRepository) AddMember(channelID, userID uuid.UUID) error {
+	args := m.Called(channelID, userID)
+	return args.Error(0)
+}
+
+func (m *MockChannelRepository) RemoveMember(channelID, userID uuid.UUID) error {
+	args := m.Called(channelID, userID)
+	return args.Error(0)
+}
+
+func (m *MockChannelRepository) PruneNonMembers(channelID uuid.UUID) error {
+	args := m.Called(channelID)
+	return args.Error(0)
+}
+
+func (m *MockChannelRepository) IsMember(channelID, userID uuid.UUID) (bool, error) {
+	args := m.Called(channelID, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockChannelRepository) ListMembers(channelID uuid.UUID) ([]*models.ChannelMember, error) {
+	args := m.Called(channelID)
+	return args.Get(0).([]*models.ChannelMember), args.Error(1)
+}
+
+func (m *MockChannelRepository) ListWorkspaceMemberships(workspaceID uuid.UUID) ([]*models.ChannelMember, error) {
+	args := m.Called(workspaceID)
+	return args.Get(0).([]*models.ChannelMember), args.Error(1)
+}
+
+func (m *MockChannelRepository) UpdateLastRead(channelID, userID uuid.UUID) error {
+	args := m.Called(channelID, userID)
+	return args.Error(0)
+}
+
+func (m *MockChannelRepository) CountJoined(workspaceID, userID uuid.UUID) (int, error) {
+	args := m.Called(workspaceID, userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockChannelRepository) SharesChannel(workspaceID, userID, otherID uuid.UUID) (bool, error) {
+	args := m.Called(workspaceID, userID, otherID)
+	return args.Bool(0), args.Error(1)
+}
+
+// MockMessageRepository is a mock implementation of MessageRepository
+type MockMessageRepository struct {
+	mock.Mock
+}
+
+func (m *MockMessageRepository) Create(message *models.Message) error {
+	args := m.Called(message)
+	return args.Error(0)
+}
+
+func (m *MockMessageRepository) FindByID(id uuid.UUID) (*models.Message, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Message), args.Error(1)
+}
+
+func (m *MockMessageRepository) ListByChannelID(channelID uuid.UUID, limit, offset int) ([]*models.Message, error) {
+	args := m.Called(channelID, limit, offset)
+	return args.Get(0).([]*models.Message), args.Error(1)
+}
+
+func (m *MockMessageRepository) ListByDMID(dmID uuid.UUID, limit, offset int) ([]*models.Message, error) {
+	args := m.Called(dmID, limit, offset)
+	return args.Get(0).([]*models.Message), args.Error(1)
+}
+
+func (m *MockMessageRepository) ListReplies(parentID uuid.UUID) ([]*models.Message, error) {
+	args := m.Called(parentID)
+	return args.Get(0).([]*models.Message), args.Error(1)
+}
+
+func (m *MockMessageRepository) Update(message *models.Message) error {
+	args := m.Called(message)
+	return args.Error(0)
+}
+
+func (m *MockMessageRepository) SoftDelete(id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockMessageRepository) Search(workspaceID, userID uuid.UUID, query string, limit, offset int) ([]*models.Message, error) {
+	args := m.Called(workspaceID, userID, query, limit, offset)
+	return args.Get(0).([]*models.Message), args.Error(1)
+}
+
+func TestParseMentions(t *testing.T) {
+	usernames, broadcasts := parseMentions("hey @Alice and @bob, cc @alice @here (email me at x@example.com)")
+
+	assert.Equal(t, []string{"alice", "bob"}, usernames)
+	assert.True(t, broadcasts[MentionTypeHere])
+	assert.False(t, broadcasts[MentionTypeChannel])
+}
+
+func TestParseMentions_None(t *testing.T) {
+	usernames, broadcasts := parseMentions("no mentions here")
+
+	assert.Empty(t, usernames)
+	assert.Empty(t, broadcasts)
+}
+
+func TestSendMessageInDeletedWorkspace(t *testing.T) {
+	mockChannels := new(MockChannelRepository)
+	mockWS := new(MockWorkspaceRepository)
+	svc := NewMessageService(nil, mockChannels, mockWS, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	userID, wsID, channelID := uuid.New(), uuid.New(), uuid.New()
+	deletedAt := time.Now().Add(-time.Hour)
+	mockChannels.On("FindByID", channelID).Return(&models.Channel{ID: channelID, WorkspaceID: wsID}, nil)
+	mockChannels.On("IsMember", channelID, userID).Return(true, nil)
+	mockWS.On("FindByID", wsID).Return(&models.Workspace{ID: wsID, DeletedAt: &deletedAt}, nil)
+
+	// Still a channel member, but the workspace is gone
+	_, err := svc.SendChannelMessage(userID, channelID, "hello", nil, nil, false, false)
+	assert.Equal(t, ErrUnauthorized, err)
+	mockChannels.AssertNotCalled(t, "IsMember", mock.Anything, mock.Anything)
+}
+
+func TestDetectMentionsSkipsGuestsOutsideChannel(t *testing.T) {
+	mockChannels := new(MockChannelRepository)
+	mockWS := new(MockWorkspaceRepository)
+	mockUsers := new(MockUserRepository)
+	svc := NewMessageService(nil, mockChannels, mockWS, nil, nil, mockUsers, nil, nil, authz.New(mockWS, nil), nil, nil, nil).(*messageService)
+
+	wsID, channelID := uuid.New(), uuid.New()
+	sender := &models.WorkspaceMember{WorkspaceID: wsID, UserID: uuid.New(), Role: authz.RoleAdmin}
+	member := &models.WorkspaceMember{WorkspaceID: wsID, UserID: uuid.New(), Role: authz.RoleMember}
+	guest := &models.WorkspaceMember{WorkspaceID: wsID, UserID: uuid.New(), Role: authz.RoleMultiChannelGuest}
+	channelGuest := &models.WorkspaceMember{WorkspaceID: wsID, UserID: uuid.New(), Role: authz.RoleSingleChannelGuest}
+	mockChannels.On("FindByID", channelID).Return(&models.Channel{ID: channelID, WorkspaceID: wsID}, nil)
+	mockChannels.On("ListMembers", channelID).Return([]*models.ChannelMember{
+		{ChannelID: channelID, UserID: sender.UserID},
+		{ChannelID: channelID, UserID: channelGuest.UserID},
+	}, nil)
+	for _, m := range []*models.WorkspaceMember{sender, member, guest, channelGuest} {
+		mockWS.On("GetMember", wsID, m.UserID).Return(m, nil)
+	}
+	mockWS.On("ListMembers", wsID).Return([]*models.WorkspaceMember{sender, member, guest, channelGuest}, nil)
+	mockUsers.On("FindWorkspaceMembersByUsernames", wsID, mock.Anything).Return([]*models.User{
+		{ID: member.UserID}, {ID: guest.UserID}, {ID: channelGuest.UserID},
+	}, nil)
+
+	mentionedIDs := func(mentions []*models.MessageMention) []uuid.UUID {
+		var ids []uuid.UUID
+		for _, m := range mentions {
+			ids = append(ids, m.UserID)
+		}
+		return ids
+	}
+
+	// A guest outside the public channel can't be pulled in by name...
+	mentions, err := svc.detectMentions(sender.UserID, "@member @guest @channelguest", &channelID, nil)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []uuid.UUID{member.UserID, channelGuest.UserID}, mentionedIDs(mentions))
+
+	// ...or by @everyone
+	mentions, err = svc.detectMentions(sender.UserID, "@everyone", &channelID, nil)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []uuid.UUID{member.UserID, channelGuest.UserID}, mentionedIDs(mentions))
+}
+
+func TestDeleteSystemMessage(t *testing.T) {
+	mockChannels := new(MockChannelRepository)
+	mockWS := new(MockWorkspaceRepository)
+	mockMessages := new(MockMessageRepository)
+	svc := NewMessageService(mockMessages, mockChannels, mockWS, nil, nil, nil, nil, nil, authz.New(mockWS, nil), nil, nil, nil)
+
+	wsID := uuid.New()
+	channel := &models.Channel{ID: uuid.New(), WorkspaceID: wsID}
+	member := &models.WorkspaceMember{WorkspaceID: wsID, UserID: uuid.New(), Role: authz.RoleMember}
+	admin := &models.WorkspaceMember{WorkspaceID: wsID, UserID: uuid.New(), Role: authz.RoleAdmin}
+	system := &models.Message{ID: uuid.New(), SenderID: &member.UserID, ChannelID: &channel.ID, Type: MessageTypeSystem}
+	mockChannels.On("FindByID", channel.ID).Return(channel, nil)
+	mockWS.On("GetMember", wsID, member.UserID).Return(member, nil)
+	mockWS.On("GetMember", wsID, admin.UserID).Return(admin, nil)
+	mockMessages.On("FindByID", system.ID).Return(system, nil)
+	mockMessages.On("SoftDelete", system.ID).Return(nil)
+
+	// Renaming a channel doesn't let the renamer erase the record of it
+	assert.Equal(t, ErrSystemMessage, svc.DeleteMessage(member.UserID, system.ID))
+	mockMessages.AssertNotCalled(t, "SoftDelete", mock.Anything)
+
+	// but moderators can still remove it
+	assert.NoError(t, svc.DeleteMessage(admin.UserID, system.ID))
+	mockMessages.AssertNumberOfCalls(t, "SoftDelete", 1)
+}
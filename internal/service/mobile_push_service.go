@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+	"github.com/DoDuy2004/slack-clone-backend/internal/models/dto"
+	"github.com/DoDuy2004/slack-clone-backend/internal/repository"
+	"github.com/DoDuy2004/slack-clone-backend/internal/websocket"
+	"github.com/DoDuy2004/slack-clone-backend/pkg/push"
+	"github.com/google/uuid"
+)
+
+var ErrDeviceNotFound = errors.New("device not found")
+
+type MobilePushService interface {
+	RegisterDevice(userID uuid.UUID, req *dto.RegisterDeviceRequest) (*models.DeviceToken, error)
+	UnregisterDevice(userID uuid.UUID, token string) error
+	ListDevices(userID uuid.UUID) ([]*models.DeviceToken, error)
+	// HandleEvent is a Hub listener: new notifications become pushes for
+	// offline users, and read events refresh badge counts on every device.
+	HandleEvent(message *websocket.WSMessage)
+	// ProcessQueue sends due pushes. It returns the number delivered.
+	ProcessQueue() (int, error)
+	RunQueue(interval time.Duration)
+}
+
+type mobilePushService struct {
+	deviceRepo repository.DeviceRepository
+	dispatcher *push.Dispatcher
+	presence   PresenceChecker
+}
+
+func NewMobilePushService(deviceRepo repository.DeviceRepository, queue push.Queue, providers []push.Provider, presence PresenceChecker) MobilePushService {
+	s := &mobilePushService{
+		deviceRepo: deviceRepo,
+		presence:   presence,
+	}
+	s.dispatcher = push.NewDispatcher(queue, providers, s.removeInvalidToken)
+	return s
+}
+
+func (s *mobilePushService) RegisterDevice(userID uuid.UUID, req *dto.RegisterDeviceRequest) (*models.DeviceToken, error) {
+	device := &models.DeviceToken{
+		UserID:     userID,
+		Platform:   req.Platform,
+		Token:      req.Token,
+		AppVersion: req.AppVersion,
+	}
+	if err := s.deviceRepo.Upsert(device); err != nil {
+		return nil, err
+	}
+	return device, nil
+}
+
+func (s *mobilePushService) UnregisterDevice(userID uuid.UUID, token string) error {
+	deleted, err := s.deviceRepo.Delete(userID, token)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrDeviceNotFound
+	}
+	return nil
+}
+
+func (s *mobilePushService) ListDevices(userID uuid.UUID) ([]*models.DeviceToken, error) {
+	devices, err := s.deviceRepo.ListByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if devices == nil {
+		devices = []*models.DeviceToken{}
+	}
+	return devices, nil
+}
+
+func (s *mobilePushService) HandleEvent(message *websocket.WSMessage) {
+	if message.UserID == nil {
+		return
+	}
+	if message.Type != websocket.EventNotificationNew && message.Type != websocket.EventNotificationRead {
+		return
+	}
+	// Hub listeners must not block the broadcaster
+	go s.handleEvent(message)
+}
+
+func (s *mobilePushService) handleEvent(message *websocket.WSMessage) {
+	userID := *message.UserID
+
+	switch message.Type {
+	case websocket.EventNotificationNew:
+		var payload struct {
+			Notification models.Notification `json:"notification"`
+			UnreadCount  int                 `json:"unread_count"`
+		}
+		if err := json.Unmarshal(message.Payload, &payload); err != nil {
+			log.Printf("error decoding notification event: %v", err)
+			return
+		}
+		if !pushable(&payload.Notification) || s.presence.IsUserConnected(userID) {
+			return
+		}
+		s.enqueue(userID, mobileNotification(&payload.Notification, payload.UnreadCount))
+
+	case websocket.EventNotificationRead:
+		var payload websocket.NotificationReadPayload
+		if err := json.Unmarshal(message.Payload, &payload); err != nil {
+			log.Printf("error decoding notification event: %v", err)
+			return
+		}
+		s.enqueue(userID, &push.Notification{Badge: &payload.UnreadCount})
+	}
+}
+
+// enqueue queues n for each of the user's devices on a configured platform.
+func (s *mobilePushService) enqueue(userID uuid.UUID, n *push.Notification) {
+	devices, err := s.deviceRepo.ListByUser(userID)
+	if err != nil {
+		log.Printf("error listing devices for user %s: %v", userID, err)
+		return
+	}
+
+	for _, device := range devices {
+		if !s.dispatcher.Supports(device.Platform) {
+			continue
+		}
+		if err := s.dispatcher.Enqueue(context.Background(), device.Platform, device.Token, n); err != nil {
+			log.Printf("error queueing push for device %s: %v", device.ID, err)
+		}
+	}
+}
+
+func (s *mobilePushService) removeInvalidToken(platform, token string) {
+	if err := s.deviceRepo.DeleteByToken(token); err != nil {
+		log.Printf("error removing invalid %s device token: %v", platform, err)
+	}
+}
+
+func (s *mobilePushService) ProcessQueue() (int, error) {
+	return s.dispatcher.Process(context.Background())
+}
+
+// RunQueue polls for due mobile pushes until the process exits.
+func (s *mobilePushService) RunQueue(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := s.ProcessQueue(); err != nil {
+			log.Printf("error processing mobile push queue: %v", err)
+		}
+	}
+}
+
+// mobileNotification builds the alert for a feed item. Notifications for the
+// same conversation share a thread ID so devices group them.
+func mobileNotification(n *models.Notification, unread int) *push.Notification {
+	mn := &push.Notification{
+		Badge:  &unread,
+		Sound:  "default",
+		Urgent: n.Urgent,
+		Data: map[string]string{
+			"notification_id": n.ID.String(),
+			"type":            n.Type,
+		},
+	}
+	if n.MessageID != nil {
+		mn.Data["message_id"] = n.MessageID.String()
+	}
+	if n.ChannelID != nil {
+		mn.Data["channel_id"] = n.ChannelID.String()
+		mn.ThreadID = "channel:" + n.ChannelID.String()
+	}
+	if n.DMID != nil {
+		mn.Data["dm_id"] = n.DMID.String()
+		mn.ThreadID = "dm:" + n.DMID.String()
+	}
+
+	switch n.Type {
+	case NotificationTypeMention:
+		mn.Title = "New mention"
+		mn.Body = "You were mentioned in a conversation"
+	case NotificationTypeKeyword:
+		var data map[string]string
+		json.Unmarshal(n.Data, &data)
+		mn.Title = "Keyword alert"
+		mn.Body = fmt.Sprintf("A message matched %q", data["keyword"])
+	default:
+		mn.Title = "New direct message"
+		mn.Body = "You have a new direct message"
+	}
+	return mn
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+	"github.com/DoDuy2004/slack-clone-backend/internal/websocket"
+	"github.com/DoDuy2004/slack-clone-backend/pkg/push"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockDeviceRepository is a mock implementation of DeviceRepository
+type MockDeviceRepository struct {
+	mock.Mock
+}
+
+func (m *MockDeviceRepository) Upsert(device *models.DeviceToken) error {
+	args := m.Called(device)
+	return args.Error(0)
+}
+
+func (m *MockDeviceRepository) Delete(userID uuid.UUID, token string) (bool, error) {
+	args := m.Called(userID, token)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockDeviceRepository) DeleteByToken(token string) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+
+func (m *MockDeviceRepository) ListByUser(userID uuid.UUID) ([]*models.DeviceToken, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]*models.DeviceToken), args.Error(1)
+}
+
+func notificationEvent(t *testing.T, n *models.Notification, unread int) *websocket.WSMessage {
+	payload, err := json.Marshal(websocket.NotificationPayload{Notification: n, UnreadCount: unread})
+	require.NoError(t, err)
+	return &websocket.WSMessage{Type: websocket.EventNotificationNew, Payload: payload, UserID: &n.UserID}
+}
+
+func TestMobilePushDeliversToOfflineDevices(t *testing.T) {
+	userID := uuid.New()
+	dmID := uuid.New()
+
+	mockRepo := new(MockDeviceRepository)
+	ios := push.NewFakeProvider(push.PlatformIOS)
+	ios.FailWith("stale-token", push.ErrInvalidToken)
+	svc := NewMobilePushService(mockRepo, push.NewMemoryQueue(), []push.Provider{ios}, stubPresence{}).(*mobilePushService)
+
+	mockRepo.On("ListByUser", userID).Return([]*models.DeviceToken{
+		{ID: uuid.New(), UserID: userID, Platform: push.PlatformIOS, Token: "phone-token"},
+		{ID: uuid.New(), UserID: userID, Platform: push.PlatformIOS, Token: "stale-token"},
+		// No Android provider is configured
+		{ID: uuid.New(), UserID: userID, Platform: push.PlatformAndroid, Token: "android-token"},
+	}, nil)
+	mockRepo.On("DeleteByToken", "stale-token").Return(nil)
+
+	svc.handleEvent(notificationEvent(t, &models.Notification{ID: uuid.New(), UserID: userID, Type: NotificationTypeMessage, DMID: &dmID}, 4))
+
+	delivered, err := svc.ProcessQueue()
+	require.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+
+	sent := ios.Sent()
+	require.Len(t, sent, 1)
+	assert.Equal(t, "phone-token", sent[0].Token)
+	assert.Equal(t, "New direct message", sent[0].Notification.Title)
+	assert.Equal(t, 4, *sent[0].Notification.Badge)
+	assert.Equal(t, "dm:"+dmID.String(), sent[0].Notification.ThreadID)
+	assert.Equal(t, dmID.String(), sent[0].Notification.Data["dm_id"])
+	mockRepo.AssertExpectations(t)
+}
+
+func TestMobilePushSkipsConnectedUsers(t *testing.T) {
+	userID := uuid.New()
+	channelID := uuid.New()
+
+	mockRepo := new(MockDeviceRepository)
+	ios := push.NewFakeProvider(push.PlatformIOS)
+	svc := NewMobilePushService(mockRepo, push.NewMemoryQueue(), []push.Provider{ios}, stubPresence{userID: true}).(*mobilePushService)
+
+	svc.handleEvent(notificationEvent(t, &models.Notification{ID: uuid.New(), UserID: userID, Type: NotificationTypeMention, ChannelID: &channelID}, 1))
+
+	delivered, err := svc.ProcessQueue()
+	require.NoError(t, err)
+	assert.Equal(t, 0, delivered)
+	mockRepo.AssertNotCalled(t, "ListByUser", userID)
+}
+
+func TestMobilePushUpdatesBadgeOnRead(t *testing.T) {
+	userID := uuid.New()
+
+	mockRepo := new(MockDeviceRepository)
+	android := push.NewFakeProvider(push.PlatformAndroid)
+	svc := NewMobilePushService(mockRepo, push.NewMemoryQueue(), []push.Provider{android}, stubPresence{userID: true}).(*mobilePushService)
+
+	mockRepo.On("ListByUser", userID).Return([]*models.DeviceToken{
+		{ID: uuid.New(), UserID: userID, Platform: push.PlatformAndroid, Token: "android-token"},
+	}, nil)
+
+	payload, err := json.Marshal(websocket.NotificationReadPayload{UnreadCount: 0})
+	require.NoError(t, err)
+	svc.handleEvent(&websocket.WSMessage{Type: websocket.EventNotificationRead, Payload: payload, UserID: &userID})
+
+	_, err = svc.ProcessQueue()
+	require.NoError(t, err)
+
+	sent := android.Sent()
+	require.Len(t, sent, 1)
+	assert.Empty(t, sent[0].Notification.Title)
+	assert.Equal(t, 0, *sent[0].Notification.Badge)
+}
@@ -0,0 +1,707 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+	"github.com/DoDuy2004/slack-clone-backend/internal/models/dto"
+	"github.com/DoDuy2004/slack-clone-backend/internal/repository"
+	"github.com/DoDuy2004/slack-clone-backend/internal/websocket"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNotificationNotFound = errors.New("notification not found")
+	ErrInvalidCursor        = errors.New("invalid cursor")
+)
+
+const heldDeliveryBatchSize = 100
+
+// Notification types stored in the activity feed
+const (
+	NotificationTypeMention        = "mention"
+	NotificationTypeReaction       = "reaction"
+	NotificationTypeThreadReply    = "thread_reply"
+	NotificationTypeInviteAccepted = "invite_accepted"
+	NotificationTypeMessage        = "message"
+	NotificationTypeKeyword        = "keyword"
+)
+
+// Notification levels for channels and DMs
+const (
+	NotificationLevelAll      = "all"
+	NotificationLevelMentions = "mentions"
+	NotificationLevelNothing  = "nothing"
+)
+
+type NotificationService interface {
+	// Notify stores a feed item and pushes it to the recipient. Items the actor
+	// would send to themselves, or that the recipient's preferences for the
+	// conversation filter out, are dropped.
+	Notify(notification *models.Notification)
+	// NotifyMessage sends message and keyword alert items for a new message to
+	// the conversation's members, except those in skip.
+	NotifyMessage(message *models.Message, workspaceID uuid.UUID, skip map[uuid.UUID]bool)
+	ListNotifications(userID uuid.UUID, workspaceID *uuid.UUID, unreadOnly bool, cursor string, limit int) (*dto.NotificationListResponse, error)
+	UnreadCount(userID uuid.UUID, workspaceID *uuid.UUID) (int, error)
+	MarkAsRead(userID, notificationID uuid.UUID) error
+	MarkAllAsRead(userID uuid.UUID, workspaceID *uuid.UUID) error
+
+	// Preferences; exactly one of channelID and dmID is set
+	GetPreference(userID uuid.UUID, channelID, dmID *uuid.UUID) (*models.NotificationPreference, error)
+	UpdatePreference(userID uuid.UUID, channelID, dmID *uuid.UUID, req *dto.UpdateNotificationPreferenceRequest) (*models.NotificationPreference, error)
+	GetUserSettings(userID, workspaceID uuid.UUID) (*models.UserNotificationSettings, error)
+	UpdateUserSettings(userID, workspaceID uuid.UUID, req *dto.UpdateNotificationSettingsRequest) (*models.UserNotificationSettings, error)
+
+	// DeliverHeld pushes notifications held during DND to users whose DND has ended.
+	DeliverHeld() error
+	RunHeldDelivery(interval time.Duration)
+}
+
+type notificationService struct {
+	notificationRepo repository.NotificationRepository
+	preferenceRepo   repository.NotificationPreferenceRepository
+	workspaceRepo    repository.WorkspaceRepository
+	channelRepo      repository.ChannelRepository
+	dmRepo           repository.DMRepository
+	dndService       DNDService
+	pushService      PushService
+	hub              *websocket.Hub
+}
+
+func NewNotificationService(
+	notificationRepo repository.NotificationRepository,
+	preferenceRepo repository.NotificationPreferenceRepository,
+	workspaceRepo repository.WorkspaceRepository,
+	channelRepo repository.ChannelRepository,
+	dmRepo repository.DMRepository,
+	dndService DNDService,
+	pushService PushService,
+	hub *websocket.Hub,
+) NotificationService {
+	return &notificationService{
+		notificationRepo: notificationRepo,
+		preferenceRepo:   preferenceRepo,
+		workspaceRepo:    workspaceRepo,
+		channelRepo:      channelRepo,
+		dmRepo:           dmRepo,
+		dndService:       dndService,
+		pushService:      pushService,
+		hub:              hub,
+	}
+}
+
+func (s *notificationService) Notify(n *models.Notification) {
+	if n.ActorID != nil && *n.ActorID == n.UserID {
+		return
+	}
+
+	// Items tied to a conversation follow the recipient's preferences for it
+	if (n.ChannelID != nil || n.DMID != nil) && n.WorkspaceID != nil {
+		pref, err := s.preferenceRepo.Get(n.UserID, n.ChannelID, n.DMID)
+		if err != nil {
+			log.Printf("error loading notification preference for user %s: %v", n.UserID, err)
+			return
+		}
+		level, err := s.resolveLevel(n.UserID, *n.WorkspaceID, pref, n.DMID != nil)
+		if err != nil {
+			log.Printf("error resolving notification level for user %s: %v", n.UserID, err)
+			return
+		}
+		if !allowsNotification(level, isMuted(pref, time.Now()), n.Type) {
+			return
+		}
+	}
+
+	s.deliver(n)
+}
+
+func (s *notificationService) NotifyMessage(message *models.Message, workspaceID uuid.UUID, skip map[uuid.UUID]bool) {
+	audience, err := s.conversationMembers(message)
+	if err != nil {
+		log.Printf("error listing members for message %s: %v", message.ID, err)
+		return
+	}
+
+	var recipients []uuid.UUID
+	for _, userID := range audience {
+		if skip[userID] || (message.SenderID != nil && *message.SenderID == userID) {
+			continue
+		}
+		recipients = append(recipients, userID)
+	}
+	if len(recipients) == 0 {
+		return
+	}
+
+	prefs, err := s.preferenceRepo.ListForConversation(message.ChannelID, message.DMID, recipients)
+	if err != nil {
+		log.Printf("error loading notification preferences for message %s: %v", message.ID, err)
+		return
+	}
+	prefByUser := make(map[uuid.UUID]*models.NotificationPreference, len(prefs))
+	for _, pref := range prefs {
+		prefByUser[pref.UserID] = pref
+	}
+
+	userSettings, err := s.preferenceRepo.ListUserSettings(workspaceID, recipients)
+	if err != nil {
+		log.Printf("error loading notification settings for message %s: %v", message.ID, err)
+		return
+	}
+	settingsByUser := make(map[uuid.UUID]*models.UserNotificationSettings, len(userSettings))
+	for _, settings := range userSettings {
+		settingsByUser[settings.UserID] = settings
+	}
+
+	wsSettings, err := s.workspaceRepo.GetSettings(workspaceID)
+	if err != nil {
+		log.Printf("error loading workspace settings for %s: %v", workspaceID, err)
+		return
+	}
+
+	// Replies only reach the conversation when they are echoed to it
+	inConversation := message.ParentMessageID == nil || message.AlsoSentToChannel
+	now := time.Now()
+
+	for _, userID := range recipients {
+		pref := prefByUser[userID]
+		settings := settingsByUser[userID]
+		level := effectiveLevel(pref, settings, wsSettings.DefaultNotificationLevel, message.DMID != nil)
+		muted := isMuted(pref, now)
+
+		n := &models.Notification{
+			UserID:      userID,
+			WorkspaceID: &workspaceID,
+			ActorID:     message.SenderID,
+			MessageID:   &message.ID,
+			ChannelID:   message.ChannelID,
+			DMID:        message.DMID,
+			Urgent:      message.Urgent,
+		}
+
+		var keywords []string
+		if settings != nil {
+			keywords = settings.Keywords
+		}
+		if keyword := matchKeyword(message.Content, keywords); keyword != "" {
+			n.Type = NotificationTypeKeyword
+			n.Data = notificationData(map[string]string{"keyword": keyword})
+		} else if inConversation {
+			n.Type = NotificationTypeMessage
+		} else {
+			continue
+		}
+
+		if allowsNotification(level, muted, n.Type) {
+			s.deliver(n)
+		}
+	}
+}
+
+// deliver stores a feed item and pushes it to the recipient, unless the
+// recipient is in DND and the item isn't urgent; then it is held.
+func (s *notificationService) deliver(n *models.Notification) {
+	if n.ID == uuid.Nil {
+		n.ID = uuid.New()
+	}
+
+	held := !interruptible(s.dndService, n.UserID, n.Urgent)
+	if !held {
+		now := time.Now().UTC()
+		n.DeliveredAt = &now
+	}
+
+	if err := s.notificationRepo.Create(n); err != nil {
+		log.Printf("error storing %s notification for user %s: %v", n.Type, n.UserID, err)
+		return
+	}
+
+	if !held {
+		s.push(n.UserID, []*models.Notification{n})
+	}
+}
+
+// push sends notification.new events to the user's connected devices, and
+// Web Push messages to their other devices when no socket is open.
+func (s *notificationService) push(userID uuid.UUID, notifications []*models.Notification) {
+	unread, err := s.notificationRepo.CountUnread(userID, nil)
+	if err != nil {
+		log.Printf("error counting unread notifications for user %s: %v", userID, err)
+	}
+
+	for _, n := range notifications {
+		payload, err := json.Marshal(websocket.NotificationPayload{
+			Notification: n,
+			UnreadCount:  unread,
+		})
+		if err != nil {
+			log.Printf("error marshaling notification payload: %v", err)
+			continue
+		}
+
+		s.hub.Broadcast(&websocket.WSMessage{
+			Type:    websocket.EventNotificationNew,
+			Payload: payload,
+			UserID:  &userID,
+		})
+		s.pushService.NotifyOffline(n)
+	}
+}
+
+// DeliverHeld walks every user with held notifications, so users still in
+// DND can't crowd out the ones whose DND has ended.
+func (s *notificationService) DeliverHeld() error {
+	var afterID *uuid.UUID
+	for {
+		userIDs, err := s.notificationRepo.ListHeldUserIDs(afterID, heldDeliveryBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, userID := range userIDs {
+			s.deliverHeldFor(userID)
+		}
+
+		if len(userIDs) < heldDeliveryBatchSize {
+			return nil
+		}
+		afterID = &userIDs[len(userIDs)-1]
+	}
+}
+
+// deliverHeldFor pushes a user's held notifications once their DND is over.
+func (s *notificationService) deliverHeldFor(userID uuid.UUID) {
+	dnd, err := s.dndService.IsActive(userID)
+	if err != nil {
+		log.Printf("error checking DND for user %s: %v", userID, err)
+		return
+	}
+	if dnd {
+		return
+	}
+
+	held, err := s.notificationRepo.ListHeld(userID, heldDeliveryBatchSize)
+	if err != nil {
+		log.Printf("error listing held notifications for user %s: %v", userID, err)
+		return
+	}
+	if len(held) == 0 {
+		return
+	}
+
+	ids := make([]uuid.UUID, 0, len(held))
+	for _, n := range held {
+		ids = append(ids, n.ID)
+	}
+	if err := s.notificationRepo.MarkDelivered(ids); err != nil {
+		log.Printf("error marking notifications delivered for user %s: %v", userID, err)
+		return
+	}
+
+	now := time.Now().UTC()
+	for _, n := range held {
+		n.DeliveredAt = &now
+	}
+	s.push(userID, held)
+}
+
+// RunHeldDelivery polls for held notifications until the process exits.
+func (s *notificationService) RunHeldDelivery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := s.DeliverHeld(); err != nil {
+			log.Printf("error delivering held notifications: %v", err)
+		}
+	}
+}
+
+func (s *notificationService) ListNotifications(userID uuid.UUID, workspaceID *uuid.UUID, unreadOnly bool, cursor string, limit int) (*dto.NotificationListResponse, error) {
+	var beforeTime *time.Time
+	var beforeID *uuid.UUID
+	if cursor != "" {
+		t, id, err := decodeNotificationCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		beforeTime, beforeID = &t, &id
+	}
+
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+
+	// Fetch one extra row to know whether there is a next page
+	notifications, err := s.notificationRepo.ListByUser(userID, workspaceID, unreadOnly, beforeTime, beforeID, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.NotificationListResponse{Notifications: notifications}
+	if len(notifications) > limit {
+		resp.Notifications = notifications[:limit]
+		last := resp.Notifications[limit-1]
+		resp.NextCursor = encodeNotificationCursor(last.CreatedAt, last.ID)
+	}
+	if resp.Notifications == nil {
+		resp.Notifications = []*models.Notification{}
+	}
+
+	resp.UnreadCount, err = s.notificationRepo.CountUnread(userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func (s *notificationService) UnreadCount(userID uuid.UUID, workspaceID *uuid.UUID) (int, error) {
+	return s.notificationRepo.CountUnread(userID, workspaceID)
+}
+
+func (s *notificationService) MarkAsRead(userID, notificationID uuid.UUID) error {
+	found, err := s.notificationRepo.MarkRead(notificationID, userID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrNotificationNotFound
+	}
+
+	s.broadcastUnreadCount(userID)
+	return nil
+}
+
+func (s *notificationService) MarkAllAsRead(userID uuid.UUID, workspaceID *uuid.UUID) error {
+	if err := s.notificationRepo.MarkAllRead(userID, workspaceID); err != nil {
+		return err
+	}
+
+	s.broadcastUnreadCount(userID)
+	return nil
+}
+
+// broadcastUnreadCount tells the user's devices the new unread count so other
+// tabs and app badges stay in sync.
+func (s *notificationService) broadcastUnreadCount(userID uuid.UUID) {
+	unread, err := s.notificationRepo.CountUnread(userID, nil)
+	if err != nil {
+		log.Printf("error counting unread notifications for user %s: %v", userID, err)
+		return
+	}
+
+	payload, _ := json.Marshal(websocket.NotificationReadPayload{UnreadCount: unread})
+	s.hub.Broadcast(&websocket.WSMessage{
+		Type:    websocket.EventNotificationRead,
+		Payload: payload,
+		UserID:  &userID,
+	})
+}
+
+func (s *notificationService) GetPreference(userID uuid.UUID, channelID, dmID *uuid.UUID) (*models.NotificationPreference, error) {
+	workspaceID, err := s.verifyConversation(userID, channelID, dmID)
+	if err != nil {
+		return nil, err
+	}
+
+	pref, err := s.preferenceRepo.Get(userID, channelID, dmID)
+	if err != nil {
+		return nil, err
+	}
+	if pref == nil {
+		pref = &models.NotificationPreference{UserID: userID, ChannelID: channelID, DMID: dmID}
+	}
+
+	pref.EffectiveLevel, err = s.resolveLevel(userID, workspaceID, pref, dmID != nil)
+	if err != nil {
+		return nil, err
+	}
+	return pref, nil
+}
+
+func (s *notificationService) UpdatePreference(userID uuid.UUID, channelID, dmID *uuid.UUID, req *dto.UpdateNotificationPreferenceRequest) (*models.NotificationPreference, error) {
+	workspaceID, err := s.verifyConversation(userID, channelID, dmID)
+	if err != nil {
+		return nil, err
+	}
+
+	pref, err := s.preferenceRepo.Get(userID, channelID, dmID)
+	if err != nil {
+		return nil, err
+	}
+	if pref == nil {
+		pref = &models.NotificationPreference{ID: uuid.New(), UserID: userID, ChannelID: channelID, DMID: dmID}
+	}
+
+	if req.Level != nil {
+		if *req.Level == "default" {
+			pref.Level = nil
+		} else {
+			pref.Level = req.Level
+		}
+	}
+	if req.Muted != nil {
+		pref.Muted = *req.Muted
+		if !pref.Muted {
+			pref.MutedUntil = nil
+		}
+	}
+	if req.MutedUntil != nil {
+		pref.Muted = true
+		pref.MutedUntil = req.MutedUntil
+	}
+
+	if err := s.preferenceRepo.Upsert(pref); err != nil {
+		return nil, err
+	}
+
+	pref.EffectiveLevel, err = s.resolveLevel(userID, workspaceID, pref, dmID != nil)
+	if err != nil {
+		return nil, err
+	}
+	return pref, nil
+}
+
+func (s *notificationService) GetUserSettings(userID, workspaceID uuid.UUID) (*models.UserNotificationSettings, error) {
+	member, err := s.workspaceRepo.GetMember(workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, ErrUnauthorized
+	}
+
+	settings, err := s.preferenceRepo.GetUserSettings(userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		settings = &models.UserNotificationSettings{UserID: userID, WorkspaceID: workspaceID, Keywords: []string{}}
+	}
+	return settings, nil
+}
+
+func (s *notificationService) UpdateUserSettings(userID, workspaceID uuid.UUID, req *dto.UpdateNotificationSettingsRequest) (*models.UserNotificationSettings, error) {
+	settings, err := s.GetUserSettings(userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.DefaultLevel != nil {
+		if *req.DefaultLevel == "default" {
+			settings.DefaultLevel = nil
+		} else {
+			settings.DefaultLevel = req.DefaultLevel
+		}
+	}
+	if req.Keywords != nil {
+		settings.Keywords = normalizeKeywords(req.Keywords)
+	}
+
+	if err := s.preferenceRepo.UpsertUserSettings(settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// verifyConversation checks that the user belongs to the channel or DM and
+// returns its workspace.
+func (s *notificationService) verifyConversation(userID uuid.UUID, channelID, dmID *uuid.UUID) (uuid.UUID, error) {
+	if channelID != nil {
+		channel, err := s.channelRepo.FindByID(*channelID)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		if channel == nil {
+			return uuid.Nil, ErrChannelNotFound
+		}
+		isMember, err := s.channelRepo.IsMember(*channelID, userID)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		if !isMember {
+			return uuid.Nil, ErrUnauthorized
+		}
+		return channel.WorkspaceID, nil
+	}
+
+	dm, err := s.dmRepo.GetByID(*dmID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if dm == nil {
+		return uuid.Nil, ErrDMNotFound
+	}
+	isParticipant, err := s.dmRepo.IsParticipant(*dmID, userID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if !isParticipant {
+		return uuid.Nil, ErrUnauthorized
+	}
+	return dm.WorkspaceID, nil
+}
+
+// resolveLevel loads whatever defaults the preference falls back on.
+func (s *notificationService) resolveLevel(userID, workspaceID uuid.UUID, pref *models.NotificationPreference, isDM bool) (string, error) {
+	if (pref != nil && pref.Level != nil) || isDM {
+		return effectiveLevel(pref, nil, "", isDM), nil
+	}
+
+	settings, err := s.preferenceRepo.GetUserSettings(userID, workspaceID)
+	if err != nil {
+		return "", err
+	}
+	wsSettings, err := s.workspaceRepo.GetSettings(workspaceID)
+	if err != nil {
+		return "", err
+	}
+	return effectiveLevel(pref, settings, wsSettings.DefaultNotificationLevel, isDM), nil
+}
+
+func (s *notificationService) conversationMembers(message *models.Message) ([]uuid.UUID, error) {
+	if message.DMID != nil {
+		return s.dmRepo.ListParticipantIDs(*message.DMID)
+	}
+	if message.ChannelID == nil {
+		return nil, nil
+	}
+
+	members, err := s.channelRepo.ListMembers(*message.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uuid.UUID, 0, len(members))
+	for _, member := range members {
+		ids = append(ids, member.UserID)
+	}
+	return ids, nil
+}
+
+// effectiveLevel resolves a conversation's level: its own setting, then the
+// user's workspace default, then the workspace default. DMs notify for every
+// message unless the user says otherwise.
+func effectiveLevel(pref *models.NotificationPreference, settings *models.UserNotificationSettings, workspaceDefault string, isDM bool) string {
+	if pref != nil && pref.Level != nil {
+		return *pref.Level
+	}
+	if isDM {
+		return NotificationLevelAll
+	}
+	if settings != nil && settings.DefaultLevel != nil {
+		return *settings.DefaultLevel
+	}
+	if workspaceDefault != "" {
+		return workspaceDefault
+	}
+	return NotificationLevelMentions
+}
+
+func isMuted(pref *models.NotificationPreference, now time.Time) bool {
+	if pref == nil || !pref.Muted {
+		return false
+	}
+	return pref.MutedUntil == nil || pref.MutedUntil.After(now)
+}
+
+// allowsNotification reports whether a feed item of the given type gets
+// through a conversation's level. Muted and "nothing" drop everything;
+// "mentions" drops plain new-message items.
+func allowsNotification(level string, muted bool, notificationType string) bool {
+	if muted || level == NotificationLevelNothing {
+		return false
+	}
+	if level == NotificationLevelMentions && notificationType == NotificationTypeMessage {
+		return false
+	}
+	return true
+}
+
+// matchKeyword returns the first keyword found in content as a whole word or
+// phrase, ignoring case.
+func matchKeyword(content string, keywords []string) string {
+	if len(keywords) == 0 {
+		return ""
+	}
+
+	lower := strings.ToLower(content)
+	for _, keyword := range keywords {
+		if keyword == "" {
+			continue
+		}
+		for start := 0; start < len(lower); {
+			i := strings.Index(lower[start:], keyword)
+			if i < 0 {
+				break
+			}
+			i += start
+			end := i + len(keyword)
+			if (i == 0 || !isWordByte(lower[i-1])) && (end == len(lower) || !isWordByte(lower[end])) {
+				return keyword
+			}
+			start = i + 1
+		}
+	}
+	return ""
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || (b >= 'a' && b <= 'z') || (b >= '0' && b <= '9') || b >= 0x80
+}
+
+func normalizeKeywords(keywords []string) []string {
+	seen := make(map[string]bool)
+	normalized := []string{}
+	for _, keyword := range keywords {
+		keyword = strings.ToLower(strings.TrimSpace(keyword))
+		if keyword == "" || seen[keyword] {
+			continue
+		}
+		seen[keyword] = true
+		normalized = append(normalized, keyword)
+	}
+	return normalized
+}
+
+// encodeNotificationCursor builds an opaque cursor from the last item of a page.
+func encodeNotificationCursor(createdAt time.Time, id uuid.UUID) string {
+	raw := strconv.FormatInt(createdAt.UnixNano(), 10) + "_" + id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeNotificationCursor(cursor string) (time.Time, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+
+	parts := strings.SplitN(string(raw), "_", 2)
+	if len(parts) != 2 {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+	id, err := uuid.Parse(parts[1])
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+
+	return time.Unix(0, nanos).UTC(), id, nil
+}
+
+// notificationData encodes the type-specific details of a feed item.
+func notificationData(fields map[string]string) json.RawMessage {
+	data, _ := json.Marshal(fields)
+	return data
+}
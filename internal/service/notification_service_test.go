@@ -0,0 +1,64 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotificationCursorRoundTrip(t *testing.T) {
+	createdAt := time.Date(2024, 3, 1, 12, 30, 0, 123456000, time.UTC)
+	id := uuid.New()
+
+	gotTime, gotID, err := decodeNotificationCursor(encodeNotificationCursor(createdAt, id))
+
+	assert.NoError(t, err)
+	assert.True(t, createdAt.Equal(gotTime))
+	assert.Equal(t, id, gotID)
+}
+
+func TestDecodeNotificationCursor_Invalid(t *testing.T) {
+	_, _, err := decodeNotificationCursor("not-a-cursor")
+
+	assert.Equal(t, ErrInvalidCursor, err)
+}
+
+func TestEffectiveLevel(t *testing.T) {
+	all, nothing := NotificationLevelAll, NotificationLevelNothing
+
+	assert.Equal(t, NotificationLevelMentions, effectiveLevel(nil, nil, "", false))
+	assert.Equal(t, NotificationLevelNothing, effectiveLevel(nil, nil, NotificationLevelNothing, false))
+	assert.Equal(t, NotificationLevelAll, effectiveLevel(nil, &models.UserNotificationSettings{DefaultLevel: &all}, NotificationLevelNothing, false))
+	assert.Equal(t, NotificationLevelNothing, effectiveLevel(&models.NotificationPreference{Level: &nothing}, &models.UserNotificationSettings{DefaultLevel: &all}, "", false))
+	assert.Equal(t, NotificationLevelAll, effectiveLevel(nil, nil, NotificationLevelNothing, true))
+}
+
+func TestIsMuted(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	assert.False(t, isMuted(nil, now))
+	assert.True(t, isMuted(&models.NotificationPreference{Muted: true}, now))
+	assert.True(t, isMuted(&models.NotificationPreference{Muted: true, MutedUntil: &future}, now))
+	assert.False(t, isMuted(&models.NotificationPreference{Muted: true, MutedUntil: &past}, now))
+}
+
+func TestAllowsNotification(t *testing.T) {
+	assert.True(t, allowsNotification(NotificationLevelAll, false, NotificationTypeMessage))
+	assert.False(t, allowsNotification(NotificationLevelMentions, false, NotificationTypeMessage))
+	assert.True(t, allowsNotification(NotificationLevelMentions, false, NotificationTypeMention))
+	assert.False(t, allowsNotification(NotificationLevelNothing, false, NotificationTypeMention))
+	assert.False(t, allowsNotification(NotificationLevelAll, true, NotificationTypeMention))
+}
+
+func TestMatchKeyword(t *testing.T) {
+	keywords := []string{"deploy", "on call"}
+
+	assert.Equal(t, "deploy", matchKeyword("Starting the DEPLOY now", keywords))
+	assert.Equal(t, "on call", matchKeyword("who is on call today?", keywords))
+	assert.Equal(t, "", matchKeyword("redeployed yesterday", keywords))
+	assert.Equal(t, "", matchKeyword("anything", nil))
+}
@@ -16,14 +16,16 @@ type PresenceService interface {
 }
 
 type presenceService struct {
-	userRepo repository.UserRepository
-	hub      *websocket.Hub
+	userRepo   repository.UserRepository
+	dndService DNDService
+	hub        *websocket.Hub
 }
 
-func NewPresenceService(userRepo repository.UserRepository, hub *websocket.Hub) PresenceService {
+func NewPresenceService(userRepo repository.UserRepository, dndService DNDService, hub *websocket.Hub) PresenceService {
 	return &presenceService{
-		userRepo: userRepo,
-		hub:      hub,
+		userRepo:   userRepo,
+		dndService: dndService,
+		hub:        hub,
 	}
 }
 
@@ -56,9 +58,15 @@ func (s *presenceService) UpdateCustomStatus(userID uuid.UUID, status string) er
 }
 
 func (s *presenceService) broadcastPresence(userID uuid.UUID, status string) {
+	dnd, err := s.dndService.IsActive(userID)
+	if err != nil {
+		log.Printf("error checking DND for user %s: %v", userID, err)
+	}
+
 	payload, err := json.Marshal(websocket.PresencePayload{
 		UserID: userID,
 		Status: status,
+		DND:    dnd,
 	})
 	if err != nil {
 		log.Printf("error marshaling presence payload: %v", err)
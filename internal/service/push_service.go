@@ -0,0 +1,237 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+	"github.com/DoDuy2004/slack-clone-backend/internal/models/dto"
+	"github.com/DoDuy2004/slack-clone-backend/internal/repository"
+	"github.com/DoDuy2004/slack-clone-backend/pkg/webpush"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidPushSubscription  = errors.New("invalid push subscription")
+	ErrPushSubscriptionNotFound = errors.New("push subscription not found")
+)
+
+const (
+	pushBatchSize   = 100
+	pushMaxAttempts = 5
+	pushTTL         = 24 * 60 * 60 // seconds
+	pushLease       = 2 * time.Minute
+	pushRetryBase   = 30 * time.Second
+	pushRetryMax    = time.Hour
+)
+
+// PresenceChecker reports whether a user has any open sockets.
+type PresenceChecker interface {
+	IsUserConnected(userID uuid.UUID) bool
+}
+
+type PushService interface {
+	VAPIDPublicKey() string
+	Subscribe(userID uuid.UUID, req *dto.PushSubscriptionRequest, userAgent string) (*models.PushSubscription, error)
+	Unsubscribe(userID uuid.UUID, endpoint string) error
+	// NotifyOffline queues a push for mentions, keyword alerts and DMs when
+	// the recipient has no open sockets.
+	NotifyOffline(notification *models.Notification)
+	// ProcessQueue sends due pushes. It returns the number delivered.
+	ProcessQueue() (int, error)
+	RunQueue(interval time.Duration)
+}
+
+type pushService struct {
+	pushRepo repository.PushRepository
+	sender   *webpush.Sender
+	presence PresenceChecker
+}
+
+func NewPushService(pushRepo repository.PushRepository, sender *webpush.Sender, presence PresenceChecker) PushService {
+	return &pushService{
+		pushRepo: pushRepo,
+		sender:   sender,
+		presence: presence,
+	}
+}
+
+func (s *pushService) VAPIDPublicKey() string {
+	return s.sender.Keys().PublicKey()
+}
+
+func (s *pushService) Subscribe(userID uuid.UUID, req *dto.PushSubscriptionRequest, userAgent string) (*models.PushSubscription, error) {
+	if err := webpush.ValidateEndpoint(req.Endpoint); err != nil {
+		return nil, ErrInvalidPushSubscription
+	}
+	if err := webpush.ValidateKeys(req.Keys.P256dh, req.Keys.Auth); err != nil {
+		return nil, ErrInvalidPushSubscription
+	}
+
+	sub := &models.PushSubscription{
+		UserID:   userID,
+		Endpoint: req.Endpoint,
+		P256dh:   req.Keys.P256dh,
+		Auth:     req.Keys.Auth,
+	}
+	if userAgent != "" {
+		sub.UserAgent = &userAgent
+	}
+	if req.ExpirationTime != nil {
+		expires := time.UnixMilli(*req.ExpirationTime).UTC()
+		if !expires.After(time.Now()) {
+			return nil, ErrInvalidPushSubscription
+		}
+		sub.ExpirationTime = &expires
+	}
+
+	if err := s.pushRepo.UpsertSubscription(sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (s *pushService) Unsubscribe(userID uuid.UUID, endpoint string) error {
+	deleted, err := s.pushRepo.DeleteSubscription(userID, endpoint)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrPushSubscriptionNotFound
+	}
+	return nil
+}
+
+func (s *pushService) NotifyOffline(n *models.Notification) {
+	if !pushable(n) || s.presence.IsUserConnected(n.UserID) {
+		return
+	}
+
+	payload, err := json.Marshal(n)
+	if err != nil {
+		log.Printf("error marshaling push payload: %v", err)
+		return
+	}
+
+	urgency := "normal"
+	if n.Urgent {
+		urgency = "high"
+	}
+	if _, err := s.pushRepo.EnqueueForUser(n.UserID, string(payload), urgency); err != nil {
+		log.Printf("error queueing push for user %s: %v", n.UserID, err)
+	}
+}
+
+func (s *pushService) ProcessQueue() (int, error) {
+	now := time.Now().UTC()
+	jobs, err := s.pushRepo.ClaimDueJobs(now, now.Add(pushLease), pushBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, job := range jobs {
+		if s.send(job) {
+			delivered++
+		}
+	}
+	return delivered, nil
+}
+
+// send delivers one job and settles it: done, retried later, or dropped
+// along with its subscription when the push service says it is gone.
+func (s *pushService) send(job *models.PushJob) bool {
+	sub := &webpush.Subscription{
+		Endpoint: job.Subscription.Endpoint,
+		P256dh:   job.Subscription.P256dh,
+		Auth:     job.Subscription.Auth,
+	}
+	err := s.sender.Send(sub, []byte(job.Payload), &webpush.Options{TTL: pushTTL, Urgency: job.Urgency})
+	if err == nil {
+		if err := s.pushRepo.DeleteJob(job.ID); err != nil {
+			log.Printf("error deleting push job %s: %v", job.ID, err)
+		}
+		if err := s.pushRepo.MarkSubscriptionUsed(job.SubscriptionID); err != nil {
+			log.Printf("error updating push subscription %s: %v", job.SubscriptionID, err)
+		}
+		return true
+	}
+
+	var pushErr *webpush.PushError
+	if (errors.As(err, &pushErr) && pushErr.Gone()) || errors.Is(err, webpush.ErrInvalidKeys) || errors.Is(err, webpush.ErrForbiddenAddress) {
+		// Deleting the subscription cascades to its queued jobs
+		if err := s.pushRepo.DeleteSubscriptionByID(job.SubscriptionID); err != nil {
+			log.Printf("error deleting push subscription %s: %v", job.SubscriptionID, err)
+		}
+		return false
+	}
+
+	if !pushRetryable(err) || job.Attempts+1 >= pushMaxAttempts {
+		log.Printf("dropping push job %s after %d attempts: %v", job.ID, job.Attempts+1, err)
+		if err := s.pushRepo.DeleteJob(job.ID); err != nil {
+			log.Printf("error deleting push job %s: %v", job.ID, err)
+		}
+		return false
+	}
+
+	next := time.Now().UTC().Add(pushRetryDelay(job.Attempts))
+	if err := s.pushRepo.RetryJob(job.ID, next, err.Error()); err != nil {
+		log.Printf("error rescheduling push job %s: %v", job.ID, err)
+	}
+	return false
+}
+
+// RunQueue polls for due pushes and expired subscriptions until the process exits.
+func (s *pushService) RunQueue(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := s.ProcessQueue(); err != nil {
+			log.Printf("error processing push queue: %v", err)
+		}
+		if _, err := s.pushRepo.DeleteExpiredSubscriptions(time.Now().UTC()); err != nil {
+			log.Printf("error deleting expired push subscriptions: %v", err)
+		}
+	}
+}
+
+// pushable reports whether a notification is worth waking a device for:
+// mentions, keyword alerts and direct messages.
+func pushable(n *models.Notification) bool {
+	switch n.Type {
+	case NotificationTypeMention, NotificationTypeKeyword:
+		return true
+	case NotificationTypeMessage:
+		return n.DMID != nil
+	}
+	return false
+}
+
+// pushRetryable reports whether sending again later may succeed: rejections
+// the push service marks as temporary and network failures, but not payloads
+// that can never be encrypted.
+func pushRetryable(err error) bool {
+	if errors.Is(err, webpush.ErrPayloadTooLarge) {
+		return false
+	}
+	var pushErr *webpush.PushError
+	if errors.As(err, &pushErr) {
+		return pushErr.Retryable()
+	}
+	return true
+}
+
+// pushRetryDelay doubles from pushRetryBase with each failed attempt, up to pushRetryMax.
+func pushRetryDelay(attempts int) time.Duration {
+	delay := pushRetryBase
+	for i := 0; i < attempts; i++ {
+		delay *= 2
+		if delay >= pushRetryMax {
+			return pushRetryMax
+		}
+	}
+	return delay
+}
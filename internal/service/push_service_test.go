@@ -0,0 +1,224 @@
+package service
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+	"github.com/DoDuy2004/slack-clone-backend/internal/models/dto"
+	"github.com/DoDuy2004/slack-clone-backend/pkg/webpush"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockPushRepository is a mock implementation of PushRepository
+type MockPushRepository struct {
+	mock.Mock
+}
+
+func (m *MockPushRepository) UpsertSubscription(sub *models.PushSubscription) error {
+	args := m.Called(sub)
+	return args.Error(0)
+}
+
+func (m *MockPushRepository) DeleteSubscription(userID uuid.UUID, endpoint string) (bool, error) {
+	args := m.Called(userID, endpoint)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPushRepository) DeleteSubscriptionByID(id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockPushRepository) ListSubscriptions(userID uuid.UUID) ([]*models.PushSubscription, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]*models.PushSubscription), args.Error(1)
+}
+
+func (m *MockPushRepository) DeleteExpiredSubscriptions(now time.Time) (int64, error) {
+	args := m.Called(now)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockPushRepository) EnqueueForUser(userID uuid.UUID, payload, urgency string) (int64, error) {
+	args := m.Called(userID, payload, urgency)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockPushRepository) ClaimDueJobs(now, leaseUntil time.Time, limit int) ([]*models.PushJob, error) {
+	args := m.Called(now, leaseUntil, limit)
+	return args.Get(0).([]*models.PushJob), args.Error(1)
+}
+
+func (m *MockPushRepository) DeleteJob(id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockPushRepository) RetryJob(id uuid.UUID, nextAttemptAt time.Time, lastError string) error {
+	args := m.Called(id, nextAttemptAt, lastError)
+	return args.Error(0)
+}
+
+func (m *MockPushRepository) MarkSubscriptionUsed(id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+type stubPresence map[uuid.UUID]bool
+
+func (p stubPresence) IsUserConnected(userID uuid.UUID) bool {
+	return p[userID]
+}
+
+func newTestPushService(t *testing.T, repo *MockPushRepository, presence PresenceChecker) PushService {
+	keys, err := webpush.GenerateVAPIDKeys()
+	require.NoError(t, err)
+	// The test push services listen on loopback, which the default client refuses
+	return NewPushService(repo, webpush.NewSender(keys, "mailto:ops@example.com", http.DefaultClient), presence)
+}
+
+func newTestPushJob(t *testing.T, endpoint string, attempts int) *models.PushJob {
+	device, err := ecdh.P256().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	auth := make([]byte, 16)
+	_, err = rand.Read(auth)
+	require.NoError(t, err)
+
+	subID := uuid.New()
+	return &models.PushJob{
+		ID:             uuid.New(),
+		SubscriptionID: subID,
+		Payload:        `{"type":"mention"}`,
+		Urgency:        "normal",
+		Attempts:       attempts,
+		Subscription: &models.PushSubscription{
+			ID:       subID,
+			Endpoint: endpoint,
+			P256dh:   base64.RawURLEncoding.EncodeToString(device.PublicKey().Bytes()),
+			Auth:     base64.RawURLEncoding.EncodeToString(auth),
+		},
+	}
+}
+
+func TestNotifyOffline(t *testing.T) {
+	online := uuid.New()
+	offline := uuid.New()
+	dmID := uuid.New()
+	channelID := uuid.New()
+
+	mockRepo := new(MockPushRepository)
+	svc := newTestPushService(t, mockRepo, stubPresence{online: true})
+
+	mockRepo.On("EnqueueForUser", offline, mock.Anything, "normal").Return(int64(1), nil).Twice()
+
+	svc.NotifyOffline(&models.Notification{UserID: offline, Type: NotificationTypeMention, ChannelID: &channelID})
+	svc.NotifyOffline(&models.Notification{UserID: offline, Type: NotificationTypeMessage, DMID: &dmID})
+	// Connected users get the socket event instead
+	svc.NotifyOffline(&models.Notification{UserID: online, Type: NotificationTypeMention, ChannelID: &channelID})
+	// Channel chatter and reactions don't wake devices
+	svc.NotifyOffline(&models.Notification{UserID: offline, Type: NotificationTypeMessage, ChannelID: &channelID})
+	svc.NotifyOffline(&models.Notification{UserID: offline, Type: NotificationTypeReaction})
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProcessQueue(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.WriteHeader(http.StatusCreated)
+		case "/gone":
+			w.WriteHeader(http.StatusGone)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	ok := newTestPushJob(t, server.URL+"/ok", 0)
+	gone := newTestPushJob(t, server.URL+"/gone", 0)
+	flaky := newTestPushJob(t, server.URL+"/flaky", 1)
+	exhausted := newTestPushJob(t, server.URL+"/flaky", pushMaxAttempts-1)
+
+	mockRepo := new(MockPushRepository)
+	svc := newTestPushService(t, mockRepo, stubPresence{})
+
+	mockRepo.On("ClaimDueJobs", mock.Anything, mock.Anything, pushBatchSize).
+		Return([]*models.PushJob{ok, gone, flaky, exhausted}, nil)
+	mockRepo.On("DeleteJob", ok.ID).Return(nil)
+	mockRepo.On("MarkSubscriptionUsed", ok.SubscriptionID).Return(nil)
+	mockRepo.On("DeleteSubscriptionByID", gone.SubscriptionID).Return(nil)
+	mockRepo.On("RetryJob", flaky.ID, mock.MatchedBy(func(next time.Time) bool {
+		delay := time.Until(next)
+		return delay > 50*time.Second && delay <= time.Minute
+	}), mock.Anything).Return(nil)
+	mockRepo.On("DeleteJob", exhausted.ID).Return(nil)
+
+	delivered, err := svc.ProcessQueue()
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProcessQueueUnsendable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	badKeys := newTestPushJob(t, server.URL, 0)
+	badKeys.Subscription.P256dh = "not-a-key"
+	tooLarge := newTestPushJob(t, server.URL, 0)
+	tooLarge.Payload = strings.Repeat("x", webpush.MaxPayloadSize+1)
+
+	mockRepo := new(MockPushRepository)
+	svc := newTestPushService(t, mockRepo, stubPresence{})
+
+	mockRepo.On("ClaimDueJobs", mock.Anything, mock.Anything, pushBatchSize).
+		Return([]*models.PushJob{badKeys, tooLarge}, nil)
+	// Broken keys mean the subscription is useless, an oversized payload
+	// only that job
+	mockRepo.On("DeleteSubscriptionByID", badKeys.SubscriptionID).Return(nil)
+	mockRepo.On("DeleteJob", tooLarge.ID).Return(nil)
+
+	delivered, err := svc.ProcessQueue()
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, delivered)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "RetryJob", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSubscribeRejectsInternalEndpoints(t *testing.T) {
+	mockRepo := new(MockPushRepository)
+	svc := newTestPushService(t, mockRepo, stubPresence{})
+	job := newTestPushJob(t, "", 0)
+
+	for _, endpoint := range []string{"http://push.example.com/abc", "https://127.0.0.1:8443/abc"} {
+		req := &dto.PushSubscriptionRequest{Endpoint: endpoint}
+		req.Keys.P256dh = job.Subscription.P256dh
+		req.Keys.Auth = job.Subscription.Auth
+
+		_, err := svc.Subscribe(uuid.New(), req, "")
+		assert.Equal(t, ErrInvalidPushSubscription, err, endpoint)
+	}
+	mockRepo.AssertNotCalled(t, "UpsertSubscription", mock.Anything)
+}
+
+func TestPushRetryDelay(t *testing.T) {
+	assert.Equal(t, 30*time.Second, pushRetryDelay(0))
+	assert.Equal(t, time.Minute, pushRetryDelay(1))
+	assert.Equal(t, 4*time.Minute, pushRetryDelay(3))
+	assert.Equal(t, time.Hour, pushRetryDelay(10))
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"errors"
+	"log"
 
 	"github.com/DoDuy2004/slack-clone-backend/internal/models"
 	"github.com/DoDuy2004/slack-clone-backend/internal/repository"
@@ -25,6 +26,9 @@ type reactionService struct {
 	channelRepo   repository.ChannelRepository
 	dmRepo        repository.DMRepository
 	workspaceRepo repository.WorkspaceRepository
+
+	messageService      MessageService
+	notificationService NotificationService
 }
 
 func NewReactionService(
@@ -33,6 +37,8 @@ func NewReactionService(
 	channelRepo repository.ChannelRepository,
 	dmRepo repository.DMRepository,
 	workspaceRepo repository.WorkspaceRepository,
+	messageService MessageService,
+	notificationService NotificationService,
 ) ReactionService {
 	return &reactionService{
 		reactionRepo:  reactionRepo,
@@ -40,6 +46,9 @@ func NewReactionService(
 		channelRepo:   channelRepo,
 		dmRepo:        dmRepo,
 		workspaceRepo: workspaceRepo,
+
+		messageService:      messageService,
+		notificationService: notificationService,
 	}
 }
 
@@ -53,10 +62,15 @@ func (s *reactionService) AddReaction(userID, messageID uuid.UUID, emoji string)
 		return nil, nil, ErrMessageNotFound
 	}
 
-	// 2. Verify access
+	// 2. Verify access; archived channels are read-only
 	if err := s.verifyAccess(userID, message); err != nil {
 		return nil, nil, err
 	}
+	if message.ChannelID != nil {
+		if err := ensureChannelWritable(s.channelRepo, *message.ChannelID); err != nil {
+			return nil, nil, err
+		}
+	}
 
 	// 3. Check if already exists
 	existing, err := s.reactionRepo.GetByMessageUserEmoji(messageID, userID, emoji)
@@ -79,6 +93,11 @@ func (s *reactionService) AddReaction(userID, messageID uuid.UUID, emoji string)
 		return nil, nil, err
 	}
 
+	// 5. Let the author know
+	if message.SenderID != nil {
+		s.notifyReaction(userID, message, emoji)
+	}
+
 	return reaction, message, nil
 }
 
@@ -92,10 +111,15 @@ func (s *reactionService) RemoveReaction(userID, messageID uuid.UUID, emoji stri
 		return nil, ErrMessageNotFound
 	}
 
-	// 2. Verify access
+	// 2. Verify access; archived channels are read-only
 	if err := s.verifyAccess(userID, message); err != nil {
 		return nil, err
 	}
+	if message.ChannelID != nil {
+		if err := ensureChannelWritable(s.channelRepo, *message.ChannelID); err != nil {
+			return nil, err
+		}
+	}
 
 	// 3. Remove
 	if err := s.reactionRepo.Remove(messageID, userID, emoji); err != nil {
@@ -109,6 +133,25 @@ func (s *reactionService) GetReactions(messageID uuid.UUID) ([]*models.Reaction,
 	return s.reactionRepo.ListByMessageID(messageID)
 }
 
+func (s *reactionService) notifyReaction(userID uuid.UUID, message *models.Message, emoji string) {
+	workspaceID, err := s.messageService.MessageWorkspaceID(message)
+	if err != nil {
+		log.Printf("error resolving workspace of message %s: %v", message.ID, err)
+		return
+	}
+
+	s.notificationService.Notify(&models.Notification{
+		UserID:      *message.SenderID,
+		WorkspaceID: &workspaceID,
+		Type:        NotificationTypeReaction,
+		ActorID:     &userID,
+		MessageID:   &message.ID,
+		ChannelID:   message.ChannelID,
+		DMID:        message.DMID,
+		Data:        notificationData(map[string]string{"emoji": emoji}),
+	})
+}
+
 func (s *reactionService) verifyAccess(userID uuid.UUID, message *models.Message) error {
 	if message.ChannelID != nil {
 		// Check channel access
@@ -132,7 +175,7 @@ func (s *reactionService) verifyAccess(userID uuid.UUID, message *models.Message
 			if err != nil {
 				return err
 			}
-			if wsMember == nil {
+			if !canReadPublicChannel(wsMember) {
 				return ErrUnauthorized
 			}
 		}
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/authz"
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+	"github.com/DoDuy2004/slack-clone-backend/internal/models/dto"
+	"github.com/DoDuy2004/slack-clone-backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrRoleNotFound      = errors.New("role not found")
+	ErrRoleExists        = errors.New("a role with this name already exists")
+	ErrInvalidPermission = errors.New("unknown permission or one a custom role cannot grant")
+)
+
+// RoleService manages a workspace's custom roles. Everything except listing
+// takes the right to manage roles, which custom roles can never grant.
+type RoleService interface {
+	ListRoles(userID, workspaceID uuid.UUID) (*dto.WorkspaceRolesResponse, error)
+	CreateRole(userID, workspaceID uuid.UUID, req *dto.CreateRoleRequest) (*models.WorkspaceRole, error)
+	UpdateRole(userID, workspaceID, roleID uuid.UUID, req *dto.UpdateRoleRequest) (*models.WorkspaceRole, error)
+	// DeleteRole removes the role; its holders keep their built-in role
+	DeleteRole(userID, workspaceID, roleID uuid.UUID) error
+	// AssignRole gives a member a custom role, or takes it away when roleID
+	// is nil
+	AssignRole(userID, workspaceID, memberID uuid.UUID, roleID *uuid.UUID) (*models.WorkspaceMember, error)
+}
+
+type roleService struct {
+	roleRepo      repository.RoleRepository
+	workspaceRepo repository.WorkspaceRepository
+	auditRepo     repository.AuditRepository
+	authz         *authz.Authorizer
+}
+
+func NewRoleService(roleRepo repository.RoleRepository, workspaceRepo repository.WorkspaceRepository, auditRepo repository.AuditRepository, authorizer *authz.Authorizer) RoleService {
+	return &roleService{
+		roleRepo:      roleRepo,
+		workspaceRepo: workspaceRepo,
+		auditRepo:     auditRepo,
+		authz:         authorizer,
+	}
+}
+
+func (s *roleService) ListRoles(userID, workspaceID uuid.UUID) (*dto.WorkspaceRolesResponse, error) {
+	member, err := s.workspaceRepo.GetMember(workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, ErrUnauthorized
+	}
+
+	custom, err := s.roleRepo.ListByWorkspace(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if custom == nil {
+		custom = []*models.WorkspaceRole{}
+	}
+
+	response := &dto.WorkspaceRolesResponse{Custom: custom}
+	for _, name := range authz.BuiltinRoles() {
+		response.Builtin = append(response.Builtin, &dto.BuiltinRoleResponse{
+			Name:        name,
+			Permissions: permissionNames(authz.BuiltinPermissions(name)),
+		})
+	}
+	for _, p := range authz.All {
+		if authz.Assignable(p) {
+			response.Permissions = append(response.Permissions, string(p))
+		}
+	}
+	return response, nil
+}
+
+func (s *roleService) CreateRole(userID, workspaceID uuid.UUID, req *dto.CreateRoleRequest) (*models.WorkspaceRole, error) {
+	if err := s.verifyManager(userID, workspaceID); err != nil {
+		return nil, err
+	}
+	permissions, err := validatePermissions(req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkNameFree(workspaceID, req.Name, nil); err != nil {
+		return nil, err
+	}
+
+	role := &models.WorkspaceRole{
+		ID:          uuid.New(),
+		WorkspaceID: workspaceID,
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+		Permissions: permissions,
+	}
+	if err := s.roleRepo.Create(role); err != nil {
+		return nil, err
+	}
+	recordAudit(s.auditRepo, workspaceID, userID, AuditActionRoleCreated, &role.ID, map[string]string{
+		"name":        role.Name,
+		"permissions": strings.Join(role.Permissions, ","),
+	})
+
+	return role, nil
+}
+
+func (s *roleService) UpdateRole(userID, workspaceID, roleID uuid.UUID, req *dto.UpdateRoleRequest) (*models.WorkspaceRole, error) {
+	if err := s.verifyManager(userID, workspaceID); err != nil {
+		return nil, err
+	}
+	role, err := s.findRole(workspaceID, roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		if err := s.checkNameFree(workspaceID, *req.Name, &roleID); err != nil {
+			return nil, err
+		}
+		role.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		role.Description = req.Description
+	}
+	if req.Permissions != nil {
+		permissions, err := validatePermissions(req.Permissions)
+		if err != nil {
+			return nil, err
+		}
+		role.Permissions = permissions
+	}
+
+	if err := s.roleRepo.Update(role); err != nil {
+		return nil, err
+	}
+	recordAudit(s.auditRepo, workspaceID, userID, AuditActionRoleUpdated, &role.ID, map[string]string{
+		"name":        role.Name,
+		"permissions": strings.Join(role.Permissions, ","),
+	})
+
+	return role, nil
+}
+
+func (s *roleService) DeleteRole(userID, workspaceID, roleID uuid.UUID) error {
+	if err := s.verifyManager(userID, workspaceID); err != nil {
+		return err
+	}
+	role, err := s.findRole(workspaceID, roleID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.roleRepo.Delete(roleID); err != nil {
+		return err
+	}
+	recordAudit(s.auditRepo, workspaceID, userID, AuditActionRoleDeleted, &roleID, map[string]string{"name": role.Name})
+	return nil
+}
+
+func (s *roleService) AssignRole(userID, workspaceID, memberID uuid.UUID, roleID *uuid.UUID) (*models.WorkspaceMember, error) {
+	if err := s.verifyManager(userID, workspaceID); err != nil {
+		return nil, err
+	}
+
+	member, err := s.workspaceRepo.GetMember(workspaceID, memberID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, ErrMemberNotFound
+	}
+
+	metadata := map[string]string{}
+	if roleID != nil {
+		role, err := s.findRole(workspaceID, *roleID)
+		if err != nil {
+			return nil, err
+		}
+		metadata["role_name"] = role.Name
+	}
+
+	if err := s.roleRepo.AssignToMember(workspaceID, memberID, roleID); err != nil {
+		return nil, err
+	}
+	recordAudit(s.auditRepo, workspaceID, userID, AuditActionRoleAssigned, &memberID, metadata)
+
+	member.CustomRoleID = roleID
+	return member, nil
+}
+
+func (s *roleService) verifyManager(userID, workspaceID uuid.UUID) error {
+	allowed, err := s.authz.Can(workspaceID, userID, authz.MemberManageRoles)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrUnauthorized
+	}
+	return nil
+}
+
+// findRole loads a custom role of the workspace; other workspaces' roles are
+// reported as not found.
+func (s *roleService) findRole(workspaceID, roleID uuid.UUID) (*models.WorkspaceRole, error) {
+	role, err := s.roleRepo.FindByID(roleID)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil || role.WorkspaceID != workspaceID {
+		return nil, ErrRoleNotFound
+	}
+	return role, nil
+}
+
+// checkNameFree rejects names used by a built-in role or by another custom
+// role in the workspace.
+func (s *roleService) checkNameFree(workspaceID uuid.UUID, name string, exceptID *uuid.UUID) error {
+	name = strings.TrimSpace(name)
+	for _, builtin := range authz.BuiltinRoles() {
+		if strings.EqualFold(name, builtin) {
+			return ErrRoleExists
+		}
+	}
+
+	roles, err := s.roleRepo.ListByWorkspace(workspaceID)
+	if err != nil {
+		return err
+	}
+	for _, role := range roles {
+		if strings.EqualFold(role.Name, name) && (exceptID == nil || role.ID != *exceptID) {
+			return ErrRoleExists
+		}
+	}
+	return nil
+}
+
+// validatePermissions checks that every permission can be granted by a
+// custom role and drops duplicates.
+func validatePermissions(permissions []string) ([]string, error) {
+	seen := make(map[string]bool, len(permissions))
+	valid := make([]string, 0, len(permissions))
+	for _, p := range permissions {
+		if !authz.Assignable(authz.Permission(p)) {
+			return nil, ErrInvalidPermission
+		}
+		if !seen[p] {
+			seen[p] = true
+			valid = append(valid, p)
+		}
+	}
+	return valid, nil
+}
+
+func permissionNames(permissions []authz.Permission) []string {
+	names := make([]string, len(permissions))
+	for i, p := range permissions {
+		names[i] = string(p)
+	}
+	return names
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/authz"
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+	"github.com/DoDuy2004/slack-clone-backend/internal/models/dto"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatePermissions(t *testing.T) {
+	permissions, err := validatePermissions([]string{"channel.archive", "invite.create", "channel.archive"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"channel.archive", "invite.create"}, permissions)
+
+	_, err = validatePermissions([]string{string(authz.MemberManageRoles)})
+	assert.Equal(t, ErrInvalidPermission, err)
+
+	_, err = validatePermissions([]string{"channel.fly"})
+	assert.Equal(t, ErrInvalidPermission, err)
+}
+
+func TestCreateRoleNeedsRoleManagement(t *testing.T) {
+	mockRepo := new(MockWorkspaceRepository)
+	svc := NewRoleService(nil, mockRepo, nil, authz.New(mockRepo, nil))
+
+	wsID, adminID := uuid.New(), uuid.New()
+	mockRepo.On("GetMember", wsID, adminID).Return(&models.WorkspaceMember{UserID: adminID, Role: "admin"}, nil)
+
+	_, err := svc.CreateRole(adminID, wsID, &dto.CreateRoleRequest{Name: "Moderator", Permissions: []string{"message.delete_any"}})
+	assert.Equal(t, ErrUnauthorized, err)
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+	"github.com/DoDuy2004/slack-clone-backend/internal/models/dto"
+	"github.com/DoDuy2004/slack-clone-backend/internal/repository"
+	"github.com/DoDuy2004/slack-clone-backend/internal/websocket"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrSavedItemNotFound = errors.New("saved item not found")
+)
+
+const reminderBatchSize = 100
+
+type SavedItemService interface {
+	SaveMessage(userID, messageID uuid.UUID, remindAt *time.Time) (*models.SavedItem, error)
+	UnsaveMessage(userID, messageID uuid.UUID) error
+	UpdateSavedItem(userID, itemID uuid.UUID, req *dto.UpdateSavedItemRequest) (*models.SavedItem, error)
+	ListSavedItems(userID, workspaceID uuid.UUID, status string, limit, offset int) ([]*models.SavedItem, error)
+	DeliverDueReminders() error
+	RunReminders(interval time.Duration)
+}
+
+type savedItemService struct {
+	savedItemRepo  repository.SavedItemRepository
+	workspaceRepo  repository.WorkspaceRepository
+	messageService MessageService
+	hub            *websocket.Hub
+}
+
+func NewSavedItemService(
+	savedItemRepo repository.SavedItemRepository,
+	workspaceRepo repository.WorkspaceRepository,
+	messageService MessageService,
+	hub *websocket.Hub,
+) SavedItemService {
+	return &savedItemService{
+		savedItemRepo:  savedItemRepo,
+		workspaceRepo:  workspaceRepo,
+		messageService: messageService,
+		hub:            hub,
+	}
+}
+
+func (s *savedItemService) SaveMessage(userID, messageID uuid.UUID, remindAt *time.Time) (*models.SavedItem, error) {
+	// 1. Verify the user can see the message
+	message, err := s.messageService.GetMessage(userID, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	workspaceID, err := s.messageService.MessageWorkspaceID(message)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Save (or refresh the reminder of an existing item)
+	item := &models.SavedItem{
+		ID:          uuid.New(),
+		UserID:      userID,
+		MessageID:   messageID,
+		WorkspaceID: workspaceID,
+		Status:      "in_progress",
+		RemindAt:    remindAt,
+	}
+	if err := s.savedItemRepo.Upsert(item); err != nil {
+		return nil, err
+	}
+
+	item.Message = message
+	return item, nil
+}
+
+func (s *savedItemService) UnsaveMessage(userID, messageID uuid.UUID) error {
+	item, err := s.savedItemRepo.FindByUserAndMessage(userID, messageID)
+	if err != nil {
+		return err
+	}
+	if item == nil {
+		return ErrSavedItemNotFound
+	}
+
+	return s.savedItemRepo.Delete(item.ID)
+}
+
+func (s *savedItemService) UpdateSavedItem(userID, itemID uuid.UUID, req *dto.UpdateSavedItemRequest) (*models.SavedItem, error) {
+	item, err := s.savedItemRepo.FindByID(itemID)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil || item.UserID != userID {
+		return nil, ErrSavedItemNotFound
+	}
+
+	// The user may have lost access to the message since saving it
+	message, err := s.messageService.GetMessage(userID, item.MessageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Status != nil && *req.Status != item.Status {
+		item.Status = *req.Status
+		if item.Status == "completed" {
+			now := time.Now()
+			item.CompletedAt = &now
+		} else {
+			item.CompletedAt = nil
+		}
+	}
+	if req.ClearReminder {
+		item.RemindAt = nil
+		item.RemindedAt = nil
+	} else if req.RemindAt != nil {
+		item.RemindAt = req.RemindAt
+		item.RemindedAt = nil
+	}
+
+	if err := s.savedItemRepo.Update(item); err != nil {
+		return nil, err
+	}
+
+	item.Message = message
+	return item, nil
+}
+
+func (s *savedItemService) ListSavedItems(userID, workspaceID uuid.UUID, status string, limit, offset int) ([]*models.SavedItem, error) {
+	// Verify workspace membership
+	member, err := s.workspaceRepo.GetMember(workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, ErrUnauthorized
+	}
+
+	return s.savedItemRepo.ListByUser(workspaceID, userID, status, canReadPublicChannel(member), limit, offset)
+}
+
+func (s *savedItemService) DeliverDueReminders() error {
+	items, err := s.savedItemRepo.ListDueReminders(reminderBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		message, err := s.messageService.GetMessage(item.UserID, item.MessageID)
+		if err == ErrUnauthorized || err == ErrMessageNotFound || err == ErrChannelNotFound {
+			// Access lost or message deleted; the saved item goes away with it
+			if err := s.savedItemRepo.Delete(item.ID); err != nil {
+				log.Printf("error removing saved item %s: %v", item.ID, err)
+			}
+			continue
+		}
+		if err != nil {
+			log.Printf("error loading saved message %s: %v", item.MessageID, err)
+			continue
+		}
+
+		if err := s.savedItemRepo.MarkReminded(item.ID); err != nil {
+			log.Printf("error marking saved item %s as reminded: %v", item.ID, err)
+			continue
+		}
+
+		item.Message = message
+		payload, err := json.Marshal(item)
+		if err != nil {
+			log.Printf("error marshaling reminder payload: %v", err)
+			continue
+		}
+
+		userID := item.UserID
+		s.hub.Broadcast(&websocket.WSMessage{
+			Type:    websocket.EventSavedReminder,
+			Payload: payload,
+			UserID:  &userID,
+		})
+	}
+
+	return nil
+}
+
+// RunReminders polls for due reminders until the process exits.
+func (s *savedItemService) RunReminders(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := s.DeliverDueReminders(); err != nil {
+			log.Printf("error delivering saved item reminders: %v", err)
+		}
+	}
+}
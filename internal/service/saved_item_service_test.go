@@ -0,0 +1,216 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/authz"
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+	"github.com/DoDuy2004/slack-clone-backend/internal/models/dto"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockSavedItemRepository is a mock implementation of SavedItemRepository
+type MockSavedItemRepository struct {
+	mock.Mock
+}
+
+func (m *MockSavedItemRepository) Upsert(item *models.SavedItem) error {
+	args := m.Called(item)
+	return args.Error(0)
+}
+
+func (m *MockSavedItemRepository) FindByID(id uuid.UUID) (*models.SavedItem, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SavedItem), args.Error(1)
+}
+
+func (m *MockSavedItemRepository) FindByUserAndMessage(userID, messageID uuid.UUID) (*models.SavedItem, error) {
+	args := m.Called(userID, messageID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SavedItem), args.Error(1)
+}
+
+func (m *MockSavedItemRepository) ListByUser(workspaceID, userID uuid.UUID, status string, includePublic bool, limit, offset int) ([]*models.SavedItem, error) {
+	args := m.Called(workspaceID, userID, status, includePublic, limit, offset)
+	return args.Get(0).([]*models.SavedItem), args.Error(1)
+}
+
+func (m *MockSavedItemRepository) Update(item *models.SavedItem) error {
+	args := m.Called(item)
+	return args.Error(0)
+}
+
+func (m *MockSavedItemRepository) Delete(id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockSavedItemRepository) ListDueReminders(limit int) ([]*models.SavedItem, error) {
+	args := m.Called(limit)
+	return args.Get(0).([]*models.SavedItem), args.Error(1)
+}
+
+func (m *MockSavedItemRepository) MarkReminded(id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+// MockMessageService is a mock implementation of MessageService
+type MockMessageService struct {
+	mock.Mock
+}
+
+func (m *MockMessageService) GetChannelMessages(userID uuid.UUID, channelID uuid.UUID, limit, offset int) ([]*models.Message, error) {
+	args := m.Called(userID, channelID, limit, offset)
+	return args.Get(0).([]*models.Message), args.Error(1)
+}
+
+func (m *MockMessageService) GetDMMessages(userID uuid.UUID, dmID uuid.UUID, limit, offset int) ([]*models.Message, error) {
+	args := m.Called(userID, dmID, limit, offset)
+	return args.Get(0).([]*models.Message), args.Error(1)
+}
+
+func (m *MockMessageService) GetThreads(userID uuid.UUID, parentID uuid.UUID) ([]*models.Message, error) {
+	args := m.Called(userID, parentID)
+	return args.Get(0).([]*models.Message), args.Error(1)
+}
+
+func (m *MockMessageService) UpdateMessage(userID uuid.UUID, messageID uuid.UUID, req *dto.UpdateMessageRequest) (*models.Message, error) {
+	args := m.Called(userID, messageID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Message), args.Error(1)
+}
+
+func (m *MockMessageService) DeleteMessage(userID uuid.UUID, messageID uuid.UUID) error {
+	args := m.Called(userID, messageID)
+	return args.Error(0)
+}
+
+func (m *MockMessageService) SendChannelMessage(userID, channelID uuid.UUID, content string, parentID *uuid.UUID, attachmentIDs []uuid.UUID, alsoSendToChannel bool, urgent bool) (*models.Message, error) {
+	args := m.Called(userID, channelID, content, parentID, attachmentIDs, alsoSendToChannel, urgent)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Message), args.Error(1)
+}
+
+func (m *MockMessageService) SendDMMessage(userID, dmID uuid.UUID, content string, parentID *uuid.UUID, attachmentIDs []uuid.UUID, alsoSendToChannel bool, urgent bool) (*models.Message, error) {
+	args := m.Called(userID, dmID, content, parentID, attachmentIDs, alsoSendToChannel, urgent)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Message), args.Error(1)
+}
+
+func (m *MockMessageService) GetMessage(userID uuid.UUID, messageID uuid.UUID) (*models.Message, error) {
+	args := m.Called(userID, messageID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Message), args.Error(1)
+}
+
+func (m *MockMessageService) VerifyMessageAccess(userID uuid.UUID, message *models.Message) error {
+	args := m.Called(userID, message)
+	return args.Error(0)
+}
+
+func (m *MockMessageService) MessageWorkspaceID(message *models.Message) (uuid.UUID, error) {
+	args := m.Called(message)
+	return args.Get(0).(uuid.UUID), args.Error(1)
+}
+
+func TestSaveMessage(t *testing.T) {
+	mockItems := new(MockSavedItemRepository)
+	mockMessages := new(MockMessageService)
+	svc := NewSavedItemService(mockItems, nil, mockMessages, nil)
+
+	userID, wsID := uuid.New(), uuid.New()
+	message := &models.Message{ID: uuid.New()}
+	hidden := uuid.New()
+	mockMessages.On("GetMessage", userID, message.ID).Return(message, nil)
+	mockMessages.On("GetMessage", userID, hidden).Return(nil, ErrUnauthorized)
+	mockMessages.On("MessageWorkspaceID", message).Return(wsID, nil)
+	mockItems.On("Upsert", mock.Anything).Return(nil)
+
+	_, err := svc.SaveMessage(userID, hidden, nil)
+	assert.Equal(t, ErrUnauthorized, err)
+
+	item, err := svc.SaveMessage(userID, message.ID, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, wsID, item.WorkspaceID)
+	assert.Equal(t, "in_progress", item.Status)
+	assert.Equal(t, message, item.Message)
+	mockItems.AssertNumberOfCalls(t, "Upsert", 1)
+}
+
+func TestUpdateSavedItem(t *testing.T) {
+	mockItems := new(MockSavedItemRepository)
+	mockMessages := new(MockMessageService)
+	svc := NewSavedItemService(mockItems, nil, mockMessages, nil)
+
+	userID := uuid.New()
+	remindAt := time.Now().Add(time.Hour)
+	item := &models.SavedItem{ID: uuid.New(), UserID: userID, MessageID: uuid.New(), Status: "in_progress", RemindAt: &remindAt}
+	lost := &models.SavedItem{ID: uuid.New(), UserID: userID, MessageID: uuid.New(), Status: "in_progress"}
+	message := &models.Message{ID: item.MessageID}
+	mockItems.On("FindByID", item.ID).Return(item, nil)
+	mockItems.On("FindByID", lost.ID).Return(lost, nil)
+	mockItems.On("Update", item).Return(nil)
+	mockMessages.On("GetMessage", userID, item.MessageID).Return(message, nil)
+	mockMessages.On("GetMessage", userID, lost.MessageID).Return(nil, ErrUnauthorized)
+
+	completed := "completed"
+
+	// Someone else's item doesn't exist for this user
+	_, err := svc.UpdateSavedItem(uuid.New(), item.ID, &dto.UpdateSavedItemRequest{Status: &completed})
+	assert.Equal(t, ErrSavedItemNotFound, err)
+
+	// Nor can items in conversations the user has left be changed
+	_, err = svc.UpdateSavedItem(userID, lost.ID, &dto.UpdateSavedItemRequest{Status: &completed})
+	assert.Equal(t, ErrUnauthorized, err)
+	mockItems.AssertNotCalled(t, "Update", lost)
+
+	updated, err := svc.UpdateSavedItem(userID, item.ID, &dto.UpdateSavedItemRequest{Status: &completed, ClearReminder: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "completed", updated.Status)
+	assert.NotNil(t, updated.CompletedAt)
+	assert.Nil(t, updated.RemindAt)
+	assert.Equal(t, message, updated.Message)
+}
+
+func TestListSavedItems(t *testing.T) {
+	mockItems := new(MockSavedItemRepository)
+	mockWS := new(MockWorkspaceRepository)
+	svc := NewSavedItemService(mockItems, mockWS, nil, nil)
+
+	wsID := uuid.New()
+	member := &models.WorkspaceMember{WorkspaceID: wsID, UserID: uuid.New(), Role: "member"}
+	guest := &models.WorkspaceMember{WorkspaceID: wsID, UserID: uuid.New(), Role: authz.RoleMultiChannelGuest}
+	outsider := uuid.New()
+	mockWS.On("GetMember", wsID, member.UserID).Return(member, nil)
+	mockWS.On("GetMember", wsID, guest.UserID).Return(guest, nil)
+	mockWS.On("GetMember", wsID, outsider).Return(nil, nil)
+	mockItems.On("ListByUser", wsID, member.UserID, "", true, 20, 0).Return([]*models.SavedItem{}, nil)
+	mockItems.On("ListByUser", wsID, guest.UserID, "", false, 20, 0).Return([]*models.SavedItem{}, nil)
+
+	_, err := svc.ListSavedItems(outsider, wsID, "", 20, 0)
+	assert.Equal(t, ErrUnauthorized, err)
+
+	// Guests only see items from conversations they are in
+	_, err = svc.ListSavedItems(member.UserID, wsID, "", 20, 0)
+	assert.NoError(t, err)
+	_, err = svc.ListSavedItems(guest.UserID, wsID, "", 20, 0)
+	assert.NoError(t, err)
+	mockItems.AssertExpectations(t)
+}
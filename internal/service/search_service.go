@@ -1,6 +1,7 @@
 package service
 
 import (
+	"github.com/DoDuy2004/slack-clone-backend/internal/authz"
 	"github.com/DoDuy2004/slack-clone-backend/internal/models"
 	"github.com/DoDuy2004/slack-clone-backend/internal/repository"
 	"github.com/google/uuid"
@@ -31,7 +32,11 @@ func (s *searchService) SearchMessages(userID, workspaceID uuid.UUID, query stri
 	if member == nil {
 		return nil, ErrUnauthorized
 	}
+	// Workspace-wide search would reach past a guest's channels
+	if authz.IsGuest(member.Role) {
+		return nil, ErrGuestRestricted
+	}
 
-	// 2. Perform search
-	return s.messageRepo.Search(workspaceID, query, limit, offset)
+	// 2. Perform search, limited to conversations the user can see
+	return s.messageRepo.Search(workspaceID, userID, query, limit, offset)
 }
@@ -0,0 +1,311 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+	"github.com/DoDuy2004/slack-clone-backend/internal/models/dto"
+	"github.com/DoDuy2004/slack-clone-backend/internal/repository"
+	"github.com/DoDuy2004/slack-clone-backend/internal/websocket"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrSidebarSectionNotFound = errors.New("sidebar section not found")
+	ErrSidebarItemNotFound    = errors.New("sidebar item not found")
+	ErrStarNotFound           = errors.New("conversation is not starred")
+	ErrInvalidConversation    = errors.New("set exactly one of channel_id and dm_id")
+)
+
+// SidebarService manages each user's custom sidebar. Every change returns the
+// whole sidebar and sends it to the user's sockets so other devices stay in
+// sync.
+type SidebarService interface {
+	GetSidebar(userID, workspaceID uuid.UUID) (*dto.SidebarResponse, error)
+	CreateSection(userID, workspaceID uuid.UUID, req *dto.CreateSidebarSectionRequest) (*dto.SidebarResponse, error)
+	UpdateSection(userID, sectionID uuid.UUID, req *dto.UpdateSidebarSectionRequest) (*dto.SidebarResponse, error)
+	// DeleteSection removes a section; its conversations go back to the
+	// default sidebar groups
+	DeleteSection(userID, sectionID uuid.UUID) (*dto.SidebarResponse, error)
+	ReorderSections(userID, workspaceID uuid.UUID, req *dto.ReorderSidebarSectionsRequest) (*dto.SidebarResponse, error)
+	PlaceItem(userID, sectionID uuid.UUID, req *dto.SidebarItemRequest) (*dto.SidebarResponse, error)
+	RemoveItem(userID, sectionID, itemID uuid.UUID) (*dto.SidebarResponse, error)
+	Star(userID, workspaceID uuid.UUID, req *dto.StarConversationRequest) (*dto.SidebarResponse, error)
+	Unstar(userID, workspaceID uuid.UUID, req *dto.StarConversationRequest) (*dto.SidebarResponse, error)
+}
+
+type sidebarService struct {
+	sidebarRepo   repository.SidebarRepository
+	channelRepo   repository.ChannelRepository
+	dmRepo        repository.DMRepository
+	workspaceRepo repository.WorkspaceRepository
+	hub           *websocket.Hub
+}
+
+func NewSidebarService(sidebarRepo repository.SidebarRepository, channelRepo repository.ChannelRepository, dmRepo repository.DMRepository, workspaceRepo repository.WorkspaceRepository, hub *websocket.Hub) SidebarService {
+	return &sidebarService{
+		sidebarRepo:   sidebarRepo,
+		channelRepo:   channelRepo,
+		dmRepo:        dmRepo,
+		workspaceRepo: workspaceRepo,
+		hub:           hub,
+	}
+}
+
+func (s *sidebarService) GetSidebar(userID, workspaceID uuid.UUID) (*dto.SidebarResponse, error) {
+	if err := s.verifyWorkspaceMember(userID, workspaceID); err != nil {
+		return nil, err
+	}
+	return s.loadSidebar(userID, workspaceID)
+}
+
+func (s *sidebarService) CreateSection(userID, workspaceID uuid.UUID, req *dto.CreateSidebarSectionRequest) (*dto.SidebarResponse, error) {
+	if err := s.verifyWorkspaceMember(userID, workspaceID); err != nil {
+		return nil, err
+	}
+
+	section := &models.SidebarSection{
+		ID:          uuid.New(),
+		UserID:      userID,
+		WorkspaceID: workspaceID,
+		Name:        req.Name,
+	}
+	if err := s.sidebarRepo.CreateSection(section); err != nil {
+		return nil, err
+	}
+
+	return s.sidebarChanged(userID, workspaceID)
+}
+
+func (s *sidebarService) UpdateSection(userID, sectionID uuid.UUID, req *dto.UpdateSidebarSectionRequest) (*dto.SidebarResponse, error) {
+	section, err := s.findSection(userID, sectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		section.Name = *req.Name
+	}
+	if req.Collapsed != nil {
+		section.Collapsed = *req.Collapsed
+	}
+	if err := s.sidebarRepo.UpdateSection(section); err != nil {
+		return nil, err
+	}
+
+	return s.sidebarChanged(userID, section.WorkspaceID)
+}
+
+func (s *sidebarService) DeleteSection(userID, sectionID uuid.UUID) (*dto.SidebarResponse, error) {
+	section, err := s.findSection(userID, sectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.sidebarRepo.DeleteSection(sectionID); err != nil {
+		return nil, err
+	}
+
+	return s.sidebarChanged(userID, section.WorkspaceID)
+}
+
+func (s *sidebarService) ReorderSections(userID, workspaceID uuid.UUID, req *dto.ReorderSidebarSectionsRequest) (*dto.SidebarResponse, error) {
+	if err := s.verifyWorkspaceMember(userID, workspaceID); err != nil {
+		return nil, err
+	}
+
+	// Sections that aren't the user's are ignored by the repository
+	if err := s.sidebarRepo.ReorderSections(userID, workspaceID, req.SectionIDs); err != nil {
+		return nil, err
+	}
+
+	return s.sidebarChanged(userID, workspaceID)
+}
+
+func (s *sidebarService) PlaceItem(userID, sectionID uuid.UUID, req *dto.SidebarItemRequest) (*dto.SidebarResponse, error) {
+	section, err := s.findSection(userID, sectionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.verifyConversation(userID, section.WorkspaceID, req.ChannelID, req.DMID); err != nil {
+		return nil, err
+	}
+
+	item := &models.SidebarItem{
+		ID:        uuid.New(),
+		SectionID: sectionID,
+		UserID:    userID,
+		ChannelID: req.ChannelID,
+		DMID:      req.DMID,
+	}
+	if err := s.sidebarRepo.PlaceItem(item, req.Position); err != nil {
+		return nil, err
+	}
+
+	return s.sidebarChanged(userID, section.WorkspaceID)
+}
+
+func (s *sidebarService) RemoveItem(userID, sectionID, itemID uuid.UUID) (*dto.SidebarResponse, error) {
+	section, err := s.findSection(userID, sectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	deleted, err := s.sidebarRepo.DeleteItem(sectionID, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if !deleted {
+		return nil, ErrSidebarItemNotFound
+	}
+
+	return s.sidebarChanged(userID, section.WorkspaceID)
+}
+
+func (s *sidebarService) Star(userID, workspaceID uuid.UUID, req *dto.StarConversationRequest) (*dto.SidebarResponse, error) {
+	if err := s.verifyWorkspaceMember(userID, workspaceID); err != nil {
+		return nil, err
+	}
+	if err := s.verifyConversation(userID, workspaceID, req.ChannelID, req.DMID); err != nil {
+		return nil, err
+	}
+
+	star := &models.StarredConversation{
+		ID:          uuid.New(),
+		UserID:      userID,
+		WorkspaceID: workspaceID,
+		ChannelID:   req.ChannelID,
+		DMID:        req.DMID,
+	}
+	if err := s.sidebarRepo.Star(star); err != nil {
+		return nil, err
+	}
+
+	return s.sidebarChanged(userID, workspaceID)
+}
+
+func (s *sidebarService) Unstar(userID, workspaceID uuid.UUID, req *dto.StarConversationRequest) (*dto.SidebarResponse, error) {
+	if (req.ChannelID == nil) == (req.DMID == nil) {
+		return nil, ErrInvalidConversation
+	}
+
+	deleted, err := s.sidebarRepo.Unstar(userID, req.ChannelID, req.DMID)
+	if err != nil {
+		return nil, err
+	}
+	if !deleted {
+		return nil, ErrStarNotFound
+	}
+
+	return s.sidebarChanged(userID, workspaceID)
+}
+
+// sidebarChanged reloads the sidebar and sends it to all of the user's sockets.
+func (s *sidebarService) sidebarChanged(userID, workspaceID uuid.UUID) (*dto.SidebarResponse, error) {
+	sidebar, err := s.loadSidebar(userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, _ := json.Marshal(sidebar)
+	s.hub.Broadcast(&websocket.WSMessage{
+		Type:    websocket.EventSidebarUpdated,
+		Payload: payload,
+		UserID:  &userID,
+	})
+	return sidebar, nil
+}
+
+func (s *sidebarService) loadSidebar(userID, workspaceID uuid.UUID) (*dto.SidebarResponse, error) {
+	sections, err := s.sidebarRepo.ListSections(userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if sections == nil {
+		sections = []*models.SidebarSection{}
+	}
+
+	starred, err := s.sidebarRepo.ListStarred(userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.SidebarResponse{
+		WorkspaceID: workspaceID,
+		Sections:    sections,
+		Starred:     starred,
+	}, nil
+}
+
+// findSection loads one of the user's sections. Other users' sections are
+// reported as not found.
+func (s *sidebarService) findSection(userID, sectionID uuid.UUID) (*models.SidebarSection, error) {
+	section, err := s.sidebarRepo.FindSection(sectionID)
+	if err != nil {
+		return nil, err
+	}
+	if section == nil || section.UserID != userID {
+		return nil, ErrSidebarSectionNotFound
+	}
+	return section, nil
+}
+
+func (s *sidebarService) verifyWorkspaceMember(userID, workspaceID uuid.UUID) error {
+	member, err := s.workspaceRepo.GetMember(workspaceID, userID)
+	if err != nil {
+		return err
+	}
+	if member == nil {
+		return ErrUnauthorized
+	}
+	return nil
+}
+
+// verifyConversation checks that exactly one of channelID and dmID is set and
+// that it is a conversation in the workspace the user can see.
+func (s *sidebarService) verifyConversation(userID, workspaceID uuid.UUID, channelID, dmID *uuid.UUID) error {
+	if (channelID == nil) == (dmID == nil) {
+		return ErrInvalidConversation
+	}
+
+	if channelID != nil {
+		channel, err := s.channelRepo.FindByID(*channelID)
+		if err != nil {
+			return err
+		}
+		if channel == nil || channel.WorkspaceID != workspaceID {
+			return ErrChannelNotFound
+		}
+		member, err := s.workspaceRepo.GetMember(workspaceID, userID)
+		if err != nil {
+			return err
+		}
+		if channel.IsPrivate || !canReadPublicChannel(member) {
+			isMember, err := s.channelRepo.IsMember(channel.ID, userID)
+			if err != nil {
+				return err
+			}
+			if !isMember {
+				return ErrChannelNotFound
+			}
+		}
+		return nil
+	}
+
+	dm, err := s.dmRepo.GetByID(*dmID)
+	if err != nil {
+		return err
+	}
+	if dm == nil || dm.WorkspaceID != workspaceID {
+		return ErrDMNotFound
+	}
+	isParticipant, err := s.dmRepo.IsParticipant(dm.ID, userID)
+	if err != nil {
+		return err
+	}
+	if !isParticipant {
+		return ErrDMNotFound
+	}
+	return nil
+}
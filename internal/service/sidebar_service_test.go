@@ -0,0 +1,104 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+	"github.com/DoDuy2004/slack-clone-backend/internal/models/dto"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockSidebarRepository is a mock implementation of SidebarRepository
+type MockSidebarRepository struct {
+	mock.Mock
+}
+
+func (m *MockSidebarRepository) CreateSection(section *models.SidebarSection) error {
+	args := m.Called(section)
+	return args.Error(0)
+}
+
+func (m *MockSidebarRepository) FindSection(id uuid.UUID) (*models.SidebarSection, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SidebarSection), args.Error(1)
+}
+
+func (m *MockSidebarRepository) UpdateSection(section *models.SidebarSection) error {
+	args := m.Called(section)
+	return args.Error(0)
+}
+
+func (m *MockSidebarRepository) DeleteSection(id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockSidebarRepository) ReorderSections(userID, workspaceID uuid.UUID, sectionIDs []uuid.UUID) error {
+	args := m.Called(userID, workspaceID, sectionIDs)
+	return args.Error(0)
+}
+
+func (m *MockSidebarRepository) ListSections(userID, workspaceID uuid.UUID) ([]*models.SidebarSection, error) {
+	args := m.Called(userID, workspaceID)
+	return args.Get(0).([]*models.SidebarSection), args.Error(1)
+}
+
+func (m *MockSidebarRepository) PlaceItem(item *models.SidebarItem, position *int) error {
+	args := m.Called(item, position)
+	return args.Error(0)
+}
+
+func (m *MockSidebarRepository) DeleteItem(sectionID, itemID uuid.UUID) (bool, error) {
+	args := m.Called(sectionID, itemID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockSidebarRepository) Star(star *models.StarredConversation) error {
+	args := m.Called(star)
+	return args.Error(0)
+}
+
+func (m *MockSidebarRepository) Unstar(userID uuid.UUID, channelID, dmID *uuid.UUID) (bool, error) {
+	args := m.Called(userID, channelID, dmID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockSidebarRepository) ListStarred(userID, workspaceID uuid.UUID) ([]*models.StarredConversation, error) {
+	args := m.Called(userID, workspaceID)
+	return args.Get(0).([]*models.StarredConversation), args.Error(1)
+}
+
+func TestSidebarSectionOfAnotherUser(t *testing.T) {
+	mockRepo := new(MockSidebarRepository)
+	svc := NewSidebarService(mockRepo, nil, nil, nil, nil)
+
+	section := &models.SidebarSection{ID: uuid.New(), UserID: uuid.New(), WorkspaceID: uuid.New()}
+	mockRepo.On("FindSection", section.ID).Return(section, nil)
+
+	collapsed := true
+	_, err := svc.UpdateSection(uuid.New(), section.ID, &dto.UpdateSidebarSectionRequest{Collapsed: &collapsed})
+	assert.Equal(t, ErrSidebarSectionNotFound, err)
+	mockRepo.AssertNotCalled(t, "UpdateSection", mock.Anything)
+}
+
+func TestSidebarPlaceItemNeedsOneConversation(t *testing.T) {
+	mockRepo := new(MockSidebarRepository)
+	svc := NewSidebarService(mockRepo, nil, nil, nil, nil)
+
+	userID := uuid.New()
+	section := &models.SidebarSection{ID: uuid.New(), UserID: userID, WorkspaceID: uuid.New()}
+	mockRepo.On("FindSection", section.ID).Return(section, nil)
+
+	_, err := svc.PlaceItem(userID, section.ID, &dto.SidebarItemRequest{})
+	assert.Equal(t, ErrInvalidConversation, err)
+
+	channelID, dmID := uuid.New(), uuid.New()
+	_, err = svc.PlaceItem(userID, section.ID, &dto.SidebarItemRequest{ChannelID: &channelID, DMID: &dmID})
+	assert.Equal(t, ErrInvalidConversation, err)
+	mockRepo.AssertNotCalled(t, "PlaceItem", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,208 @@
+package service
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+	"github.com/DoDuy2004/slack-clone-backend/internal/models/dto"
+	"github.com/DoDuy2004/slack-clone-backend/internal/repository"
+	"github.com/DoDuy2004/slack-clone-backend/internal/websocket"
+	"github.com/DoDuy2004/slack-clone-backend/pkg/storage"
+	"github.com/google/uuid"
+)
+
+const (
+	teardownBatchSize      = 500
+	teardownWorkspaceBatch = 10
+)
+
+// TeardownService removes deleted workspaces once their restore window has
+// ended. Rows go in small batches so no table stays locked for long, and
+// progress is saved after every batch so an interrupted teardown picks up
+// where it left off.
+type TeardownService interface {
+	// GetDeletion reports a deleted workspace's restore window and teardown
+	// progress to its owner
+	GetDeletion(userID, workspaceID uuid.UUID) (*dto.WorkspaceDeletionResponse, error)
+	// TeardownDue tears down every workspace that is due. It returns the
+	// number of workspaces finished.
+	TeardownDue() (int, error)
+	RunTeardown(interval time.Duration)
+}
+
+type teardownService struct {
+	teardownRepo  repository.TeardownRepository
+	workspaceRepo repository.WorkspaceRepository
+	storage       storage.Storage
+	hub           *websocket.Hub
+}
+
+func NewTeardownService(teardownRepo repository.TeardownRepository, workspaceRepo repository.WorkspaceRepository, storage storage.Storage, hub *websocket.Hub) TeardownService {
+	return &teardownService{
+		teardownRepo:  teardownRepo,
+		workspaceRepo: workspaceRepo,
+		storage:       storage,
+		hub:           hub,
+	}
+}
+
+func (s *teardownService) GetDeletion(userID, workspaceID uuid.UUID) (*dto.WorkspaceDeletionResponse, error) {
+	teardown, err := s.teardownRepo.Find(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	ws, err := s.workspaceRepo.FindByID(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if ws == nil {
+		// Torn down; only the teardown record is left
+		if teardown == nil || teardown.OwnerID == nil || *teardown.OwnerID != userID {
+			return nil, ErrWorkspaceNotFound
+		}
+		return &dto.WorkspaceDeletionResponse{WorkspaceID: workspaceID, Teardown: teardown}, nil
+	}
+
+	if ws.OwnerID != userID {
+		return nil, ErrUnauthorized
+	}
+	if ws.DeletedAt == nil {
+		return nil, ErrNotDeleted
+	}
+	return &dto.WorkspaceDeletionResponse{
+		WorkspaceID: workspaceID,
+		DeletedAt:   ws.DeletedAt,
+		PurgeAfter:  ws.PurgeAfter,
+		Teardown:    teardown,
+	}, nil
+}
+
+func (s *teardownService) TeardownDue() (int, error) {
+	ids, err := s.teardownRepo.ListDue(teardownWorkspaceBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	finished := 0
+	for _, id := range ids {
+		if err := s.teardown(id); err != nil {
+			log.Printf("error tearing down workspace %s: %v", id, err)
+			continue
+		}
+		finished++
+	}
+	return finished, nil
+}
+
+// RunTeardown polls for workspaces to tear down until the process exits.
+func (s *teardownService) RunTeardown(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := s.TeardownDue(); err != nil {
+			log.Printf("error tearing down deleted workspaces: %v", err)
+		}
+	}
+}
+
+// teardown removes one workspace: its files first, then its rows step by
+// step, then the workspace itself. A failure is saved with the progress so
+// the owner can see it, and the next run carries on from there.
+func (s *teardownService) teardown(workspaceID uuid.UUID) error {
+	t, err := s.teardownRepo.Start(workspaceID)
+	if err != nil || t == nil {
+		return err
+	}
+	log.Printf("tearing down workspace %s (%s) from step %s", t.WorkspaceID, t.WorkspaceName, t.Step)
+
+	if err := s.deleteFiles(t); err != nil {
+		return s.fail(t, err)
+	}
+	for _, step := range repository.TeardownSteps {
+		if err := s.deleteRows(t, step); err != nil {
+			return s.fail(t, err)
+		}
+	}
+
+	if err := s.teardownRepo.Finish(t); err != nil {
+		return s.fail(t, err)
+	}
+	s.hub.CloseRoom("workspace", workspaceID)
+	log.Printf("tore down workspace %s: %d rows and %d files deleted, %d files failed",
+		t.WorkspaceID, t.RowsDeleted, t.FilesDeleted, t.FilesFailed)
+	return nil
+}
+
+// deleteFiles removes the workspace's attachments from storage, then their
+// rows. A file that can't be removed is counted and logged rather than
+// retried, so one bad object can't hold up the rest.
+func (s *teardownService) deleteFiles(t *models.WorkspaceTeardown) error {
+	t.Step = "files"
+	for {
+		attachments, err := s.teardownRepo.ListAttachments(t.WorkspaceID, teardownBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(attachments) == 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, 0, len(attachments))
+		for _, a := range attachments {
+			if err := s.storage.Delete(a.FileURL); err != nil && !os.IsNotExist(err) {
+				log.Printf("error deleting file %s of workspace %s: %v", a.FileURL, t.WorkspaceID, err)
+				t.FilesFailed++
+			} else {
+				t.FilesDeleted++
+			}
+			ids = append(ids, a.ID)
+		}
+
+		deleted, err := s.teardownRepo.DeleteAttachments(ids)
+		if err != nil {
+			return err
+		}
+		t.RowsDeleted += deleted
+		if err := s.teardownRepo.SaveProgress(t); err != nil {
+			return err
+		}
+	}
+}
+
+// deleteRows deletes one step's rows in batches until there are none left.
+func (s *teardownService) deleteRows(t *models.WorkspaceTeardown, step string) error {
+	t.Step = step
+	for {
+		ids, err := s.teardownRepo.DeleteBatch(t.WorkspaceID, step, teardownBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		if step == "channels" {
+			for _, channelID := range ids {
+				s.hub.CloseRoom("channel", channelID)
+			}
+		}
+		t.RowsDeleted += int64(len(ids))
+		if err := s.teardownRepo.SaveProgress(t); err != nil {
+			return err
+		}
+	}
+}
+
+// fail saves the error with the progress made so far and returns it.
+func (s *teardownService) fail(t *models.WorkspaceTeardown, err error) error {
+	message := err.Error()
+	t.LastError = &message
+	if saveErr := s.teardownRepo.SaveProgress(t); saveErr != nil {
+		log.Printf("error saving teardown progress of workspace %s: %v", t.WorkspaceID, saveErr)
+	}
+	return err
+}
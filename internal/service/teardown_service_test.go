@@ -0,0 +1,139 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+	"github.com/DoDuy2004/slack-clone-backend/internal/repository"
+	"github.com/DoDuy2004/slack-clone-backend/internal/websocket"
+	"github.com/DoDuy2004/slack-clone-backend/pkg/storage"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockTeardownRepository is a mock implementation of TeardownRepository
+type MockTeardownRepository struct {
+	mock.Mock
+}
+
+func (m *MockTeardownRepository) ListDue(limit int) ([]uuid.UUID, error) {
+	args := m.Called(limit)
+	return args.Get(0).([]uuid.UUID), args.Error(1)
+}
+
+func (m *MockTeardownRepository) Start(workspaceID uuid.UUID) (*models.WorkspaceTeardown, error) {
+	args := m.Called(workspaceID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WorkspaceTeardown), args.Error(1)
+}
+
+func (m *MockTeardownRepository) Find(workspaceID uuid.UUID) (*models.WorkspaceTeardown, error) {
+	args := m.Called(workspaceID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WorkspaceTeardown), args.Error(1)
+}
+
+func (m *MockTeardownRepository) SaveProgress(teardown *models.WorkspaceTeardown) error {
+	args := m.Called(teardown)
+	return args.Error(0)
+}
+
+func (m *MockTeardownRepository) ListAttachments(workspaceID uuid.UUID, limit int) ([]*models.Attachment, error) {
+	args := m.Called(workspaceID, limit)
+	return args.Get(0).([]*models.Attachment), args.Error(1)
+}
+
+func (m *MockTeardownRepository) DeleteAttachments(ids []uuid.UUID) (int64, error) {
+	args := m.Called(ids)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockTeardownRepository) DeleteBatch(workspaceID uuid.UUID, step string, limit int) ([]uuid.UUID, error) {
+	args := m.Called(workspaceID, step, limit)
+	return args.Get(0).([]uuid.UUID), args.Error(1)
+}
+
+func (m *MockTeardownRepository) Finish(teardown *models.WorkspaceTeardown) error {
+	args := m.Called(teardown)
+	return args.Error(0)
+}
+
+func TestTeardownDue(t *testing.T) {
+	mockRepo := new(MockTeardownRepository)
+	files, err := storage.NewLocalStorage(t.TempDir(), "")
+	assert.NoError(t, err)
+	svc := NewTeardownService(mockRepo, nil, files, websocket.NewHub())
+
+	wsID := uuid.New()
+	teardown := &models.WorkspaceTeardown{WorkspaceID: wsID, Status: "running", Step: "files"}
+	assert.NoError(t, os.WriteFile(filepath.Join(files.UploadDir, "kept.png"), []byte("png"), 0644))
+	attachments := []*models.Attachment{
+		{ID: uuid.New(), FileURL: "kept.png"},
+		{ID: uuid.New(), FileURL: "already-gone.png"},
+	}
+
+	mockRepo.On("ListDue", teardownWorkspaceBatch).Return([]uuid.UUID{wsID}, nil)
+	mockRepo.On("Start", wsID).Return(teardown, nil)
+	mockRepo.On("ListAttachments", wsID, teardownBatchSize).Return(attachments, nil).Once()
+	mockRepo.On("ListAttachments", wsID, teardownBatchSize).Return([]*models.Attachment{}, nil)
+	mockRepo.On("DeleteAttachments", []uuid.UUID{attachments[0].ID, attachments[1].ID}).Return(int64(2), nil)
+	mockRepo.On("DeleteBatch", wsID, "messages", teardownBatchSize).Return([]uuid.UUID{uuid.New(), uuid.New(), uuid.New()}, nil).Once()
+	for _, step := range repository.TeardownSteps {
+		mockRepo.On("DeleteBatch", wsID, step, teardownBatchSize).Return([]uuid.UUID{}, nil)
+	}
+	mockRepo.On("SaveProgress", teardown).Return(nil)
+	mockRepo.On("Finish", teardown).Return(nil)
+
+	finished, err := svc.TeardownDue()
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, finished)
+	assert.Equal(t, int64(5), teardown.RowsDeleted)
+	assert.Equal(t, 2, teardown.FilesDeleted)
+	assert.Equal(t, 0, teardown.FilesFailed)
+	_, err = os.Stat(filepath.Join(files.UploadDir, "kept.png"))
+	assert.True(t, os.IsNotExist(err))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestTeardownSkipsRestoredWorkspace(t *testing.T) {
+	mockRepo := new(MockTeardownRepository)
+	svc := NewTeardownService(mockRepo, nil, nil, nil)
+
+	wsID := uuid.New()
+	mockRepo.On("ListDue", teardownWorkspaceBatch).Return([]uuid.UUID{wsID}, nil)
+	mockRepo.On("Start", wsID).Return(nil, nil)
+
+	_, err := svc.TeardownDue()
+
+	assert.NoError(t, err)
+	mockRepo.AssertNotCalled(t, "ListAttachments", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "Finish", mock.Anything)
+}
+
+func TestGetDeletionAfterTeardown(t *testing.T) {
+	mockRepo := new(MockTeardownRepository)
+	mockWS := new(MockWorkspaceRepository)
+	svc := NewTeardownService(mockRepo, mockWS, nil, nil)
+
+	wsID, ownerID := uuid.New(), uuid.New()
+	completedAt := time.Now()
+	teardown := &models.WorkspaceTeardown{WorkspaceID: wsID, OwnerID: &ownerID, Status: "completed", CompletedAt: &completedAt}
+	mockRepo.On("Find", wsID).Return(teardown, nil)
+	mockWS.On("FindByID", wsID).Return(nil, nil)
+
+	deletion, err := svc.GetDeletion(ownerID, wsID)
+	assert.NoError(t, err)
+	assert.Equal(t, teardown, deletion.Teardown)
+
+	_, err = svc.GetDeletion(uuid.New(), wsID)
+	assert.Equal(t, ErrWorkspaceNotFound, err)
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+	"github.com/DoDuy2004/slack-clone-backend/internal/repository"
+	"github.com/DoDuy2004/slack-clone-backend/internal/websocket"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidThread = errors.New("message is not a thread parent")
+)
+
+type ThreadService interface {
+	FollowThread(userID, threadID uuid.UUID) error
+	UnfollowThread(userID, threadID uuid.UUID) error
+	MarkThreadAsRead(userID, threadID uuid.UUID) error
+	ListThreadInbox(userID, workspaceID uuid.UUID, unreadOnly bool, limit, offset int) ([]*models.ThreadInboxItem, error)
+	NotifyReply(reply *models.Message)
+}
+
+type threadService struct {
+	threadRepo     repository.ThreadRepository
+	workspaceRepo  repository.WorkspaceRepository
+	messageService MessageService
+	dndService     DNDService
+	hub            *websocket.Hub
+}
+
+func NewThreadService(
+	threadRepo repository.ThreadRepository,
+	workspaceRepo repository.WorkspaceRepository,
+	messageService MessageService,
+	dndService DNDService,
+	hub *websocket.Hub,
+) ThreadService {
+	return &threadService{
+		threadRepo:     threadRepo,
+		workspaceRepo:  workspaceRepo,
+		messageService: messageService,
+		dndService:     dndService,
+		hub:            hub,
+	}
+}
+
+func (s *threadService) FollowThread(userID, threadID uuid.UUID) error {
+	if _, err := s.getThreadParent(userID, threadID); err != nil {
+		return err
+	}
+	return s.threadRepo.Follow(threadID, userID)
+}
+
+func (s *threadService) UnfollowThread(userID, threadID uuid.UUID) error {
+	if _, err := s.getThreadParent(userID, threadID); err != nil {
+		return err
+	}
+	return s.threadRepo.Unfollow(threadID, userID)
+}
+
+func (s *threadService) MarkThreadAsRead(userID, threadID uuid.UUID) error {
+	if _, err := s.getThreadParent(userID, threadID); err != nil {
+		return err
+	}
+	return s.threadRepo.MarkRead(threadID, userID)
+}
+
+func (s *threadService) ListThreadInbox(userID, workspaceID uuid.UUID, unreadOnly bool, limit, offset int) ([]*models.ThreadInboxItem, error) {
+	// Verify workspace membership
+	member, err := s.workspaceRepo.GetMember(workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, ErrUnauthorized
+	}
+
+	return s.threadRepo.ListInbox(workspaceID, userID, unreadOnly, canReadPublicChannel(member), limit, offset)
+}
+
+// NotifyReply sends a thread.reply event to every follower except the sender
+// and those in DND, unless the reply is urgent.
+func (s *threadService) NotifyReply(reply *models.Message) {
+	if reply.ParentMessageID == nil {
+		return
+	}
+
+	followerIDs, err := s.threadRepo.ListFollowerIDs(*reply.ParentMessageID)
+	if err != nil {
+		log.Printf("error listing followers of thread %s: %v", *reply.ParentMessageID, err)
+		return
+	}
+
+	payload, err := json.Marshal(websocket.ThreadReplyPayload{
+		ThreadID: *reply.ParentMessageID,
+		Message:  reply,
+	})
+	if err != nil {
+		log.Printf("error marshaling thread reply payload: %v", err)
+		return
+	}
+
+	for _, followerID := range followerIDs {
+		if reply.SenderID != nil && *reply.SenderID == followerID {
+			continue
+		}
+		if !interruptible(s.dndService, followerID, reply.Urgent) {
+			continue
+		}
+		userID := followerID
+		s.hub.Broadcast(&websocket.WSMessage{
+			Type:    websocket.EventThreadReply,
+			Payload: payload,
+			UserID:  &userID,
+		})
+	}
+}
+
+func (s *threadService) getThreadParent(userID, threadID uuid.UUID) (*models.Message, error) {
+	parent, err := s.messageService.GetMessage(userID, threadID)
+	if err != nil {
+		return nil, err
+	}
+	if parent.ParentMessageID != nil {
+		return nil, ErrInvalidThread
+	}
+	return parent, nil
+}
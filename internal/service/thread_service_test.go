@@ -0,0 +1,141 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/DoDuy2004/slack-clone-backend/internal/authz"
+	"github.com/DoDuy2004/slack-clone-backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockThreadRepository is a mock implementation of ThreadRepository
+type MockThreadRepository struct {
+	mock.Mock
+}
+
+func (m *MockThreadRepository) Follow(threadID, userID uuid.UUID) error {
+	args := m.Called(threadID, userID)
+	return args.Error(0)
+}
+
+func (m *MockThreadRepository) AutoFollow(threadID, userID uuid.UUID) error {
+	args := m.Called(threadID, userID)
+	return args.Error(0)
+}
+
+func (m *MockThreadRepository) Unfollow(threadID, userID uuid.UUID) error {
+	args := m.Called(threadID, userID)
+	return args.Error(0)
+}
+
+func (m *MockThreadRepository) MarkRead(threadID, userID uuid.UUID) error {
+	args := m.Called(threadID, userID)
+	return args.Error(0)
+}
+
+func (m *MockThreadRepository) GetSubscription(threadID, userID uuid.UUID) (*models.ThreadSubscription, error) {
+	args := m.Called(threadID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ThreadSubscription), args.Error(1)
+}
+
+func (m *MockThreadRepository) ListFollowerIDs(threadID uuid.UUID) ([]uuid.UUID, error) {
+	args := m.Called(threadID)
+	return args.Get(0).([]uuid.UUID), args.Error(1)
+}
+
+func (m *MockThreadRepository) ListInbox(workspaceID, userID uuid.UUID, unreadOnly, includePublic bool, limit, offset int) ([]*models.ThreadInboxItem, error) {
+	args := m.Called(workspaceID, userID, unreadOnly, includePublic, limit, offset)
+	return args.Get(0).([]*models.ThreadInboxItem), args.Error(1)
+}
+
+func TestFollowThreadToggle(t *testing.T) {
+	mockThreads := new(MockThreadRepository)
+	mockMessages := new(MockMessageService)
+	svc := NewThreadService(mockThreads, nil, mockMessages, nil, nil)
+
+	userID := uuid.New()
+	parent := &models.Message{ID: uuid.New()}
+	reply := &models.Message{ID: uuid.New(), ParentMessageID: &parent.ID}
+	hidden := uuid.New()
+	mockMessages.On("GetMessage", userID, parent.ID).Return(parent, nil)
+	mockMessages.On("GetMessage", userID, reply.ID).Return(reply, nil)
+	mockMessages.On("GetMessage", userID, hidden).Return(nil, ErrUnauthorized)
+	mockThreads.On("Follow", parent.ID, userID).Return(nil)
+	mockThreads.On("Unfollow", parent.ID, userID).Return(nil)
+
+	assert.NoError(t, svc.FollowThread(userID, parent.ID))
+	assert.NoError(t, svc.UnfollowThread(userID, parent.ID))
+	assert.NoError(t, svc.FollowThread(userID, parent.ID))
+	mockThreads.AssertNumberOfCalls(t, "Follow", 2)
+	mockThreads.AssertNumberOfCalls(t, "Unfollow", 1)
+
+	// Only thread parents the user can see are followed
+	assert.Equal(t, ErrInvalidThread, svc.FollowThread(userID, reply.ID))
+	assert.Equal(t, ErrUnauthorized, svc.FollowThread(userID, hidden))
+	assert.Equal(t, ErrUnauthorized, svc.UnfollowThread(userID, hidden))
+	mockThreads.AssertNotCalled(t, "Follow", reply.ID, userID)
+	mockThreads.AssertNotCalled(t, "Follow", hidden, userID)
+	mockThreads.AssertNotCalled(t, "Unfollow", hidden, userID)
+}
+
+func TestMarkThreadAsReadClearsUnread(t *testing.T) {
+	mockThreads := new(MockThreadRepository)
+	mockWS := new(MockWorkspaceRepository)
+	mockMessages := new(MockMessageService)
+	svc := NewThreadService(mockThreads, mockWS, mockMessages, nil, nil)
+
+	wsID := uuid.New()
+	member := &models.WorkspaceMember{WorkspaceID: wsID, UserID: uuid.New(), Role: authz.RoleMember}
+	parent := &models.Message{ID: uuid.New()}
+	mockWS.On("GetMember", wsID, member.UserID).Return(member, nil)
+	mockMessages.On("GetMessage", member.UserID, parent.ID).Return(parent, nil)
+	mockThreads.On("ListInbox", wsID, member.UserID, false, true, 20, 0).
+		Return([]*models.ThreadInboxItem{{Parent: parent, UnreadCount: 3}}, nil).Once()
+	mockThreads.On("MarkRead", parent.ID, member.UserID).Return(nil).Once()
+	mockThreads.On("ListInbox", wsID, member.UserID, false, true, 20, 0).
+		Return([]*models.ThreadInboxItem{{Parent: parent, UnreadCount: 0}}, nil).Once()
+
+	inbox, err := svc.ListThreadInbox(member.UserID, wsID, false, 20, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, inbox[0].UnreadCount)
+
+	assert.NoError(t, svc.MarkThreadAsRead(member.UserID, parent.ID))
+
+	inbox, err = svc.ListThreadInbox(member.UserID, wsID, false, 20, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, inbox[0].UnreadCount)
+	mockThreads.AssertExpectations(t)
+}
+
+func TestListThreadInboxAccess(t *testing.T) {
+	mockThreads := new(MockThreadRepository)
+	mockWS := new(MockWorkspaceRepository)
+	svc := NewThreadService(mockThreads, mockWS, nil, nil, nil)
+
+	wsID := uuid.New()
+	member := &models.WorkspaceMember{WorkspaceID: wsID, UserID: uuid.New(), Role: authz.RoleMember}
+	guest := &models.WorkspaceMember{WorkspaceID: wsID, UserID: uuid.New(), Role: authz.RoleSingleChannelGuest}
+	outsider := uuid.New()
+	mockWS.On("GetMember", wsID, member.UserID).Return(member, nil)
+	mockWS.On("GetMember", wsID, guest.UserID).Return(guest, nil)
+	mockWS.On("GetMember", wsID, outsider).Return(nil, nil)
+	mockThreads.On("ListInbox", wsID, member.UserID, true, true, 20, 0).Return([]*models.ThreadInboxItem{}, nil)
+	mockThreads.On("ListInbox", wsID, guest.UserID, true, false, 20, 0).Return([]*models.ThreadInboxItem{}, nil)
+
+	_, err := svc.ListThreadInbox(outsider, wsID, true, 20, 0)
+	assert.Equal(t, ErrUnauthorized, err)
+	mockThreads.AssertNotCalled(t, "ListInbox", wsID, outsider, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	// Members see followed threads in any public channel, guests only in
+	// the channels they are in
+	_, err = svc.ListThreadInbox(member.UserID, wsID, true, 20, 0)
+	assert.NoError(t, err)
+	_, err = svc.ListThreadInbox(guest.UserID, wsID, true, 20, 0)
+	assert.NoError(t, err)
+	mockThreads.AssertExpectations(t)
+}
@@ -1,11 +1,17 @@
 package service
 
 import (
+	"encoding/json"
 	"errors"
+	"log"
+	"time"
 
+	"github.com/DoDuy2004/slack-clone-backend/internal/authz"
 	"github.com/DoDuy2004/slack-clone-backend/internal/models"
 	"github.com/DoDuy2004/slack-clone-backend/internal/models/dto"
 	"github.com/DoDuy2004/slack-clone-backend/internal/repository"
+	"github.com/DoDuy2004/slack-clone-backend/internal/websocket"
+	"github.com/DoDuy2004/slack-clone-backend/pkg/hash"
 	"github.com/google/uuid"
 )
 
@@ -13,24 +19,94 @@ var (
 	ErrWorkspaceNotFound = errors.New("workspace not found")
 	ErrWorkspaceExists   = errors.New("workspace with this slug already exists")
 	ErrUnauthorized      = errors.New("unauthorized")
+	ErrMemberNotFound    = errors.New("workspace member not found")
+	ErrOwnerImmutable    = errors.New("the workspace owner cannot be removed or have their role changed")
+	ErrTransferTarget    = errors.New("ownership can only be transferred to another admin")
+	ErrIncorrectPassword = errors.New("incorrect password")
+	ErrTOTPUnsupported   = errors.New("two-factor codes aren't supported; confirm with your password")
+	ErrNotGuest          = errors.New("member is not a guest")
+	ErrGuestExpiry       = errors.New("guest access must expire in the future")
+	ErrNotDeleted        = errors.New("workspace is not deleted")
+	ErrRestoreExpired    = errors.New("the workspace can no longer be restored")
 )
 
+// Audit log actions
+const (
+	AuditActionOwnershipTransferred = "workspace.ownership_transferred"
+	AuditActionMemberRoleChanged    = "member.role_changed"
+	AuditActionMemberRemoved        = "member.removed"
+	AuditActionRoleCreated          = "role.created"
+	AuditActionRoleUpdated          = "role.updated"
+	AuditActionRoleDeleted          = "role.deleted"
+	AuditActionRoleAssigned         = "member.custom_role_changed"
+	AuditActionGuestExpiryChanged   = "guest.expiry_changed"
+	AuditActionGuestExpired         = "guest.expired"
+	AuditActionWorkspaceDeleted     = "workspace.deleted"
+	AuditActionWorkspaceRestored    = "workspace.restored"
+)
+
+// workspaceRestoreWindow is how long a deleted workspace can be restored
+// before it is torn down
+const workspaceRestoreWindow = 30 * 24 * time.Hour
+
 type WorkspaceService interface {
 	CreateWorkspace(userID uuid.UUID, req *dto.CreateWorkspaceRequest) (*models.Workspace, error)
 	GetWorkspace(id uuid.UUID) (*models.Workspace, error)
 	GetWorkspaceBySlug(slug string) (*models.Workspace, error)
 	ListUserWorkspaces(userID uuid.UUID) ([]*models.Workspace, error)
 	UpdateWorkspace(userID uuid.UUID, wsID uuid.UUID, req *dto.UpdateWorkspaceRequest) (*models.Workspace, error)
-	DeleteWorkspace(userID uuid.UUID, wsID uuid.UUID) error
+	// DeleteWorkspace hides the workspace from its members at once; the owner
+	// can restore it until its PurgeAfter, after which it is torn down
+	DeleteWorkspace(userID uuid.UUID, wsID uuid.UUID) (*models.Workspace, error)
+	RestoreWorkspace(userID uuid.UUID, wsID uuid.UUID) (*models.Workspace, error)
+	// ListDeletedWorkspaces returns the user's deleted workspaces that are
+	// still waiting to be torn down
+	ListDeletedWorkspaces(userID uuid.UUID) ([]*models.Workspace, error)
+	GetSettings(userID uuid.UUID, wsID uuid.UUID) (*models.WorkspaceSettings, error)
+	UpdateSettings(userID uuid.UUID, wsID uuid.UUID, req *dto.UpdateWorkspaceSettingsRequest) (*models.WorkspaceSettings, error)
+
+	// Members
+	ListMembers(userID uuid.UUID, wsID uuid.UUID) ([]*dto.WorkspaceMemberResponse, error)
+	// UpdateMemberRole makes a member an admin or back; by default only the
+	// owner can do it
+	UpdateMemberRole(userID uuid.UUID, wsID uuid.UUID, memberID uuid.UUID, role string) (*models.WorkspaceMember, error)
+	// RemoveMember removes a member from the workspace and its channels and
+	// DMs. Removing an admin takes the right to manage roles and removing a
+	// member takes member.remove; anyone can remove themselves and nobody can
+	// remove the owner.
+	RemoveMember(userID uuid.UUID, wsID uuid.UUID, memberID uuid.UUID) error
+	// TransferOwnership hands the workspace to an existing admin. The owner
+	// confirms it with their password and stays on as an admin; two-factor
+	// codes aren't supported and are refused.
+	TransferOwnership(userID uuid.UUID, wsID uuid.UUID, req *dto.TransferOwnershipRequest) (*models.Workspace, error)
+	// SetGuestExpiry changes when a guest's access ends; nil leaves it open
+	SetGuestExpiry(userID uuid.UUID, wsID uuid.UUID, guestID uuid.UUID, expiresAt *time.Time) (*models.WorkspaceMember, error)
+	// RemoveExpiredGuests removes every guest whose access has ended
+	RemoveExpiredGuests() error
+	// RunGuestExpiry polls for expired guests until the process exits
+	RunGuestExpiry(interval time.Duration)
+	// ListAuditLog returns the workspace's audit log, newest first. By
+	// default only admins and the owner can see it.
+	ListAuditLog(userID uuid.UUID, wsID uuid.UUID, limit, offset int) ([]*models.AuditLogEntry, error)
 }
 
 type workspaceService struct {
 	workspaceRepo repository.WorkspaceRepository
+	channelRepo   repository.ChannelRepository
+	userRepo      repository.UserRepository
+	auditRepo     repository.AuditRepository
+	authz         *authz.Authorizer
+	hub           *websocket.Hub
 }
 
-func NewWorkspaceService(workspaceRepo repository.WorkspaceRepository) WorkspaceService {
+func NewWorkspaceService(workspaceRepo repository.WorkspaceRepository, channelRepo repository.ChannelRepository, userRepo repository.UserRepository, auditRepo repository.AuditRepository, authorizer *authz.Authorizer, hub *websocket.Hub) WorkspaceService {
 	return &workspaceService{
 		workspaceRepo: workspaceRepo,
+		channelRepo:   channelRepo,
+		userRepo:      userRepo,
+		auditRepo:     auditRepo,
+		authz:         authorizer,
+		hub:           hub,
 	}
 }
 
@@ -64,7 +140,7 @@ func (s *workspaceService) GetWorkspace(id uuid.UUID) (*models.Workspace, error)
 	if err != nil {
 		return nil, err
 	}
-	if ws == nil {
+	if ws == nil || ws.DeletedAt != nil {
 		return nil, ErrWorkspaceNotFound
 	}
 	return ws, nil
@@ -75,7 +151,7 @@ func (s *workspaceService) GetWorkspaceBySlug(slug string) (*models.Workspace, e
 	if err != nil {
 		return nil, err
 	}
-	if ws == nil {
+	if ws == nil || ws.DeletedAt != nil {
 		return nil, ErrWorkspaceNotFound
 	}
 	return ws, nil
@@ -90,7 +166,7 @@ func (s *workspaceService) UpdateWorkspace(userID uuid.UUID, wsID uuid.UUID, req
 	if err != nil {
 		return nil, err
 	}
-	if ws == nil {
+	if ws == nil || ws.DeletedAt != nil {
 		return nil, ErrWorkspaceNotFound
 	}
 
@@ -113,19 +189,542 @@ func (s *workspaceService) UpdateWorkspace(userID uuid.UUID, wsID uuid.UUID, req
 	return ws, nil
 }
 
-func (s *workspaceService) DeleteWorkspace(userID uuid.UUID, wsID uuid.UUID) error {
+func (s *workspaceService) DeleteWorkspace(userID uuid.UUID, wsID uuid.UUID) (*models.Workspace, error) {
 	ws, err := s.workspaceRepo.FindByID(wsID)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if ws == nil {
-		return ErrWorkspaceNotFound
+	if ws == nil || ws.DeletedAt != nil {
+		return nil, ErrWorkspaceNotFound
 	}
 
 	// Only owner can delete
 	if ws.OwnerID != userID {
+		return nil, ErrUnauthorized
+	}
+
+	// Members are hidden once the workspace is deleted, so list them first
+	members, err := s.workspaceRepo.ListMembers(wsID)
+	if err != nil {
+		return nil, err
+	}
+
+	deleted, err := s.workspaceRepo.SoftDelete(wsID, userID, time.Now().Add(workspaceRestoreWindow))
+	if err != nil {
+		return nil, err
+	}
+	if !deleted {
+		return nil, ErrWorkspaceNotFound
+	}
+	s.audit(wsID, userID, AuditActionWorkspaceDeleted, nil, nil)
+
+	ws, err = s.workspaceRepo.FindByID(wsID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tell each member directly, since the rooms are closed right after
+	payload, _ := json.Marshal(ws)
+	for _, m := range members {
+		memberID := m.UserID
+		s.hub.Broadcast(&websocket.WSMessage{
+			Type:    websocket.EventWorkspaceDeleted,
+			Payload: payload,
+			UserID:  &memberID,
+		})
+	}
+	s.closeWorkspaceRooms(wsID)
+	return ws, nil
+}
+
+func (s *workspaceService) RestoreWorkspace(userID uuid.UUID, wsID uuid.UUID) (*models.Workspace, error) {
+	ws, err := s.workspaceRepo.FindByID(wsID)
+	if err != nil {
+		return nil, err
+	}
+	if ws == nil {
+		return nil, ErrWorkspaceNotFound
+	}
+	if ws.OwnerID != userID {
+		return nil, ErrUnauthorized
+	}
+	if ws.DeletedAt == nil {
+		return nil, ErrNotDeleted
+	}
+
+	restored, err := s.workspaceRepo.Restore(wsID)
+	if err != nil {
+		return nil, err
+	}
+	if !restored {
+		return nil, ErrRestoreExpired
+	}
+	s.audit(wsID, userID, AuditActionWorkspaceRestored, nil, nil)
+
+	ws, err = s.workspaceRepo.FindByID(wsID)
+	if err != nil {
+		return nil, err
+	}
+	s.reopenWorkspaceRooms(wsID)
+	s.workspaceChanged(websocket.EventWorkspaceRestored, ws)
+	return ws, nil
+}
+
+func (s *workspaceService) ListDeletedWorkspaces(userID uuid.UUID) ([]*models.Workspace, error) {
+	return s.workspaceRepo.ListDeleted(userID)
+}
+
+// closeWorkspaceRooms drops every socket from the workspace's rooms so a
+// deleted workspace gets no more events.
+func (s *workspaceService) closeWorkspaceRooms(wsID uuid.UUID) {
+	s.hub.CloseRoom("workspace", wsID)
+
+	memberships, err := s.channelRepo.ListWorkspaceMemberships(wsID)
+	if err != nil {
+		log.Printf("failed to list channel memberships of workspace %s: %v", wsID, err)
+		return
+	}
+	closed := make(map[uuid.UUID]bool)
+	for _, m := range memberships {
+		if !closed[m.ChannelID] {
+			s.hub.CloseRoom("channel", m.ChannelID)
+			closed[m.ChannelID] = true
+		}
+	}
+}
+
+// reopenWorkspaceRooms puts the connected members of a restored workspace
+// back into its rooms.
+func (s *workspaceService) reopenWorkspaceRooms(wsID uuid.UUID) {
+	members, err := s.workspaceRepo.ListMembers(wsID)
+	if err != nil {
+		log.Printf("failed to list members of workspace %s: %v", wsID, err)
+		return
+	}
+	for _, m := range members {
+		s.hub.AddUserToRoom("workspace", wsID, m.UserID)
+	}
+
+	memberships, err := s.channelRepo.ListWorkspaceMemberships(wsID)
+	if err != nil {
+		log.Printf("failed to list channel memberships of workspace %s: %v", wsID, err)
+		return
+	}
+	for _, m := range memberships {
+		s.hub.AddUserToRoom("channel", m.ChannelID, m.UserID)
+	}
+}
+
+// workspaceChanged tells everyone in the workspace it was restored.
+func (s *workspaceService) workspaceChanged(event string, ws *models.Workspace) {
+	payload, _ := json.Marshal(ws)
+	s.hub.Broadcast(&websocket.WSMessage{
+		Type:        event,
+		Payload:     payload,
+		WorkspaceID: &ws.ID,
+	})
+}
+
+func (s *workspaceService) GetSettings(userID uuid.UUID, wsID uuid.UUID) (*models.WorkspaceSettings, error) {
+	member, err := s.workspaceRepo.GetMember(wsID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, ErrUnauthorized
+	}
+
+	return s.workspaceRepo.GetSettings(wsID)
+}
+
+func (s *workspaceService) UpdateSettings(userID uuid.UUID, wsID uuid.UUID, req *dto.UpdateWorkspaceSettingsRequest) (*models.WorkspaceSettings, error) {
+	allowed, err := s.authz.Can(wsID, userID, authz.WorkspaceManageSettings)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrUnauthorized
+	}
+
+	settings, err := s.workspaceRepo.GetSettings(wsID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.BroadcastMentionPolicy != nil {
+		settings.BroadcastMentionPolicy = *req.BroadcastMentionPolicy
+	}
+	if req.DefaultNotificationLevel != nil {
+		settings.DefaultNotificationLevel = *req.DefaultNotificationLevel
+	}
+	if req.UrgentOverridePolicy != nil {
+		settings.UrgentOverridePolicy = *req.UrgentOverridePolicy
+	}
+
+	if err := s.workspaceRepo.UpdateSettings(settings); err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+func (s *workspaceService) ListMembers(userID uuid.UUID, wsID uuid.UUID) ([]*dto.WorkspaceMemberResponse, error) {
+	member, err := s.workspaceRepo.GetMember(wsID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, ErrUnauthorized
+	}
+
+	members, err := s.workspaceRepo.ListMembers(wsID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]*dto.WorkspaceMemberResponse, 0, len(members))
+	for _, m := range members {
+		r := &dto.WorkspaceMemberResponse{
+			ID:             m.ID,
+			UserID:         m.UserID,
+			Role:           m.Role,
+			CustomRoleID:   m.CustomRoleID,
+			GuestExpiresAt: m.GuestExpiresAt,
+			JoinedAt:       m.JoinedAt,
+		}
+		if m.User != nil {
+			r.Email = m.User.Email
+			r.Username = m.User.Username
+			r.FullName = m.User.FullName
+			r.AvatarURL = m.User.AvatarURL
+		}
+		response = append(response, r)
+	}
+	return response, nil
+}
+
+func (s *workspaceService) UpdateMemberRole(userID uuid.UUID, wsID uuid.UUID, memberID uuid.UUID, role string) (*models.WorkspaceMember, error) {
+	allowed, err := s.authz.Can(wsID, userID, authz.MemberManageRoles)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrUnauthorized
+	}
+
+	member, err := s.workspaceRepo.GetMember(wsID, memberID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, ErrMemberNotFound
+	}
+	if member.Role == authz.RoleOwner {
+		return nil, ErrOwnerImmutable
+	}
+	if member.Role == role {
+		return member, nil
+	}
+
+	if err := s.workspaceRepo.UpdateMemberRole(wsID, memberID, role); err != nil {
+		return nil, err
+	}
+	s.audit(wsID, userID, AuditActionMemberRoleChanged, &memberID, map[string]string{
+		"old_role": member.Role,
+		"new_role": role,
+	})
+	member.Role = role
+
+	payload, _ := json.Marshal(websocket.WorkspaceMemberPayload{
+		WorkspaceID: wsID,
+		UserID:      memberID,
+		Role:        role,
+		ActorID:     &userID,
+	})
+	s.hub.Broadcast(&websocket.WSMessage{
+		Type:        websocket.EventWorkspaceMemberUpdated,
+		Payload:     payload,
+		WorkspaceID: &wsID,
+	})
+
+	return member, nil
+}
+
+func (s *workspaceService) RemoveMember(userID uuid.UUID, wsID uuid.UUID, memberID uuid.UUID) error {
+	actor, err := s.authz.Grant(wsID, userID)
+	if err != nil {
+		return err
+	}
+	if actor == nil {
 		return ErrUnauthorized
 	}
 
-	return s.workspaceRepo.Delete(wsID)
+	member, err := s.workspaceRepo.GetMember(wsID, memberID)
+	if err != nil {
+		return err
+	}
+	if member == nil {
+		return ErrMemberNotFound
+	}
+	if !canRemoveMember(actor, member) {
+		if member.Role == authz.RoleOwner {
+			return ErrOwnerImmutable
+		}
+		return ErrUnauthorized
+	}
+
+	if err := s.removeMember(member, &userID); err != nil {
+		return err
+	}
+	s.audit(wsID, userID, AuditActionMemberRemoved, &memberID, map[string]string{"role": member.Role})
+
+	return nil
+}
+
+// removeMember takes the member out of the workspace and tells everyone,
+// including the removed user so their clients drop the workspace. actorID
+// is nil when the system removes them.
+func (s *workspaceService) removeMember(member *models.WorkspaceMember, actorID *uuid.UUID) error {
+	wsID, memberID := member.WorkspaceID, member.UserID
+
+	// Note the channels the user could see before they lose access, so their
+	// sockets can be taken out of those rooms
+	channels, err := s.channelRepo.ListByWorkspaceID(wsID, memberID, true)
+	if err != nil {
+		return err
+	}
+
+	if err := s.workspaceRepo.RemoveMember(wsID, memberID); err != nil {
+		return err
+	}
+
+	for _, channel := range channels {
+		s.hub.RemoveUserFromRoom("channel", channel.ID, memberID)
+	}
+	s.hub.RemoveUserFromRoom("workspace", wsID, memberID)
+
+	payload, _ := json.Marshal(websocket.WorkspaceMemberPayload{
+		WorkspaceID: wsID,
+		UserID:      memberID,
+		Role:        member.Role,
+		ActorID:     actorID,
+	})
+	s.hub.Broadcast(&websocket.WSMessage{
+		Type:        websocket.EventWorkspaceMemberRemoved,
+		Payload:     payload,
+		WorkspaceID: &wsID,
+	})
+	s.hub.Broadcast(&websocket.WSMessage{
+		Type:    websocket.EventWorkspaceMemberRemoved,
+		Payload: payload,
+		UserID:  &memberID,
+	})
+
+	return nil
+}
+
+func (s *workspaceService) SetGuestExpiry(userID uuid.UUID, wsID uuid.UUID, guestID uuid.UUID, expiresAt *time.Time) (*models.WorkspaceMember, error) {
+	allowed, err := s.authz.Can(wsID, userID, authz.MemberManageGuests)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrUnauthorized
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, ErrGuestExpiry
+	}
+
+	guest, err := s.workspaceRepo.GetMember(wsID, guestID)
+	if err != nil {
+		return nil, err
+	}
+	if guest == nil {
+		return nil, ErrMemberNotFound
+	}
+	if !authz.IsGuest(guest.Role) {
+		return nil, ErrNotGuest
+	}
+
+	if err := s.workspaceRepo.SetGuestExpiry(wsID, guestID, expiresAt); err != nil {
+		return nil, err
+	}
+	metadata := map[string]string{}
+	if expiresAt != nil {
+		metadata["expires_at"] = expiresAt.UTC().Format(time.RFC3339)
+	}
+	s.audit(wsID, userID, AuditActionGuestExpiryChanged, &guestID, metadata)
+
+	guest.GuestExpiresAt = expiresAt
+	return guest, nil
+}
+
+func (s *workspaceService) RemoveExpiredGuests() error {
+	guests, err := s.workspaceRepo.ListExpiredGuests()
+	if err != nil {
+		return err
+	}
+
+	for _, guest := range guests {
+		if err := s.removeMember(guest, nil); err != nil {
+			log.Printf("error removing expired guest %s from workspace %s: %v", guest.UserID, guest.WorkspaceID, err)
+			continue
+		}
+
+		entry := &models.AuditLogEntry{
+			ID:          uuid.New(),
+			WorkspaceID: guest.WorkspaceID,
+			Action:      AuditActionGuestExpired,
+			TargetID:    &guest.UserID,
+		}
+		if err := s.auditRepo.Create(entry); err != nil {
+			log.Printf("error recording %s in workspace %s: %v", entry.Action, guest.WorkspaceID, err)
+		}
+	}
+	return nil
+}
+
+// RunGuestExpiry polls for expired guests until the process exits.
+func (s *workspaceService) RunGuestExpiry(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := s.RemoveExpiredGuests(); err != nil {
+			log.Printf("error removing expired guests: %v", err)
+		}
+	}
+}
+
+// canRemoveMember applies the removal rules: never the owner; otherwise
+// anyone can leave, removing an admin takes the right to manage roles, and
+// removing anyone else takes member.remove.
+func canRemoveMember(actor *authz.Grant, member *models.WorkspaceMember) bool {
+	switch {
+	case member.Role == authz.RoleOwner:
+		return false
+	case actor.Member.UserID == member.UserID:
+		return true
+	case member.Role == authz.RoleAdmin:
+		return actor.Has(authz.MemberManageRoles)
+	}
+	return actor.Has(authz.MemberRemove)
+}
+
+func (s *workspaceService) TransferOwnership(userID uuid.UUID, wsID uuid.UUID, req *dto.TransferOwnershipRequest) (*models.Workspace, error) {
+	ws, err := s.workspaceRepo.FindByID(wsID)
+	if err != nil {
+		return nil, err
+	}
+	if ws == nil || ws.DeletedAt != nil {
+		return nil, ErrWorkspaceNotFound
+	}
+	if ws.OwnerID != userID {
+		return nil, ErrUnauthorized
+	}
+	if req.TOTPCode != "" {
+		return nil, ErrTOTPUnsupported
+	}
+
+	newOwner, err := s.workspaceRepo.GetMember(wsID, req.NewOwnerID)
+	if err != nil {
+		return nil, err
+	}
+	if newOwner == nil || newOwner.Role != authz.RoleAdmin {
+		return nil, ErrTransferTarget
+	}
+
+	owner, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if owner == nil || !hash.CheckPassword(req.Password, owner.PasswordHash) {
+		return nil, ErrIncorrectPassword
+	}
+
+	entry := newAuditEntry(wsID, userID, AuditActionOwnershipTransferred, &req.NewOwnerID, map[string]string{
+		"previous_owner_id": userID.String(),
+		"new_owner_id":      req.NewOwnerID.String(),
+	})
+	transferred, err := s.workspaceRepo.TransferOwnership(wsID, userID, req.NewOwnerID, entry)
+	if err != nil {
+		return nil, err
+	}
+	if !transferred {
+		return nil, ErrUnauthorized
+	}
+
+	for _, m := range []struct {
+		userID uuid.UUID
+		role   string
+	}{{req.NewOwnerID, authz.RoleOwner}, {userID, authz.RoleAdmin}} {
+		payload, _ := json.Marshal(websocket.WorkspaceMemberPayload{
+			WorkspaceID: wsID,
+			UserID:      m.userID,
+			Role:        m.role,
+			ActorID:     &userID,
+		})
+		s.hub.Broadcast(&websocket.WSMessage{
+			Type:        websocket.EventWorkspaceMemberUpdated,
+			Payload:     payload,
+			WorkspaceID: &wsID,
+		})
+	}
+
+	return s.workspaceRepo.FindByID(wsID)
+}
+
+func (s *workspaceService) ListAuditLog(userID uuid.UUID, wsID uuid.UUID, limit, offset int) ([]*models.AuditLogEntry, error) {
+	allowed, err := s.authz.Can(wsID, userID, authz.WorkspaceViewAuditLog)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrUnauthorized
+	}
+
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	entries, err := s.auditRepo.ListByWorkspace(wsID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	if entries == nil {
+		entries = []*models.AuditLogEntry{}
+	}
+	return entries, nil
+}
+
+// audit records an action that has already been carried out, so a failure is
+// logged rather than returned.
+func (s *workspaceService) audit(wsID, actorID uuid.UUID, action string, targetID *uuid.UUID, metadata map[string]string) {
+	recordAudit(s.auditRepo, wsID, actorID, action, targetID, metadata)
+}
+
+// recordAudit writes an audit log entry, logging rather than returning a
+// failure so it never undoes the action being recorded.
+func recordAudit(auditRepo repository.AuditRepository, wsID, actorID uuid.UUID, action string, targetID *uuid.UUID, metadata map[string]string) {
+	entry := newAuditEntry(wsID, actorID, action, targetID, metadata)
+	if err := auditRepo.Create(entry); err != nil {
+		log.Printf("error recording %s in workspace %s: %v", action, wsID, err)
+	}
+}
+
+func newAuditEntry(wsID, actorID uuid.UUID, action string, targetID *uuid.UUID, metadata map[string]string) *models.AuditLogEntry {
+	entry := &models.AuditLogEntry{
+		ID:          uuid.New(),
+		WorkspaceID: wsID,
+		ActorID:     &actorID,
+		Action:      action,
+		TargetID:    targetID,
+	}
+	if len(metadata) > 0 {
+		entry.Metadata, _ = json.Marshal(metadata)
+	}
+	return entry
 }
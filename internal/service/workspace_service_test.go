@@ -2,9 +2,12 @@ package service
 
 import (
 	"testing"
+	"time"
 
+	"github.com/DoDuy2004/slack-clone-backend/internal/authz"
 	"github.com/DoDuy2004/slack-clone-backend/internal/models"
 	"github.com/DoDuy2004/slack-clone-backend/internal/models/dto"
+	"github.com/DoDuy2004/slack-clone-backend/pkg/hash"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -46,9 +49,24 @@ func (m *MockWorkspaceRepository) Update(workspace *models.Workspace) error {
 	return args.Error(0)
 }
 
-func (m *MockWorkspaceRepository) Delete(id uuid.UUID) error {
+func (m *MockWorkspaceRepository) SoftDelete(id, deletedBy uuid.UUID, purgeAfter time.Time) (bool, error) {
+	args := m.Called(id, deletedBy, purgeAfter)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockWorkspaceRepository) Restore(id uuid.UUID) (bool, error) {
 	args := m.Called(id)
-	return args.Error(0)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockWorkspaceRepository) ListDeleted(ownerID uuid.UUID) ([]*models.Workspace, error) {
+	args := m.Called(ownerID)
+	return args.Get(0).([]*models.Workspace), args.Error(1)
+}
+
+func (m *MockWorkspaceRepository) TransferOwnership(workspaceID, fromID, toID uuid.UUID, entry *models.AuditLogEntry) (bool, error) {
+	args := m.Called(workspaceID, fromID, toID, entry)
+	return args.Bool(0), args.Error(1)
 }
 
 func (m *MockWorkspaceRepository) AddMember(workspaceID, userID uuid.UUID, role string) error {
@@ -56,6 +74,21 @@ func (m *MockWorkspaceRepository) AddMember(workspaceID, userID uuid.UUID, role
 	return args.Error(0)
 }
 
+func (m *MockWorkspaceRepository) SetGuestExpiry(workspaceID, userID uuid.UUID, expiresAt *time.Time) error {
+	args := m.Called(workspaceID, userID, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockWorkspaceRepository) ListExpiredGuests() ([]*models.WorkspaceMember, error) {
+	args := m.Called()
+	return args.Get(0).([]*models.WorkspaceMember), args.Error(1)
+}
+
+func (m *MockWorkspaceRepository) UpdateMemberRole(workspaceID, userID uuid.UUID, role string) error {
+	args := m.Called(workspaceID, userID, role)
+	return args.Error(0)
+}
+
 func (m *MockWorkspaceRepository) RemoveMember(workspaceID, userID uuid.UUID) error {
 	args := m.Called(workspaceID, userID)
 	return args.Error(0)
@@ -74,9 +107,22 @@ func (m *MockWorkspaceRepository) ListMembers(workspaceID uuid.UUID) ([]*models.
 	return args.Get(0).([]*models.WorkspaceMember), args.Error(1)
 }
 
+func (m *MockWorkspaceRepository) GetSettings(workspaceID uuid.UUID) (*models.WorkspaceSettings, error) {
+	args := m.Called(workspaceID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WorkspaceSettings), args.Error(1)
+}
+
+func (m *MockWorkspaceRepository) UpdateSettings(settings *models.WorkspaceSettings) error {
+	args := m.Called(settings)
+	return args.Error(0)
+}
+
 func TestCreateWorkspace(t *testing.T) {
 	mockRepo := new(MockWorkspaceRepository)
-	svc := NewWorkspaceService(mockRepo)
+	svc := NewWorkspaceService(mockRepo, nil, nil, nil, authz.New(mockRepo, nil), nil)
 
 	userID := uuid.New()
 	req := &dto.CreateWorkspaceRequest{
@@ -111,7 +157,7 @@ func TestCreateWorkspace(t *testing.T) {
 
 func TestUpdateWorkspace(t *testing.T) {
 	mockRepo := new(MockWorkspaceRepository)
-	svc := NewWorkspaceService(mockRepo)
+	svc := NewWorkspaceService(mockRepo, nil, nil, nil, authz.New(mockRepo, nil), nil)
 
 	userID := uuid.New()
 	wsID := uuid.New()
@@ -151,6 +197,198 @@ func TestUpdateWorkspace(t *testing.T) {
 	})
 }
 
+func TestDeleteAndRestoreWorkspace(t *testing.T) {
+	mockRepo := new(MockWorkspaceRepository)
+	svc := NewWorkspaceService(mockRepo, nil, nil, nil, authz.New(mockRepo, nil), nil)
+
+	ownerID, adminID := uuid.New(), uuid.New()
+	liveID, deletedID, expiredID := uuid.New(), uuid.New(), uuid.New()
+	deletedAt := time.Now().Add(-time.Hour)
+	mockRepo.On("FindByID", liveID).Return(&models.Workspace{ID: liveID, OwnerID: ownerID}, nil)
+	mockRepo.On("FindByID", deletedID).Return(&models.Workspace{ID: deletedID, OwnerID: ownerID, DeletedAt: &deletedAt}, nil)
+	mockRepo.On("FindByID", expiredID).Return(&models.Workspace{ID: expiredID, OwnerID: ownerID, DeletedAt: &deletedAt}, nil)
+	mockRepo.On("Restore", expiredID).Return(false, nil)
+
+	// Only the owner deletes, and a deleted workspace is already gone
+	_, err := svc.DeleteWorkspace(adminID, liveID)
+	assert.Equal(t, ErrUnauthorized, err)
+	_, err = svc.DeleteWorkspace(ownerID, deletedID)
+	assert.Equal(t, ErrWorkspaceNotFound, err)
+	mockRepo.AssertNotCalled(t, "SoftDelete", mock.Anything, mock.Anything, mock.Anything)
+
+	// Deleted workspaces are hidden until restored
+	_, err = svc.GetWorkspace(deletedID)
+	assert.Equal(t, ErrWorkspaceNotFound, err)
+
+	_, err = svc.RestoreWorkspace(adminID, deletedID)
+	assert.Equal(t, ErrUnauthorized, err)
+	_, err = svc.RestoreWorkspace(ownerID, liveID)
+	assert.Equal(t, ErrNotDeleted, err)
+	_, err = svc.RestoreWorkspace(ownerID, expiredID)
+	assert.Equal(t, ErrRestoreExpired, err)
+}
+
 func stringPtr(s string) *string {
 	return &s
 }
+
+func TestUpdateMemberRoleOwnerOnly(t *testing.T) {
+	mockRepo := new(MockWorkspaceRepository)
+	svc := NewWorkspaceService(mockRepo, nil, nil, nil, authz.New(mockRepo, nil), nil)
+
+	wsID := uuid.New()
+	owner := &models.WorkspaceMember{WorkspaceID: wsID, UserID: uuid.New(), Role: "owner"}
+	admin := &models.WorkspaceMember{WorkspaceID: wsID, UserID: uuid.New(), Role: "admin"}
+	member := &models.WorkspaceMember{WorkspaceID: wsID, UserID: uuid.New(), Role: "member"}
+	for _, m := range []*models.WorkspaceMember{owner, admin, member} {
+		mockRepo.On("GetMember", wsID, m.UserID).Return(m, nil)
+	}
+
+	_, err := svc.UpdateMemberRole(admin.UserID, wsID, member.UserID, "admin")
+	assert.Equal(t, ErrUnauthorized, err)
+
+	_, err = svc.UpdateMemberRole(owner.UserID, wsID, owner.UserID, "member")
+	assert.Equal(t, ErrOwnerImmutable, err)
+	mockRepo.AssertNotCalled(t, "UpdateMemberRole", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCanRemoveMember(t *testing.T) {
+	owner := &models.WorkspaceMember{UserID: uuid.New(), Role: "owner"}
+	admin := &models.WorkspaceMember{UserID: uuid.New(), Role: "admin"}
+	otherAdmin := &models.WorkspaceMember{UserID: uuid.New(), Role: "admin"}
+	member := &models.WorkspaceMember{UserID: uuid.New(), Role: "member"}
+	otherMember := &models.WorkspaceMember{UserID: uuid.New(), Role: "member"}
+	grant := func(m *models.WorkspaceMember, custom ...string) *authz.Grant {
+		return authz.NewGrant(m, custom)
+	}
+
+	assert.True(t, canRemoveMember(grant(owner), admin))
+	assert.True(t, canRemoveMember(grant(admin), member))
+	assert.True(t, canRemoveMember(grant(member), member), "members can leave")
+	assert.False(t, canRemoveMember(grant(admin), otherAdmin))
+	assert.False(t, canRemoveMember(grant(member), otherMember))
+	assert.False(t, canRemoveMember(grant(admin), owner))
+	assert.False(t, canRemoveMember(grant(owner), owner))
+	// A custom role can let a member remove members, but not admins
+	assert.True(t, canRemoveMember(grant(member, string(authz.MemberRemove)), otherMember))
+	assert.False(t, canRemoveMember(grant(member, string(authz.MemberRemove), string(authz.MemberManageRoles)), admin))
+}
+
+// MockUserRepository is a mock implementation of UserRepository
+type MockUserRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserRepository) Create(user *models.User) error {
+	args := m.Called(user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) FindByEmail(email string) (*models.User, error) {
+	args := m.Called(email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) FindByID(id uuid.UUID) (*models.User, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) Update(user *models.User) error {
+	args := m.Called(user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdateStatus(userID uuid.UUID, status string) error {
+	args := m.Called(userID, status)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) FindByUsername(username string) (*models.User, error) {
+	args := m.Called(username)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) FindByIDs(ids []uuid.UUID) ([]*models.User, error) {
+	args := m.Called(ids)
+	return args.Get(0).([]*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) FindWorkspaceMembersByUsernames(workspaceID uuid.UUID, usernames []string) ([]*models.User, error) {
+	args := m.Called(workspaceID, usernames)
+	return args.Get(0).([]*models.User), args.Error(1)
+}
+
+func TestTransferOwnership(t *testing.T) {
+	mockRepo := new(MockWorkspaceRepository)
+	mockUsers := new(MockUserRepository)
+	svc := NewWorkspaceService(mockRepo, nil, mockUsers, nil, authz.New(mockRepo, nil), nil)
+
+	ownerID, adminID, memberID := uuid.New(), uuid.New(), uuid.New()
+	wsID := uuid.New()
+	passwordHash, err := hash.HashPassword("correct horse")
+	assert.NoError(t, err)
+
+	mockRepo.On("FindByID", wsID).Return(&models.Workspace{ID: wsID, OwnerID: ownerID}, nil)
+	mockRepo.On("GetMember", wsID, adminID).Return(&models.WorkspaceMember{UserID: adminID, Role: "admin"}, nil)
+	mockRepo.On("GetMember", wsID, memberID).Return(&models.WorkspaceMember{UserID: memberID, Role: "member"}, nil)
+	mockUsers.On("FindByID", ownerID).Return(&models.User{ID: ownerID, PasswordHash: passwordHash}, nil)
+
+	t.Run("OnlyOwner", func(t *testing.T) {
+		_, err := svc.TransferOwnership(adminID, wsID, &dto.TransferOwnershipRequest{NewOwnerID: adminID, Password: "correct horse"})
+		assert.Equal(t, ErrUnauthorized, err)
+	})
+
+	t.Run("TargetMustBeAdmin", func(t *testing.T) {
+		_, err := svc.TransferOwnership(ownerID, wsID, &dto.TransferOwnershipRequest{NewOwnerID: memberID, Password: "correct horse"})
+		assert.Equal(t, ErrTransferTarget, err)
+	})
+
+	t.Run("WrongPassword", func(t *testing.T) {
+		_, err := svc.TransferOwnership(ownerID, wsID, &dto.TransferOwnershipRequest{NewOwnerID: adminID, Password: "wrong"})
+		assert.Equal(t, ErrIncorrectPassword, err)
+	})
+
+	t.Run("NoTOTP", func(t *testing.T) {
+		_, err := svc.TransferOwnership(ownerID, wsID, &dto.TransferOwnershipRequest{NewOwnerID: adminID, TOTPCode: "123456"})
+		assert.Equal(t, ErrTOTPUnsupported, err)
+		_, err = svc.TransferOwnership(ownerID, wsID, &dto.TransferOwnershipRequest{NewOwnerID: adminID})
+		assert.Equal(t, ErrIncorrectPassword, err)
+	})
+
+	mockRepo.AssertNotCalled(t, "TransferOwnership", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSetGuestExpiry(t *testing.T) {
+	mockRepo := new(MockWorkspaceRepository)
+	svc := NewWorkspaceService(mockRepo, nil, nil, nil, authz.New(mockRepo, nil), nil)
+
+	wsID := uuid.New()
+	admin := &models.WorkspaceMember{WorkspaceID: wsID, UserID: uuid.New(), Role: "admin"}
+	member := &models.WorkspaceMember{WorkspaceID: wsID, UserID: uuid.New(), Role: "member"}
+	guest := &models.WorkspaceMember{WorkspaceID: wsID, UserID: uuid.New(), Role: "single_channel_guest"}
+	for _, m := range []*models.WorkspaceMember{admin, member, guest} {
+		mockRepo.On("GetMember", wsID, m.UserID).Return(m, nil)
+	}
+	tomorrow := time.Now().Add(24 * time.Hour)
+	yesterday := time.Now().Add(-24 * time.Hour)
+
+	_, err := svc.SetGuestExpiry(member.UserID, wsID, guest.UserID, &tomorrow)
+	assert.Equal(t, ErrUnauthorized, err)
+
+	_, err = svc.SetGuestExpiry(admin.UserID, wsID, member.UserID, &tomorrow)
+	assert.Equal(t, ErrNotGuest, err)
+
+	_, err = svc.SetGuestExpiry(admin.UserID, wsID, guest.UserID, &yesterday)
+	assert.Equal(t, ErrGuestExpiry, err)
+	mockRepo.AssertNotCalled(t, "SetGuestExpiry", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -23,6 +23,9 @@ type Hub struct {
 	// Map of workspace_id/channel_id to clients in that "room"
 	rooms map[string]map[*Client]bool
 
+	// Callbacks run for every broadcast message
+	listeners []func(*WSMessage)
+
 	mu sync.RWMutex
 }
 
@@ -131,7 +134,101 @@ func (h *Hub) LeaveRoom(roomType string, id uuid.UUID, client *Client) {
 	}
 }
 
+// AddUserToRoom adds all of the user's open sockets to a room
+func (h *Hub) AddUserToRoom(roomType string, id uuid.UUID, userID uuid.UUID) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	roomID := roomType + ":" + id.String()
+	for client := range h.clients {
+		if client.userID != userID {
+			continue
+		}
+		if h.rooms[roomID] == nil {
+			h.rooms[roomID] = make(map[*Client]bool)
+		}
+		h.rooms[roomID][client] = true
+	}
+}
+
+// RemoveUserFromRoom removes all of the user's open sockets from a room
+func (h *Hub) RemoveUserFromRoom(roomType string, id uuid.UUID, userID uuid.UUID) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	roomID := roomType + ":" + id.String()
+	for client := range h.rooms[roomID] {
+		if client.userID == userID {
+			delete(h.rooms[roomID], client)
+		}
+	}
+	if len(h.rooms[roomID]) == 0 {
+		delete(h.rooms, roomID)
+	}
+}
+
+// RestrictRoom removes every socket whose user is not in allowed from a room,
+// and returns the users that were removed
+func (h *Hub) RestrictRoom(roomType string, id uuid.UUID, allowed map[uuid.UUID]bool) []uuid.UUID {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	roomID := roomType + ":" + id.String()
+	removed := make(map[uuid.UUID]bool)
+	for client := range h.rooms[roomID] {
+		if !allowed[client.userID] {
+			delete(h.rooms[roomID], client)
+			removed[client.userID] = true
+		}
+	}
+	if len(h.rooms[roomID]) == 0 {
+		delete(h.rooms, roomID)
+	}
+
+	userIDs := make([]uuid.UUID, 0, len(removed))
+	for userID := range removed {
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs
+}
+
+// CloseRoom removes every socket from a room
+func (h *Hub) CloseRoom(roomType string, id uuid.UUID) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.rooms, roomType+":"+id.String())
+}
+
+// AddListener registers fn to be called with every broadcast message, on the
+// broadcasting goroutine; fn must not block.
+func (h *Hub) AddListener(fn func(*WSMessage)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.listeners = append(h.listeners, fn)
+}
+
 // Broadcast sends a message to the hub for distribution
 func (h *Hub) Broadcast(message *WSMessage) {
+	h.mu.RLock()
+	listeners := h.listeners
+	h.mu.RUnlock()
+
+	for _, fn := range listeners {
+		fn(message)
+	}
 	h.broadcast <- message
 }
+
+// IsUserConnected reports whether the user has at least one open socket
+func (h *Hub) IsUserConnected(userID uuid.UUID) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for client := range h.clients {
+		if client.userID == userID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package websocket
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestClient(hub *Hub, userID uuid.UUID) *Client {
+	client := &Client{hub: hub, userID: userID, send: make(chan *WSMessage, 4)}
+	hub.clients[client] = true
+	return client
+}
+
+func TestRestrictRoom(t *testing.T) {
+	hub := NewHub()
+	channelID, memberID, outsiderID := uuid.New(), uuid.New(), uuid.New()
+	member := newTestClient(hub, memberID)
+	outsider := newTestClient(hub, outsiderID)
+	hub.AddUserToRoom("channel", channelID, memberID)
+	hub.AddUserToRoom("channel", channelID, outsiderID)
+
+	revoked := hub.RestrictRoom("channel", channelID, map[uuid.UUID]bool{memberID: true})
+	assert.Equal(t, []uuid.UUID{outsiderID}, revoked)
+
+	// Channel messages only reach the sockets left in the room
+	hub.handleBroadcast(&WSMessage{Type: EventMessageNew, ChannelID: &channelID})
+	assert.Len(t, member.send, 1)
+	assert.Len(t, outsider.send, 0)
+
+	// while the users who were cut off can still be told directly
+	hub.handleBroadcast(&WSMessage{Type: EventChannelUpdated, UserID: &outsiderID})
+	assert.Len(t, outsider.send, 1)
+}
@@ -8,15 +8,30 @@ import (
 
 // Event types
 const (
-	EventMessageNew      = "message.new"
-	EventMessageUpdated  = "message.updated"
-	EventMessageDeleted  = "message.deleted"
-	EventUserTyping      = "user.typing"
-	EventUserPresence    = "user.presence"
-	EventChannelJoined   = "channel.joined"
-	EventWorkspaceJoined = "workspace.joined"
-	EventReactionAdded   = "reaction.added"
-	EventReactionRemoved = "reaction.removed"
+	EventMessageNew             = "message.new"
+	EventMessageUpdated         = "message.updated"
+	EventMessageDeleted         = "message.deleted"
+	EventUserTyping             = "user.typing"
+	EventUserPresence           = "user.presence"
+	EventChannelJoined          = "channel.joined"
+	EventChannelLeft            = "channel.left"
+	EventChannelUpdated         = "channel.updated"
+	EventChannelArchived        = "channel.archived"
+	EventChannelUnarchived      = "channel.unarchived"
+	EventWorkspaceJoined        = "workspace.joined"
+	EventWorkspaceMemberUpdated = "workspace.member_updated"
+	EventWorkspaceMemberRemoved = "workspace.member_removed"
+	EventWorkspaceDeleted       = "workspace.deleted"
+	EventWorkspaceRestored      = "workspace.restored"
+	EventReactionAdded          = "reaction.added"
+	EventReactionRemoved        = "reaction.removed"
+	EventSavedReminder          = "saved_item.reminder"
+	EventThreadReply            = "thread.reply"
+	EventMentionNew             = "mention.new"
+	EventNotificationNew        = "notification.new"
+	EventNotificationRead       = "notification.read"
+	EventDNDUpdated             = "dnd.updated"
+	EventSidebarUpdated         = "sidebar.updated"
 )
 
 // WSMessage represents the structure of messages sent over WebSocket
@@ -46,4 +61,45 @@ type TypingPayload struct {
 type PresencePayload struct {
 	UserID uuid.UUID `json:"user_id"`
 	Status string    `json:"status"` // online, offline, away
+	DND    bool      `json:"dnd"`
+}
+
+// ThreadReplyPayload represents the payload sent to thread followers
+type ThreadReplyPayload struct {
+	ThreadID uuid.UUID   `json:"thread_id"`
+	Message  interface{} `json:"message"`
+}
+
+// MentionPayload represents the payload sent to a mentioned user
+type MentionPayload struct {
+	MentionType string      `json:"mention_type"` // user, channel, here, everyone
+	Message     interface{} `json:"message"`
+}
+
+// ChannelMemberPayload represents a user joining or leaving a channel. ActorID
+// is who added or removed them, when it wasn't the user themselves.
+type ChannelMemberPayload struct {
+	ChannelID uuid.UUID  `json:"channel_id"`
+	UserID    uuid.UUID  `json:"user_id"`
+	ActorID   *uuid.UUID `json:"actor_id,omitempty"`
+}
+
+// NotificationPayload represents the payload for a new activity feed item
+type NotificationPayload struct {
+	Notification interface{} `json:"notification"`
+	UnreadCount  int         `json:"unread_count"`
+}
+
+// NotificationReadPayload carries the unread count after notifications are read
+type NotificationReadPayload struct {
+	UnreadCount int `json:"unread_count"`
+}
+
+// WorkspaceMemberPayload represents a user joining or leaving a workspace, or
+// a change to their role
+type WorkspaceMemberPayload struct {
+	WorkspaceID uuid.UUID  `json:"workspace_id"`
+	UserID      uuid.UUID  `json:"user_id"`
+	Role        string     `json:"role"`
+	ActorID     *uuid.UUID `json:"actor_id,omitempty"`
 }
@@ -0,0 +1,3 @@
+-- Drop saved items table
+DROP TRIGGER IF EXISTS update_saved_items_updated_at ON saved_items;
+DROP TABLE IF EXISTS saved_items;
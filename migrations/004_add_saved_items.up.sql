@@ -0,0 +1,20 @@
+-- Saved items (bookmarked messages) with optional reminders
+CREATE TABLE saved_items (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    message_id UUID NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    workspace_id UUID NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'in_progress' CHECK (status IN ('in_progress', 'completed')),
+    remind_at TIMESTAMP,
+    reminded_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(user_id, message_id)
+);
+
+CREATE INDEX idx_saved_items_user_workspace ON saved_items(user_id, workspace_id, status, created_at DESC);
+CREATE INDEX idx_saved_items_due ON saved_items(remind_at) WHERE remind_at IS NOT NULL AND reminded_at IS NULL;
+
+CREATE TRIGGER update_saved_items_updated_at BEFORE UPDATE ON saved_items
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,3 @@
+-- Drop thread subscriptions table
+DROP TRIGGER IF EXISTS update_thread_subscriptions_updated_at ON thread_subscriptions;
+DROP TABLE IF EXISTS thread_subscriptions;
@@ -0,0 +1,18 @@
+-- Thread followers and per-thread read markers
+CREATE TABLE thread_subscriptions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    thread_id UUID NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    following BOOLEAN NOT NULL DEFAULT true,
+    unfollowed_at TIMESTAMP,
+    last_read_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(thread_id, user_id)
+);
+
+CREATE INDEX idx_thread_subscriptions_user ON thread_subscriptions(user_id) WHERE following = true;
+CREATE INDEX idx_thread_subscriptions_thread ON thread_subscriptions(thread_id) WHERE following = true;
+
+CREATE TRIGGER update_thread_subscriptions_updated_at BEFORE UPDATE ON thread_subscriptions
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
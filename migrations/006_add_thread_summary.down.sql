@@ -0,0 +1,8 @@
+-- Remove thread summary columns
+DROP INDEX IF EXISTS idx_messages_channel_broadcast;
+
+ALTER TABLE messages
+    DROP COLUMN IF EXISTS also_sent_to_channel,
+    DROP COLUMN IF EXISTS reply_user_ids,
+    DROP COLUMN IF EXISTS last_reply_at,
+    DROP COLUMN IF EXISTS reply_count;
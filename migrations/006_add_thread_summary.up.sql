@@ -0,0 +1,34 @@
+-- Denormalized thread summary and "also send to channel" replies
+ALTER TABLE messages
+    ADD COLUMN reply_count INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN last_reply_at TIMESTAMP,
+    ADD COLUMN reply_user_ids UUID[] NOT NULL DEFAULT '{}',
+    ADD COLUMN also_sent_to_channel BOOLEAN NOT NULL DEFAULT false;
+
+-- Backfill existing threads without touching updated_at
+ALTER TABLE messages DISABLE TRIGGER update_messages_updated_at;
+
+UPDATE messages p
+SET reply_count = s.reply_count, last_reply_at = s.last_reply_at
+FROM (
+    SELECT parent_message_id, COUNT(*) AS reply_count, MAX(created_at) AS last_reply_at
+    FROM messages
+    WHERE parent_message_id IS NOT NULL AND deleted_at IS NULL
+    GROUP BY parent_message_id
+) s
+WHERE p.id = s.parent_message_id;
+
+UPDATE messages p
+SET reply_user_ids = ARRAY(
+    SELECT r.sender_id
+    FROM messages r
+    WHERE r.parent_message_id = p.id AND r.deleted_at IS NULL AND r.sender_id IS NOT NULL
+    GROUP BY r.sender_id
+    ORDER BY MAX(r.created_at) DESC
+    LIMIT 5
+)
+WHERE p.reply_count > 0;
+
+ALTER TABLE messages ENABLE TRIGGER update_messages_updated_at;
+
+CREATE INDEX idx_messages_channel_broadcast ON messages(channel_id, created_at DESC) WHERE also_sent_to_channel = true;
@@ -0,0 +1,3 @@
+-- Drop mentions and workspace settings
+DROP TABLE IF EXISTS workspace_settings;
+DROP TABLE IF EXISTS message_mentions;
@@ -0,0 +1,18 @@
+-- Resolved mentions per message (one row per mentioned user)
+CREATE TABLE message_mentions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    message_id UUID NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    mention_type VARCHAR(20) NOT NULL DEFAULT 'user' CHECK (mention_type IN ('user', 'channel', 'here', 'everyone')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(message_id, user_id)
+);
+
+CREATE INDEX idx_message_mentions_user ON message_mentions(user_id, created_at DESC);
+
+-- Per-workspace settings
+CREATE TABLE workspace_settings (
+    workspace_id UUID PRIMARY KEY REFERENCES workspaces(id) ON DELETE CASCADE,
+    broadcast_mention_policy VARCHAR(20) NOT NULL DEFAULT 'everyone' CHECK (broadcast_mention_policy IN ('everyone', 'admins')),
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
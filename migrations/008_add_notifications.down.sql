@@ -0,0 +1,2 @@
+-- Drop activity feed
+DROP TABLE IF EXISTS notifications;
@@ -0,0 +1,10 @@
+-- Drop notification preferences
+DELETE FROM notifications WHERE type IN ('message', 'keyword');
+ALTER TABLE notifications DROP CONSTRAINT notifications_type_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_type_check
+    CHECK (type IN ('mention', 'reaction', 'thread_reply', 'invite_accepted'));
+
+ALTER TABLE workspace_settings DROP COLUMN IF EXISTS default_notification_level;
+
+DROP TABLE IF EXISTS user_notification_settings;
+DROP TABLE IF EXISTS notification_preferences;
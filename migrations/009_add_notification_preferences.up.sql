@@ -0,0 +1,41 @@
+-- Per-channel and per-DM notification preferences
+CREATE TABLE notification_preferences (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    channel_id UUID REFERENCES channels(id) ON DELETE CASCADE,
+    dm_id UUID REFERENCES direct_messages(id) ON DELETE CASCADE,
+    level VARCHAR(20) CHECK (level IN ('all', 'mentions', 'nothing')), -- NULL inherits the user's default
+    muted BOOLEAN NOT NULL DEFAULT false,
+    muted_until TIMESTAMP, -- NULL keeps a muted conversation muted indefinitely
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((channel_id IS NULL) <> (dm_id IS NULL)),
+    UNIQUE(user_id, channel_id),
+    UNIQUE(user_id, dm_id)
+);
+
+CREATE INDEX idx_notification_preferences_channel ON notification_preferences(channel_id) WHERE channel_id IS NOT NULL;
+CREATE INDEX idx_notification_preferences_dm ON notification_preferences(dm_id) WHERE dm_id IS NOT NULL;
+
+CREATE TRIGGER update_notification_preferences_updated_at BEFORE UPDATE ON notification_preferences
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Per-user defaults and keyword alerts within a workspace
+CREATE TABLE user_notification_settings (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    workspace_id UUID NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    default_level VARCHAR(20) CHECK (default_level IN ('all', 'mentions', 'nothing')), -- NULL inherits the workspace default
+    keywords TEXT[] NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, workspace_id)
+);
+
+-- Workspace-wide default for channels
+ALTER TABLE workspace_settings
+    ADD COLUMN default_notification_level VARCHAR(20) NOT NULL DEFAULT 'mentions'
+    CHECK (default_notification_level IN ('all', 'mentions', 'nothing'));
+
+-- Feed items for new messages and keyword alerts
+ALTER TABLE notifications DROP CONSTRAINT notifications_type_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_type_check
+    CHECK (type IN ('mention', 'reaction', 'thread_reply', 'invite_accepted', 'message', 'keyword'));
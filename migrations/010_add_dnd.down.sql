@@ -0,0 +1,6 @@
+-- Drop Do Not Disturb
+DROP INDEX IF EXISTS idx_notifications_held;
+ALTER TABLE notifications DROP COLUMN IF EXISTS delivered_at;
+ALTER TABLE notifications DROP COLUMN IF EXISTS urgent;
+ALTER TABLE workspace_settings DROP COLUMN IF EXISTS urgent_override_policy;
+DROP TABLE IF EXISTS user_dnd_settings;
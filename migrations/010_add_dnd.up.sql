@@ -0,0 +1,23 @@
+-- Do Not Disturb: weekly quiet hours and ad-hoc snooze
+CREATE TABLE user_dnd_settings (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    schedule_enabled BOOLEAN NOT NULL DEFAULT false,
+    schedule JSONB NOT NULL DEFAULT '[]', -- [{"day": 0-6, "start": "HH:MM", "end": "HH:MM"}]
+    snooze_until TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Who may send urgent messages that break through DND
+ALTER TABLE workspace_settings
+    ADD COLUMN urgent_override_policy VARCHAR(20) NOT NULL DEFAULT 'disabled'
+    CHECK (urgent_override_policy IN ('disabled', 'admins', 'everyone'));
+
+-- Notifications created during DND are held until it ends
+ALTER TABLE notifications
+    ADD COLUMN urgent BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN delivered_at TIMESTAMP;
+
+UPDATE notifications SET delivered_at = created_at;
+
+CREATE INDEX idx_notifications_held ON notifications(user_id, created_at) WHERE delivered_at IS NULL;
@@ -0,0 +1,3 @@
+-- Drop email digests
+DROP INDEX IF EXISTS idx_users_last_seen;
+DROP TABLE IF EXISTS email_digest_settings;
@@ -0,0 +1,3 @@
+-- Drop Web Push tables
+DROP TABLE IF EXISTS push_jobs;
+DROP TABLE IF EXISTS push_subscriptions;
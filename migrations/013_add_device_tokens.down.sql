@@ -0,0 +1,2 @@
+-- Drop mobile device tokens
+DROP TABLE IF EXISTS device_tokens;
@@ -0,0 +1,3 @@
+-- Drop channel archiving
+ALTER TABLE channels DROP COLUMN IF EXISTS archived_by;
+ALTER TABLE channels DROP COLUMN IF EXISTS archived_at;
@@ -0,0 +1,3 @@
+-- Channel archiving; archived channels are read-only but keep their history
+ALTER TABLE channels ADD COLUMN archived_at TIMESTAMP;
+ALTER TABLE channels ADD COLUMN archived_by UUID REFERENCES users(id) ON DELETE SET NULL;
@@ -0,0 +1,3 @@
+-- Drop default channels
+DROP INDEX IF EXISTS idx_channels_default;
+ALTER TABLE channels DROP COLUMN IF EXISTS is_default;
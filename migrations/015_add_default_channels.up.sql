@@ -0,0 +1,4 @@
+-- Default channels; new workspace members are added to them automatically
+ALTER TABLE channels ADD COLUMN is_default BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX idx_channels_default ON channels(workspace_id) WHERE is_default = true;
@@ -0,0 +1,5 @@
+-- Drop channel topics, system messages and the channel change history
+DROP TABLE IF EXISTS channel_changes;
+ALTER TABLE messages DROP COLUMN IF EXISTS metadata;
+ALTER TABLE messages DROP COLUMN IF EXISTS type;
+ALTER TABLE channels DROP COLUMN IF EXISTS topic;
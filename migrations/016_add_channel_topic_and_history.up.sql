@@ -0,0 +1,18 @@
+-- Channel topics, system messages and the channel change history
+ALTER TABLE channels ADD COLUMN topic VARCHAR(250);
+
+ALTER TABLE messages ADD COLUMN type VARCHAR(20) NOT NULL DEFAULT 'user' CHECK (type IN ('user', 'system'));
+ALTER TABLE messages ADD COLUMN metadata JSONB; -- structured details of a system message
+
+CREATE TABLE channel_changes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    channel_id UUID NOT NULL REFERENCES channels(id) ON DELETE CASCADE,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    field VARCHAR(30) NOT NULL, -- name, description, topic, is_private, is_default, archived
+    old_value TEXT,
+    new_value TEXT,
+    message_id UUID REFERENCES messages(id) ON DELETE SET NULL, -- the system message announcing it
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_channel_changes_channel ON channel_changes(channel_id, created_at DESC);
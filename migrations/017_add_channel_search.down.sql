@@ -0,0 +1,3 @@
+-- Drop channel directory search indexes
+DROP INDEX IF EXISTS idx_channels_topic_trgm;
+DROP INDEX IF EXISTS idx_channels_name_trgm;
@@ -0,0 +1,5 @@
+-- Trigram indexes for searching the channel directory by name and topic
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX idx_channels_name_trgm ON channels USING GIN (name gin_trgm_ops);
+CREATE INDEX idx_channels_topic_trgm ON channels USING GIN (topic gin_trgm_ops);
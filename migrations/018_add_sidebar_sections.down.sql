@@ -0,0 +1,5 @@
+-- Drop sidebar sections and starred conversations
+DROP TABLE IF EXISTS starred_conversations;
+DROP TABLE IF EXISTS sidebar_items;
+DROP TRIGGER IF EXISTS update_sidebar_sections_updated_at ON sidebar_sections;
+DROP TABLE IF EXISTS sidebar_sections;
@@ -0,0 +1,47 @@
+-- Per-user sidebar sections and starred conversations
+CREATE TABLE sidebar_sections (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    workspace_id UUID NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    name VARCHAR(80) NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    collapsed BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_sidebar_sections_user ON sidebar_sections(user_id, workspace_id, position);
+
+CREATE TRIGGER update_sidebar_sections_updated_at BEFORE UPDATE ON sidebar_sections
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- A conversation sits in at most one of a user's sections. Deleting the
+-- channel or DM removes it from sections automatically.
+CREATE TABLE sidebar_items (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    section_id UUID NOT NULL REFERENCES sidebar_sections(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    channel_id UUID REFERENCES channels(id) ON DELETE CASCADE,
+    dm_id UUID REFERENCES direct_messages(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((channel_id IS NULL) <> (dm_id IS NULL)),
+    UNIQUE(user_id, channel_id),
+    UNIQUE(user_id, dm_id)
+);
+
+CREATE INDEX idx_sidebar_items_section ON sidebar_items(section_id, position);
+
+CREATE TABLE starred_conversations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    workspace_id UUID NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    channel_id UUID REFERENCES channels(id) ON DELETE CASCADE,
+    dm_id UUID REFERENCES direct_messages(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((channel_id IS NULL) <> (dm_id IS NULL)),
+    UNIQUE(user_id, channel_id),
+    UNIQUE(user_id, dm_id)
+);
+
+CREATE INDEX idx_starred_conversations_user ON starred_conversations(user_id, workspace_id);
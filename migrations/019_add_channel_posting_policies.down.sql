@@ -0,0 +1,4 @@
+-- Drop channel posting policies
+DROP TABLE IF EXISTS channel_posters;
+ALTER TABLE channels DROP COLUMN IF EXISTS reply_policy;
+ALTER TABLE channels DROP COLUMN IF EXISTS posting_policy;
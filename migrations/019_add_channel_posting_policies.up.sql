@@ -0,0 +1,13 @@
+-- Channel posting policies: who can post top-level messages and who can reply in threads
+ALTER TABLE channels ADD COLUMN posting_policy VARCHAR(20) NOT NULL DEFAULT 'everyone'
+    CHECK (posting_policy IN ('everyone', 'admins', 'allowlist'));
+ALTER TABLE channels ADD COLUMN reply_policy VARCHAR(20) NOT NULL DEFAULT 'everyone'
+    CHECK (reply_policy IN ('everyone', 'admins', 'allowlist'));
+
+-- Members allowed to post when a policy is 'allowlist'; workspace admins always can
+CREATE TABLE channel_posters (
+    channel_id UUID NOT NULL REFERENCES channels(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (channel_id, user_id)
+);
@@ -0,0 +1,2 @@
+-- Drop the workspace audit log
+DROP TABLE IF EXISTS audit_log;
@@ -0,0 +1,12 @@
+-- Workspace audit log of sensitive administrative actions
+CREATE TABLE audit_log (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    workspace_id UUID NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    action VARCHAR(50) NOT NULL, -- e.g. workspace.ownership_transferred, member.role_changed, member.removed
+    target_id UUID, -- the user or object acted on
+    metadata JSONB,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_audit_log_workspace ON audit_log(workspace_id, created_at DESC);
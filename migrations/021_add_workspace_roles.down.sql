@@ -0,0 +1,3 @@
+-- Drop custom workspace roles
+ALTER TABLE workspace_members DROP COLUMN IF EXISTS custom_role_id;
+DROP TABLE IF EXISTS workspace_roles;
@@ -0,0 +1,16 @@
+-- Custom workspace roles; they grant permissions on top of a member's built-in role
+CREATE TABLE workspace_roles (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    workspace_id UUID NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    description VARCHAR(255),
+    permissions TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(workspace_id, name)
+);
+
+CREATE TRIGGER update_workspace_roles_updated_at BEFORE UPDATE ON workspace_roles
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+ALTER TABLE workspace_members ADD COLUMN custom_role_id UUID REFERENCES workspace_roles(id) ON DELETE SET NULL;
@@ -0,0 +1,11 @@
+-- Drop guest accounts; remaining guests are removed from their workspaces
+ALTER TABLE workspace_invites DROP COLUMN IF EXISTS guest_expires_at;
+ALTER TABLE workspace_invites DROP COLUMN IF EXISTS channel_ids;
+ALTER TABLE workspace_invites DROP COLUMN IF EXISTS role;
+
+DROP INDEX IF EXISTS idx_workspace_members_guest_expiry;
+ALTER TABLE workspace_members DROP COLUMN IF EXISTS guest_expires_at;
+DELETE FROM workspace_members WHERE role IN ('multi_channel_guest', 'single_channel_guest');
+ALTER TABLE workspace_members DROP CONSTRAINT IF EXISTS workspace_members_role_check;
+ALTER TABLE workspace_members ADD CONSTRAINT workspace_members_role_check
+    CHECK (role IN ('owner', 'admin', 'member'));
@@ -0,0 +1,13 @@
+-- Guest roles; guests only see the channels they were added to and lose access at guest_expires_at
+ALTER TABLE workspace_members DROP CONSTRAINT IF EXISTS workspace_members_role_check;
+ALTER TABLE workspace_members ADD CONSTRAINT workspace_members_role_check
+    CHECK (role IN ('owner', 'admin', 'member', 'multi_channel_guest', 'single_channel_guest'));
+ALTER TABLE workspace_members ADD COLUMN guest_expires_at TIMESTAMP;
+
+CREATE INDEX idx_workspace_members_guest_expiry ON workspace_members(guest_expires_at)
+    WHERE guest_expires_at IS NOT NULL;
+
+-- Guest invites carry the role, the channels to join and the access expiry
+ALTER TABLE workspace_invites ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'member';
+ALTER TABLE workspace_invites ADD COLUMN channel_ids UUID[] NOT NULL DEFAULT '{}';
+ALTER TABLE workspace_invites ADD COLUMN guest_expires_at TIMESTAMP;
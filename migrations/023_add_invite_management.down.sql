@@ -0,0 +1,6 @@
+-- Drop email invites, invite revocation and the domain allowlist
+ALTER TABLE workspace_settings DROP COLUMN IF EXISTS allowed_email_domains;
+
+ALTER TABLE workspace_invites DROP COLUMN IF EXISTS revoked_by;
+ALTER TABLE workspace_invites DROP COLUMN IF EXISTS revoked_at;
+ALTER TABLE workspace_invites DROP COLUMN IF EXISTS email;
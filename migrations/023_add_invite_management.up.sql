@@ -0,0 +1,7 @@
+-- Email invites are tied to one address; revoked invites can no longer be used
+ALTER TABLE workspace_invites ADD COLUMN email VARCHAR(255);
+ALTER TABLE workspace_invites ADD COLUMN revoked_at TIMESTAMP;
+ALTER TABLE workspace_invites ADD COLUMN revoked_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+-- Anyone whose email is at one of these domains can join without an invite
+ALTER TABLE workspace_settings ADD COLUMN allowed_email_domains TEXT[] NOT NULL DEFAULT '{}';
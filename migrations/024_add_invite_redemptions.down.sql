@@ -0,0 +1,2 @@
+-- Drop invite redemptions
+DROP TABLE IF EXISTS workspace_invite_redemptions;
@@ -0,0 +1,10 @@
+-- Who joined through each invite; written in the same transaction that counts the use
+CREATE TABLE workspace_invite_redemptions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    invite_id UUID NOT NULL REFERENCES workspace_invites(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    redeemed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(invite_id, user_id)
+);
+
+CREATE INDEX idx_invite_redemptions_invite ON workspace_invite_redemptions(invite_id, redeemed_at DESC);
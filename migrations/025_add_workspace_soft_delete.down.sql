@@ -0,0 +1,8 @@
+-- Drop workspace soft deletion; workspaces still waiting to be torn down are deleted outright
+DROP TABLE IF EXISTS workspace_teardowns;
+
+DELETE FROM workspaces WHERE deleted_at IS NOT NULL;
+DROP INDEX IF EXISTS idx_workspaces_purge_after;
+ALTER TABLE workspaces DROP COLUMN IF EXISTS purge_after;
+ALTER TABLE workspaces DROP COLUMN IF EXISTS deleted_by;
+ALTER TABLE workspaces DROP COLUMN IF EXISTS deleted_at;
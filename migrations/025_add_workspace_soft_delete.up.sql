@@ -0,0 +1,23 @@
+-- Deleted workspaces are hidden at once and can be restored until purge_after,
+-- after which a background job tears them down in batches
+ALTER TABLE workspaces ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE workspaces ADD COLUMN deleted_by UUID REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE workspaces ADD COLUMN purge_after TIMESTAMP;
+
+CREATE INDEX idx_workspaces_purge_after ON workspaces(purge_after) WHERE deleted_at IS NOT NULL;
+
+-- Teardown progress; kept after the workspace row is gone so the owner can see it finished
+CREATE TABLE workspace_teardowns (
+    workspace_id UUID PRIMARY KEY,
+    workspace_name VARCHAR(100) NOT NULL,
+    owner_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'running' CHECK (status IN ('running', 'completed')),
+    step VARCHAR(20) NOT NULL DEFAULT 'files',
+    rows_deleted BIGINT NOT NULL DEFAULT 0,
+    files_deleted INTEGER NOT NULL DEFAULT 0,
+    files_failed INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    started_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP
+);
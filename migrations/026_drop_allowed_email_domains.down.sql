@@ -0,0 +1 @@
+ALTER TABLE workspace_settings ADD COLUMN allowed_email_domains TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,2 @@
+-- Joining by email domain is off until email addresses are verified
+ALTER TABLE workspace_settings DROP COLUMN IF EXISTS allowed_email_domains;
@@ -0,0 +1,203 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	apnsProductionURL = "https://api.push.apple.com"
+	apnsSandboxURL    = "https://api.sandbox.push.apple.com"
+
+	// APNs rejects provider tokens older than an hour and throttles refreshing
+	// them more often than every 20 minutes.
+	apnsTokenLifetime = 50 * time.Minute
+)
+
+// APNsConfig configures token-based (.p8 key) authentication with APNs.
+type APNsConfig struct {
+	KeyPEM     string // contents of the .p8 signing key
+	KeyID      string
+	TeamID     string
+	Topic      string // the app's bundle ID
+	Production bool
+	Endpoint   string // overrides the APNs host, for tests
+}
+
+// APNsProvider sends notifications to iOS devices.
+type APNsProvider struct {
+	key      *ecdsa.PrivateKey
+	keyID    string
+	teamID   string
+	topic    string
+	endpoint string
+	client   *http.Client
+
+	mu       sync.Mutex
+	token    string
+	issuedAt time.Time
+}
+
+// NewAPNsProvider creates an APNs provider. The default client negotiates
+// HTTP/2, which APNs requires.
+func NewAPNsProvider(cfg APNsConfig, client *http.Client) (*APNsProvider, error) {
+	key, err := jwt.ParseECPrivateKeyFromPEM([]byte(cfg.KeyPEM))
+	if err != nil {
+		return nil, fmt.Errorf("invalid APNs key: %w", err)
+	}
+	if cfg.KeyID == "" || cfg.TeamID == "" || cfg.Topic == "" {
+		return nil, fmt.Errorf("APNs key ID, team ID and topic are required")
+	}
+
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = apnsSandboxURL
+		if cfg.Production {
+			endpoint = apnsProductionURL
+		}
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &APNsProvider{
+		key:      key,
+		keyID:    cfg.KeyID,
+		teamID:   cfg.TeamID,
+		topic:    cfg.Topic,
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		client:   client,
+	}, nil
+}
+
+func (p *APNsProvider) Platform() string {
+	return PlatformIOS
+}
+
+type apnsAlert struct {
+	Title string `json:"title,omitempty"`
+	Body  string `json:"body,omitempty"`
+}
+
+type apnsAPS struct {
+	Alert             *apnsAlert `json:"alert,omitempty"`
+	Badge             *int       `json:"badge,omitempty"`
+	Sound             string     `json:"sound,omitempty"`
+	ThreadID          string     `json:"thread-id,omitempty"`
+	InterruptionLevel string     `json:"interruption-level,omitempty"`
+}
+
+func (p *APNsProvider) Send(ctx context.Context, token string, n *Notification) error {
+	// Custom data sits next to the aps dictionary
+	payload := map[string]interface{}{}
+	for k, v := range n.Data {
+		payload[k] = v
+	}
+	aps := apnsAPS{
+		Badge:    n.Badge,
+		Sound:    n.Sound,
+		ThreadID: n.ThreadID,
+	}
+	if n.Title != "" || n.Body != "" {
+		aps.Alert = &apnsAlert{Title: n.Title, Body: n.Body}
+	}
+	if n.Urgent {
+		aps.InterruptionLevel = "time-sensitive"
+	}
+	payload["aps"] = aps
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	authToken, err := p.providerToken()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint+"/3/device/"+token, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "bearer "+authToken)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("apns-topic", p.topic)
+	req.Header.Set("apns-push-type", "alert")
+	if aps.Alert != nil {
+		req.Header.Set("apns-priority", "10")
+	} else {
+		// Silent badge updates don't need to wake the device
+		req.Header.Set("apns-priority", "5")
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	var apnsErr struct {
+		Reason string `json:"reason"`
+	}
+	json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&apnsErr)
+
+	switch apnsErr.Reason {
+	case "BadDeviceToken", "Unregistered", "DeviceTokenNotForTopic":
+		return ErrInvalidToken
+	case "ExpiredProviderToken":
+		p.resetToken()
+		return &ProviderError{StatusCode: resp.StatusCode, Reason: apnsErr.Reason, Temporary: true}
+	}
+	if resp.StatusCode == http.StatusGone {
+		return ErrInvalidToken
+	}
+	return &ProviderError{StatusCode: resp.StatusCode, Reason: apnsErr.Reason, Temporary: temporaryStatus(resp.StatusCode)}
+}
+
+// providerToken returns the cached ES256 provider token, signing a new one
+// when it is close to expiring.
+func (p *APNsProvider) providerToken() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.token != "" && time.Since(p.issuedAt) < apnsTokenLifetime {
+		return p.token, nil
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.RegisteredClaims{
+		Issuer:   p.teamID,
+		IssuedAt: jwt.NewNumericDate(now),
+	})
+	token.Header["kid"] = p.keyID
+
+	signed, err := token.SignedString(p.key)
+	if err != nil {
+		return "", err
+	}
+	p.token = signed
+	p.issuedAt = now
+	return signed, nil
+}
+
+func (p *APNsProvider) resetToken() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.token = ""
+}
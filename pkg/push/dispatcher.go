@@ -0,0 +1,143 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	dispatchBatchSize = 100
+	dispatchLease     = 2 * time.Minute
+	maxAttempts       = 5
+	retryBase         = 30 * time.Second
+	retryMax          = time.Hour
+)
+
+// Dispatcher queues notifications and delivers them through the provider for
+// each device's platform.
+type Dispatcher struct {
+	queue          Queue
+	providers      map[string]Provider
+	onInvalidToken func(platform, token string)
+}
+
+// NewDispatcher creates a Dispatcher. onInvalidToken, if set, is called when a
+// provider reports a device token as invalid so it can be removed.
+func NewDispatcher(queue Queue, providers []Provider, onInvalidToken func(platform, token string)) *Dispatcher {
+	d := &Dispatcher{
+		queue:          queue,
+		providers:      make(map[string]Provider),
+		onInvalidToken: onInvalidToken,
+	}
+	for _, p := range providers {
+		d.providers[p.Platform()] = p
+	}
+	return d
+}
+
+// Supports reports whether a provider is configured for platform.
+func (d *Dispatcher) Supports(platform string) bool {
+	_, ok := d.providers[platform]
+	return ok
+}
+
+// Enqueue schedules n for delivery to a device.
+func (d *Dispatcher) Enqueue(ctx context.Context, platform, token string, n *Notification) error {
+	if !d.Supports(platform) {
+		return ErrNoProvider
+	}
+
+	now := time.Now().UTC()
+	job := &Job{
+		ID:           uuid.New().String(),
+		Platform:     platform,
+		Token:        token,
+		Notification: *n,
+		EnqueuedAt:   now,
+	}
+	return d.queue.Push(ctx, job, now)
+}
+
+// Process sends due jobs. It returns the number delivered.
+func (d *Dispatcher) Process(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+	jobs, err := d.queue.Claim(ctx, now, dispatchLease, dispatchBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, job := range jobs {
+		if d.send(ctx, job) {
+			delivered++
+		}
+	}
+	return delivered, nil
+}
+
+// send delivers one job and settles it: acknowledged, retried later, or
+// dead-lettered.
+func (d *Dispatcher) send(ctx context.Context, job *Job) bool {
+	provider, ok := d.providers[job.Platform]
+	if !ok {
+		job.LastError = ErrNoProvider.Error()
+		d.deadLetter(ctx, job)
+		return false
+	}
+
+	err := provider.Send(ctx, job.Token, &job.Notification)
+	if err == nil {
+		if err := d.queue.Ack(ctx, job); err != nil {
+			log.Printf("error acknowledging push job %s: %v", job.ID, err)
+		}
+		return true
+	}
+
+	job.Attempts++
+	job.LastError = err.Error()
+
+	if errors.Is(err, ErrInvalidToken) {
+		if err := d.queue.Ack(ctx, job); err != nil {
+			log.Printf("error acknowledging push job %s: %v", job.ID, err)
+		}
+		if d.onInvalidToken != nil {
+			d.onInvalidToken(job.Platform, job.Token)
+		}
+		return false
+	}
+
+	var providerErr *ProviderError
+	retryable := !errors.As(err, &providerErr) || providerErr.Retryable()
+	if !retryable || job.Attempts >= maxAttempts {
+		d.deadLetter(ctx, job)
+		return false
+	}
+
+	if err := d.queue.Push(ctx, job, time.Now().UTC().Add(retryDelay(job.Attempts))); err != nil {
+		log.Printf("error rescheduling push job %s: %v", job.ID, err)
+	}
+	return false
+}
+
+func (d *Dispatcher) deadLetter(ctx context.Context, job *Job) {
+	log.Printf("dead-lettering push job %s after %d attempts: %s", job.ID, job.Attempts, job.LastError)
+	if err := d.queue.DeadLetter(ctx, job); err != nil {
+		log.Printf("error dead-lettering push job %s: %v", job.ID, err)
+	}
+}
+
+// retryDelay doubles from retryBase with each failed attempt, up to retryMax.
+func retryDelay(attempts int) time.Duration {
+	delay := retryBase
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= retryMax {
+			return retryMax
+		}
+	}
+	return delay
+}
@@ -0,0 +1,74 @@
+package push
+
+import (
+	"context"
+	"sync"
+)
+
+// Sent is a notification recorded by FakeProvider.
+type Sent struct {
+	Token        string
+	Notification Notification
+}
+
+// FakeProvider records notifications instead of sending them. It is meant for
+// tests and local development.
+type FakeProvider struct {
+	platform string
+
+	mu   sync.Mutex
+	sent []Sent
+	errs map[string]error
+}
+
+func NewFakeProvider(platform string) *FakeProvider {
+	return &FakeProvider{
+		platform: platform,
+		errs:     make(map[string]error),
+	}
+}
+
+func (p *FakeProvider) Platform() string {
+	return p.platform
+}
+
+func (p *FakeProvider) Send(ctx context.Context, token string, n *Notification) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.errs[token]; err != nil {
+		return err
+	}
+	p.sent = append(p.sent, Sent{Token: token, Notification: *n})
+	return nil
+}
+
+// FailWith makes every send to token return err; nil clears it.
+func (p *FakeProvider) FailWith(token string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err == nil {
+		delete(p.errs, token)
+		return
+	}
+	p.errs[token] = err
+}
+
+// Sent returns a copy of the notifications sent so far.
+func (p *FakeProvider) Sent() []Sent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	sent := make([]Sent, len(p.sent))
+	copy(sent, p.sent)
+	return sent
+}
+
+func (p *FakeProvider) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.sent = nil
+	p.errs = make(map[string]error)
+}
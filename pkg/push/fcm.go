@@ -0,0 +1,249 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	fcmURL   = "https://fcm.googleapis.com"
+	fcmScope = "https://www.googleapis.com/auth/firebase.messaging"
+)
+
+// FCMConfig configures the FCM HTTP v1 API with a service account.
+type FCMConfig struct {
+	CredentialsJSON string // contents of the service account key file
+	Endpoint        string // overrides the FCM host, for tests
+}
+
+type serviceAccount struct {
+	ProjectID   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// FCMProvider sends notifications to Android devices.
+type FCMProvider struct {
+	projectID   string
+	clientEmail string
+	key         *rsa.PrivateKey
+	tokenURI    string
+	endpoint    string
+	client      *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+func NewFCMProvider(cfg FCMConfig, client *http.Client) (*FCMProvider, error) {
+	var account serviceAccount
+	if err := json.Unmarshal([]byte(cfg.CredentialsJSON), &account); err != nil {
+		return nil, fmt.Errorf("invalid FCM credentials: %w", err)
+	}
+	if account.ProjectID == "" || account.ClientEmail == "" || account.TokenURI == "" {
+		return nil, fmt.Errorf("FCM credentials must include project_id, client_email and token_uri")
+	}
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(account.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid FCM private key: %w", err)
+	}
+
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = fcmURL
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &FCMProvider{
+		projectID:   account.ProjectID,
+		clientEmail: account.ClientEmail,
+		key:         key,
+		tokenURI:    account.TokenURI,
+		endpoint:    strings.TrimSuffix(endpoint, "/"),
+		client:      client,
+	}, nil
+}
+
+func (p *FCMProvider) Platform() string {
+	return PlatformAndroid
+}
+
+type fcmMessage struct {
+	Token        string            `json:"token"`
+	Notification *fcmNotification  `json:"notification,omitempty"`
+	Data         map[string]string `json:"data,omitempty"`
+	Android      fcmAndroid        `json:"android"`
+}
+
+type fcmNotification struct {
+	Title string `json:"title,omitempty"`
+	Body  string `json:"body,omitempty"`
+}
+
+type fcmAndroid struct {
+	Priority     string                  `json:"priority"`
+	CollapseKey  string                  `json:"collapse_key,omitempty"`
+	Notification *fcmAndroidNotification `json:"notification,omitempty"`
+}
+
+type fcmAndroidNotification struct {
+	Sound             string `json:"sound,omitempty"`
+	NotificationCount *int   `json:"notification_count,omitempty"`
+}
+
+func (p *FCMProvider) Send(ctx context.Context, token string, n *Notification) error {
+	msg := fcmMessage{
+		Token: token,
+		Data:  map[string]string{},
+		Android: fcmAndroid{
+			Priority:    "NORMAL",
+			CollapseKey: n.ThreadID,
+		},
+	}
+	for k, v := range n.Data {
+		msg.Data[k] = v
+	}
+	if n.Badge != nil {
+		// Launchers read the count from the notification; the app also gets it as data
+		msg.Data["badge"] = strconv.Itoa(*n.Badge)
+	}
+	if n.Title != "" || n.Body != "" {
+		msg.Notification = &fcmNotification{Title: n.Title, Body: n.Body}
+		msg.Android.Priority = "HIGH"
+		msg.Android.Notification = &fcmAndroidNotification{Sound: n.Sound, NotificationCount: n.Badge}
+	}
+
+	body, err := json.Marshal(map[string]fcmMessage{"message": msg})
+	if err != nil {
+		return err
+	}
+
+	accessToken, err := p.token(ctx)
+	if err != nil {
+		return err
+	}
+
+	sendURL := fmt.Sprintf("%s/v1/projects/%s/messages:send", p.endpoint, p.projectID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sendURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	var fcmErr struct {
+		Error struct {
+			Status  string `json:"status"`
+			Message string `json:"message"`
+			Details []struct {
+				ErrorCode string `json:"errorCode"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+	json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&fcmErr)
+
+	reason := fcmErr.Error.Status
+	for _, detail := range fcmErr.Error.Details {
+		if detail.ErrorCode != "" {
+			reason = detail.ErrorCode
+		}
+	}
+
+	switch {
+	case reason == "UNREGISTERED" || reason == "SENDER_ID_MISMATCH" || resp.StatusCode == http.StatusNotFound:
+		return ErrInvalidToken
+	case resp.StatusCode == http.StatusUnauthorized:
+		p.resetToken()
+		return &ProviderError{StatusCode: resp.StatusCode, Reason: reason, Temporary: true}
+	}
+	return &ProviderError{StatusCode: resp.StatusCode, Reason: reason, Temporary: temporaryStatus(resp.StatusCode)}
+}
+
+// token returns a cached OAuth2 access token, exchanging a signed service
+// account assertion for a new one when it is close to expiring.
+func (p *FCMProvider) token(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.accessToken != "" && time.Now().Before(p.expiresAt) {
+		return p.accessToken, nil
+	}
+
+	now := time.Now()
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   p.clientEmail,
+		"scope": fcmScope,
+		"aud":   p.tokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(p.key)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.tokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", &ProviderError{StatusCode: resp.StatusCode, Reason: "token exchange failed: " + string(body), Temporary: temporaryStatus(resp.StatusCode)}
+	}
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+
+	p.accessToken = result.AccessToken
+	p.expiresAt = now.Add(time.Duration(result.ExpiresIn)*time.Second - time.Minute)
+	return p.accessToken, nil
+}
+
+func (p *FCMProvider) resetToken() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.accessToken = ""
+}
@@ -0,0 +1,60 @@
+// Package push delivers notifications to mobile devices through APNs and
+// FCM, with a retrying job queue in front of the providers.
+package push
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Supported device platforms
+const (
+	PlatformIOS     = "ios"
+	PlatformAndroid = "android"
+)
+
+var (
+	// ErrInvalidToken means the device token is unregistered or malformed and
+	// should be removed.
+	ErrInvalidToken = errors.New("invalid device token")
+	ErrNoProvider   = errors.New("no push provider for platform")
+)
+
+// Notification is the platform-neutral content of a push.
+type Notification struct {
+	Title    string            `json:"title,omitempty"`
+	Body     string            `json:"body,omitempty"`
+	Badge    *int              `json:"badge,omitempty"`
+	Sound    string            `json:"sound,omitempty"`
+	ThreadID string            `json:"thread_id,omitempty"` // groups related notifications on the device
+	Data     map[string]string `json:"data,omitempty"`
+	Urgent   bool              `json:"urgent,omitempty"`
+}
+
+// Provider sends notifications for one platform.
+type Provider interface {
+	Platform() string
+	Send(ctx context.Context, token string, n *Notification) error
+}
+
+// ProviderError is returned when a provider rejects a message for a reason
+// other than an invalid token.
+type ProviderError struct {
+	StatusCode int
+	Reason     string
+	Temporary  bool
+}
+
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("push provider responded %d: %s", e.StatusCode, e.Reason)
+}
+
+// Retryable reports whether sending again later may succeed.
+func (e *ProviderError) Retryable() bool {
+	return e.Temporary
+}
+
+func temporaryStatus(status int) bool {
+	return status == 429 || status >= 500
+}
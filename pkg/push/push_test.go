@@ -0,0 +1,198 @@
+package push
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPNsProviderSend(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	var payload map[string]json.RawMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/3/device/gone-token" {
+			w.WriteHeader(http.StatusGone)
+			w.Write([]byte(`{"reason":"Unregistered"}`))
+			return
+		}
+
+		assert.Equal(t, "/3/device/device-token", r.URL.Path)
+		assert.Equal(t, "com.example.chat", r.Header.Get("apns-topic"))
+		assert.Equal(t, "alert", r.Header.Get("apns-push-type"))
+		assert.Equal(t, "10", r.Header.Get("apns-priority"))
+
+		token, err := jwt.Parse(r.Header.Get("Authorization")[len("bearer "):], func(*jwt.Token) (interface{}, error) {
+			return &key.PublicKey, nil
+		}, jwt.WithValidMethods([]string{"ES256"}))
+		require.NoError(t, err)
+		assert.Equal(t, "KEY123", token.Header["kid"])
+		issuer, _ := token.Claims.GetIssuer()
+		assert.Equal(t, "TEAM456", issuer)
+
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	provider, err := NewAPNsProvider(APNsConfig{
+		KeyPEM:   string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		KeyID:    "KEY123",
+		TeamID:   "TEAM456",
+		Topic:    "com.example.chat",
+		Endpoint: server.URL,
+	}, nil)
+	require.NoError(t, err)
+
+	badge := 3
+	n := &Notification{Title: "New mention", Body: "alice mentioned you", Badge: &badge, Data: map[string]string{"channel_id": "c1"}}
+	require.NoError(t, provider.Send(context.Background(), "device-token", n))
+
+	assert.JSONEq(t, `{"alert":{"title":"New mention","body":"alice mentioned you"},"badge":3}`, string(payload["aps"]))
+	assert.JSONEq(t, `"c1"`, string(payload["channel_id"]))
+
+	assert.ErrorIs(t, provider.Send(context.Background(), "gone-token", n), ErrInvalidToken)
+}
+
+func TestFCMProviderSend(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var message map[string]json.RawMessage
+	tokenRequests := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests++
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.PostForm.Get("grant_type"))
+		_, err := jwt.Parse(r.PostForm.Get("assertion"), func(*jwt.Token) (interface{}, error) {
+			return &key.PublicKey, nil
+		}, jwt.WithValidMethods([]string{"RS256"}))
+		require.NoError(t, err)
+		w.Write([]byte(`{"access_token":"access-123","expires_in":3600}`))
+	})
+	mux.HandleFunc("/v1/projects/chat-app/messages:send", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer access-123", r.Header.Get("Authorization"))
+
+		var body struct {
+			Message map[string]json.RawMessage `json:"message"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		if string(body.Message["token"]) == `"stale-token"` {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"code":404,"status":"NOT_FOUND","details":[{"errorCode":"UNREGISTERED"}]}}`))
+			return
+		}
+		message = body.Message
+		w.Write([]byte(`{"name":"projects/chat-app/messages/1"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	credentials, err := json.Marshal(map[string]string{
+		"project_id":   "chat-app",
+		"client_email": "push@chat-app.iam.gserviceaccount.com",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})),
+		"token_uri":    server.URL + "/token",
+	})
+	require.NoError(t, err)
+
+	provider, err := NewFCMProvider(FCMConfig{CredentialsJSON: string(credentials), Endpoint: server.URL}, nil)
+	require.NoError(t, err)
+
+	badge := 2
+	n := &Notification{Title: "New message", Body: "hi", Badge: &badge, Urgent: true}
+	require.NoError(t, provider.Send(context.Background(), "device-token", n))
+	require.NoError(t, provider.Send(context.Background(), "device-token", n))
+
+	assert.Equal(t, 1, tokenRequests, "access token should be cached")
+	assert.JSONEq(t, `{"title":"New message","body":"hi"}`, string(message["notification"]))
+	assert.JSONEq(t, `{"badge":"2"}`, string(message["data"]))
+	assert.JSONEq(t, `{"priority":"HIGH","notification":{"notification_count":2}}`, string(message["android"]))
+
+	assert.ErrorIs(t, provider.Send(context.Background(), "stale-token", n), ErrInvalidToken)
+}
+
+func TestDispatcherProcess(t *testing.T) {
+	ctx := context.Background()
+	queue := NewMemoryQueue()
+	ios := NewFakeProvider(PlatformIOS)
+	android := NewFakeProvider(PlatformAndroid)
+
+	var removed []string
+	dispatcher := NewDispatcher(queue, []Provider{ios, android}, func(platform, token string) {
+		removed = append(removed, platform+":"+token)
+	})
+
+	ios.FailWith("stale", ErrInvalidToken)
+	android.FailWith("flaky", &ProviderError{StatusCode: 503, Temporary: true})
+	android.FailWith("rejected", &ProviderError{StatusCode: 400, Reason: "INVALID_ARGUMENT"})
+
+	n := &Notification{Title: "New mention"}
+	require.NoError(t, dispatcher.Enqueue(ctx, PlatformIOS, "good", n))
+	require.NoError(t, dispatcher.Enqueue(ctx, PlatformIOS, "stale", n))
+	require.NoError(t, dispatcher.Enqueue(ctx, PlatformAndroid, "flaky", n))
+	require.NoError(t, dispatcher.Enqueue(ctx, PlatformAndroid, "rejected", n))
+	assert.ErrorIs(t, dispatcher.Enqueue(ctx, "web", "token", n), ErrNoProvider)
+
+	delivered, err := dispatcher.Process(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, delivered)
+	assert.Len(t, ios.Sent(), 1)
+	assert.Equal(t, []string{"ios:stale"}, removed)
+
+	// The flaky job waits for its retry; the rejected one is dead-lettered
+	assert.Equal(t, 1, queue.Len())
+	dead := queue.DeadLetters()
+	require.Len(t, dead, 1)
+	assert.Equal(t, "rejected", dead[0].Token)
+
+	delivered, err = dispatcher.Process(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, delivered)
+
+	jobs, err := queue.Claim(ctx, time.Now().Add(time.Minute), time.Minute, 10)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, 1, jobs[0].Attempts)
+}
+
+func TestDispatcherDeadLettersAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	queue := NewMemoryQueue()
+	android := NewFakeProvider(PlatformAndroid)
+	android.FailWith("flaky", &ProviderError{StatusCode: 500, Temporary: true})
+	dispatcher := NewDispatcher(queue, []Provider{android}, nil)
+
+	require.NoError(t, queue.Push(ctx, &Job{ID: "job-1", Platform: PlatformAndroid, Token: "flaky", Attempts: maxAttempts - 1}, time.Now()))
+
+	_, err := dispatcher.Process(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, queue.Len())
+	assert.Len(t, queue.DeadLetters(), 1)
+}
+
+func TestRetryDelay(t *testing.T) {
+	assert.Equal(t, 30*time.Second, retryDelay(1))
+	assert.Equal(t, time.Minute, retryDelay(2))
+	assert.Equal(t, 8*time.Minute, retryDelay(5))
+	assert.Equal(t, time.Hour, retryDelay(20))
+}
@@ -0,0 +1,219 @@
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// deadLetterLimit caps how many failed jobs are kept for inspection
+const deadLetterLimit = 1000
+
+// Job is a queued delivery to one device.
+type Job struct {
+	ID           string       `json:"id"`
+	Platform     string       `json:"platform"`
+	Token        string       `json:"token"`
+	Notification Notification `json:"notification"`
+	Attempts     int          `json:"attempts"`
+	LastError    string       `json:"last_error,omitempty"`
+	EnqueuedAt   time.Time    `json:"enqueued_at"`
+}
+
+// Queue stores jobs until they are due. Claimed jobs are leased rather than
+// removed, so a worker that dies mid-send doesn't lose them.
+type Queue interface {
+	// Push schedules job to run at at; pushing an existing job reschedules it.
+	Push(ctx context.Context, job *Job, at time.Time) error
+	// Claim returns up to limit jobs due by now and hides them until now+lease.
+	Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*Job, error)
+	// Ack removes a finished job.
+	Ack(ctx context.Context, job *Job) error
+	// DeadLetter removes a job that will not be retried and keeps it for inspection.
+	DeadLetter(ctx context.Context, job *Job) error
+}
+
+// RedisQueue keeps job bodies in a hash and due times in a sorted set.
+type RedisQueue struct {
+	client  redis.UniversalClient
+	pending string
+	jobs    string
+	dead    string
+}
+
+// NewRedisQueue creates a queue whose keys all start with prefix.
+func NewRedisQueue(client redis.UniversalClient, prefix string) *RedisQueue {
+	return &RedisQueue{
+		client:  client,
+		pending: prefix + ":pending",
+		jobs:    prefix + ":jobs",
+		dead:    prefix + ":dead",
+	}
+}
+
+// claimScript moves due jobs' scores forward to the lease expiry and returns
+// their bodies, atomically so concurrent workers never claim the same job.
+var claimScript = redis.NewScript(`
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[3])
+local jobs = {}
+for _, id in ipairs(ids) do
+	redis.call('ZADD', KEYS[1], ARGV[2], id)
+	local body = redis.call('HGET', KEYS[2], id)
+	if body then
+		table.insert(jobs, body)
+	else
+		redis.call('ZREM', KEYS[1], id)
+	end
+end
+return jobs
+`)
+
+func (q *RedisQueue) Push(ctx context.Context, job *Job, at time.Time) error {
+	body, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, q.jobs, job.ID, body)
+		pipe.ZAdd(ctx, q.pending, redis.Z{Score: float64(at.UnixMilli()), Member: job.ID})
+		return nil
+	})
+	return err
+}
+
+func (q *RedisQueue) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*Job, error) {
+	bodies, err := claimScript.Run(ctx, q.client,
+		[]string{q.pending, q.jobs},
+		strconv.FormatInt(now.UnixMilli(), 10),
+		strconv.FormatInt(now.Add(lease).UnixMilli(), 10),
+		limit,
+	).StringSlice()
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]*Job, 0, len(bodies))
+	for _, body := range bodies {
+		job := &Job{}
+		if err := json.Unmarshal([]byte(body), job); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+func (q *RedisQueue) Ack(ctx context.Context, job *Job) error {
+	_, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, q.pending, job.ID)
+		pipe.HDel(ctx, q.jobs, job.ID)
+		return nil
+	})
+	return err
+}
+
+func (q *RedisQueue) DeadLetter(ctx context.Context, job *Job) error {
+	body, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, q.pending, job.ID)
+		pipe.HDel(ctx, q.jobs, job.ID)
+		pipe.LPush(ctx, q.dead, body)
+		pipe.LTrim(ctx, q.dead, 0, deadLetterLimit-1)
+		return nil
+	})
+	return err
+}
+
+// MemoryQueue is an in-process Queue for tests.
+type MemoryQueue struct {
+	mu   sync.Mutex
+	jobs map[string]*memoryEntry
+	dead []*Job
+}
+
+type memoryEntry struct {
+	job *Job
+	due time.Time
+}
+
+func NewMemoryQueue() *MemoryQueue {
+	return &MemoryQueue{jobs: make(map[string]*memoryEntry)}
+}
+
+func (q *MemoryQueue) Push(ctx context.Context, job *Job, at time.Time) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stored := *job
+	q.jobs[job.ID] = &memoryEntry{job: &stored, due: at}
+	return nil
+}
+
+func (q *MemoryQueue) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var due []*memoryEntry
+	for _, entry := range q.jobs {
+		if !entry.due.After(now) {
+			due = append(due, entry)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].due.Before(due[j].due) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	jobs := make([]*Job, 0, len(due))
+	for _, entry := range due {
+		entry.due = now.Add(lease)
+		claimed := *entry.job
+		jobs = append(jobs, &claimed)
+	}
+	return jobs, nil
+}
+
+func (q *MemoryQueue) Ack(ctx context.Context, job *Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.jobs, job.ID)
+	return nil
+}
+
+func (q *MemoryQueue) DeadLetter(ctx context.Context, job *Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.jobs, job.ID)
+	dead := *job
+	q.dead = append(q.dead, &dead)
+	return nil
+}
+
+// Len returns the number of pending jobs, including leased ones.
+func (q *MemoryQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.jobs)
+}
+
+// DeadLetters returns the jobs that were dead-lettered.
+func (q *MemoryQueue) DeadLetters() []*Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	dead := make([]*Job, len(q.dead))
+	copy(dead, q.dead)
+	return dead
+}
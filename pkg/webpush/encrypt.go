@@ -0,0 +1,133 @@
+package webpush
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// recordSize is the aes128gcm record size; a push message is one record
+	recordSize = 4096
+	// MaxPayloadSize is the largest plaintext that fits in one record
+	MaxPayloadSize = recordSize - 16 - 1 - 86
+)
+
+var (
+	ErrPayloadTooLarge = errors.New("push payload too large")
+	ErrInvalidKeys     = errors.New("invalid subscription keys")
+)
+
+// Encrypt encrypts payload for a subscription as described in RFC 8291,
+// using the aes128gcm content coding from RFC 8188.
+func Encrypt(payload []byte, p256dh, auth string) ([]byte, error) {
+	if len(payload) > MaxPayloadSize {
+		return nil, ErrPayloadTooLarge
+	}
+
+	uaPublic, authSecret, err := parseKeys(p256dh, auth)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ephemeral application server key pair and salt, one per message
+	asPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	return encrypt(payload, uaPublic, authSecret, asPrivate, salt)
+}
+
+// ValidateKeys checks a subscription's p256dh and auth values.
+func ValidateKeys(p256dh, auth string) error {
+	_, _, err := parseKeys(p256dh, auth)
+	return err
+}
+
+func parseKeys(p256dh, auth string) (*ecdh.PublicKey, []byte, error) {
+	uaPublicBytes, err := decodeBase64(p256dh)
+	if err != nil {
+		return nil, nil, ErrInvalidKeys
+	}
+	uaPublic, err := ecdh.P256().NewPublicKey(uaPublicBytes)
+	if err != nil {
+		return nil, nil, ErrInvalidKeys
+	}
+	authSecret, err := decodeBase64(auth)
+	if err != nil || len(authSecret) != 16 {
+		return nil, nil, ErrInvalidKeys
+	}
+	return uaPublic, authSecret, nil
+}
+
+func encrypt(payload []byte, uaPublic *ecdh.PublicKey, authSecret []byte, asPrivate *ecdh.PrivateKey, salt []byte) ([]byte, error) {
+	asPublicBytes := asPrivate.PublicKey().Bytes()
+
+	cek, nonce, err := deriveKeys(asPrivate, uaPublic, asPublicBytes, uaPublic.Bytes(), authSecret, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// Single, last record: payload followed by the 0x02 delimiter
+	plaintext := append(append([]byte{}, payload...), 0x02)
+	ciphertext := gcm.Seal(nil, nonce, plaintext, nil)
+
+	var body bytes.Buffer
+	body.Write(salt)
+	binary.Write(&body, binary.BigEndian, uint32(recordSize))
+	body.WriteByte(byte(len(asPublicBytes)))
+	body.Write(asPublicBytes)
+	body.Write(ciphertext)
+	return body.Bytes(), nil
+}
+
+// deriveKeys computes the content encryption key and nonce. local is our
+// private key and remote the other side's public key, so the same function
+// serves both encryption and decryption.
+func deriveKeys(local *ecdh.PrivateKey, remote *ecdh.PublicKey, asPublic, uaPublic, authSecret, salt []byte) ([]byte, []byte, error) {
+	ecdhSecret, err := local.ECDH(remote)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyInfo := append([]byte("WebPush: info\x00"), uaPublic...)
+	keyInfo = append(keyInfo, asPublic...)
+	ikm := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, ecdhSecret, authSecret, keyInfo), ikm); err != nil {
+		return nil, nil, err
+	}
+
+	prk := hkdf.Extract(sha256.New, ikm, salt)
+
+	cek := make([]byte, 16)
+	if _, err := io.ReadFull(hkdf.Expand(sha256.New, prk, []byte("Content-Encoding: aes128gcm\x00")), cek); err != nil {
+		return nil, nil, err
+	}
+	nonce := make([]byte, 12)
+	if _, err := io.ReadFull(hkdf.Expand(sha256.New, prk, []byte("Content-Encoding: nonce\x00")), nonce); err != nil {
+		return nil, nil, err
+	}
+	return cek, nonce, nil
+}
@@ -0,0 +1,175 @@
+package webpush
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrInvalidEndpoint  = errors.New("push endpoint must be a public https URL")
+	ErrForbiddenAddress = errors.New("push endpoint resolves to a non-public address")
+)
+
+// Subscription is a browser push subscription (PushSubscription.toJSON()).
+type Subscription struct {
+	Endpoint string
+	P256dh   string
+	Auth     string
+}
+
+// Options control how the push service handles a message.
+type Options struct {
+	TTL     int    // seconds the push service keeps the message for an offline device
+	Urgency string // very-low, low, normal, high
+	Topic   string // replaces an undelivered message with the same topic
+}
+
+// PushError is returned when the push service rejects a message.
+type PushError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *PushError) Error() string {
+	return fmt.Sprintf("push service responded %d: %s", e.StatusCode, e.Body)
+}
+
+// Gone reports whether the subscription no longer exists and should be removed.
+func (e *PushError) Gone() bool {
+	return e.StatusCode == http.StatusNotFound || e.StatusCode == http.StatusGone
+}
+
+// Retryable reports whether sending again later may succeed.
+func (e *PushError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// Sender delivers encrypted messages to push services.
+type Sender struct {
+	keys    *VAPIDKeys
+	subject string
+	client  *http.Client
+}
+
+// NewSender creates a Sender. subject is a mailto: or https: contact URL the
+// push service can use to reach the application's operator. A nil client
+// gets one from NewClient.
+func NewSender(keys *VAPIDKeys, subject string, client *http.Client) *Sender {
+	if client == nil {
+		client = NewClient(10 * time.Second)
+	}
+	return &Sender{
+		keys:    keys,
+		subject: subject,
+		client:  client,
+	}
+}
+
+// Keys returns the sender's VAPID keys.
+func (s *Sender) Keys() *VAPIDKeys {
+	return s.keys
+}
+
+// Send encrypts payload for sub and posts it to the subscription's endpoint.
+func (s *Sender) Send(sub *Subscription, payload []byte, opts *Options) error {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	body, err := Encrypt(payload, sub.P256dh, sub.Auth)
+	if err != nil {
+		return err
+	}
+
+	authorization, err := s.keys.authorization(sub.Endpoint, s.subject, 12*time.Hour)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", strconv.Itoa(opts.TTL))
+	if opts.Urgency != "" {
+		req.Header.Set("Urgency", opts.Urgency)
+	}
+	if opts.Topic != "" {
+		req.Header.Set("Topic", opts.Topic)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return &PushError{StatusCode: resp.StatusCode, Body: string(respBody)}
+}
+
+// ValidateEndpoint checks that a subscription endpoint is an https URL whose
+// host isn't obviously internal. Hostnames are checked again when dialing.
+func ValidateEndpoint(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" {
+		return ErrInvalidEndpoint
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrInvalidEndpoint
+	}
+	if ip := net.ParseIP(host); ip != nil && !publicAddress(ip) {
+		return ErrInvalidEndpoint
+	}
+	return nil
+}
+
+// NewClient returns an HTTP client that only connects to public addresses.
+// The check runs on the resolved address of every connection, redirects
+// included, so DNS rebinding can't get around it.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !publicAddress(ip) {
+				return ErrForbiddenAddress
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would make the connection on our behalf, unchecked
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// publicAddress reports whether ip is routable on the public internet.
+func publicAddress(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast())
+}
@@ -0,0 +1,115 @@
+package webpush
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// VAPIDKeys is the application server's P-256 key pair (RFC 8292).
+type VAPIDKeys struct {
+	private *ecdsa.PrivateKey
+	public  []byte // uncompressed point
+}
+
+// GenerateVAPIDKeys creates a new key pair.
+func GenerateVAPIDKeys() (*VAPIDKeys, error) {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return newVAPIDKeys(key)
+}
+
+// ParseVAPIDKeys loads a key pair from a base64url encoded private scalar, the
+// format used by most Web Push libraries. The public key, if given, must match.
+func ParseVAPIDKeys(privateKey, publicKey string) (*VAPIDKeys, error) {
+	raw, err := decodeBase64(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	key, err := ecdh.P256().NewPrivateKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+
+	keys, err := newVAPIDKeys(key)
+	if err != nil {
+		return nil, err
+	}
+	if publicKey != "" {
+		public, err := decodeBase64(publicKey)
+		if err != nil || !bytes.Equal(public, keys.public) {
+			return nil, errors.New("VAPID public key does not match private key")
+		}
+	}
+	return keys, nil
+}
+
+func newVAPIDKeys(key *ecdh.PrivateKey) (*VAPIDKeys, error) {
+	public := key.PublicKey().Bytes()
+	x, y := elliptic.Unmarshal(elliptic.P256(), public)
+	if x == nil {
+		return nil, errors.New("invalid VAPID public key")
+	}
+
+	return &VAPIDKeys{
+		private: &ecdsa.PrivateKey{
+			PublicKey: ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y},
+			D:         new(big.Int).SetBytes(key.Bytes()),
+		},
+		public: public,
+	}, nil
+}
+
+// PublicKey returns the base64url public key browsers pass to
+// pushManager.subscribe as applicationServerKey.
+func (k *VAPIDKeys) PublicKey() string {
+	return base64.RawURLEncoding.EncodeToString(k.public)
+}
+
+// PrivateKey returns the base64url private scalar.
+func (k *VAPIDKeys) PrivateKey() string {
+	return base64.RawURLEncoding.EncodeToString(k.private.D.FillBytes(make([]byte, 32)))
+}
+
+// authorization builds the Authorization header for a push endpoint.
+func (k *VAPIDKeys) authorization(endpoint, subject string, expiry time.Duration) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": time.Now().Add(expiry).Unix(),
+		"sub": subject,
+	})
+	signed, err := token.SignedString(k.private)
+	if err != nil {
+		return "", err
+	}
+
+	return "vapid t=" + signed + ", k=" + k.PublicKey(), nil
+}
+
+// decodeBase64 accepts base64url or standard base64, padded or not, since
+// browsers and libraries disagree on which one to use for subscription keys.
+func decodeBase64(s string) ([]byte, error) {
+	for _, enc := range []*base64.Encoding{base64.RawURLEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.StdEncoding} {
+		if b, err := enc.DecodeString(s); err == nil {
+			return b, nil
+		}
+	}
+	return nil, errors.New("invalid base64")
+}
@@ -0,0 +1,207 @@
+package webpush
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testBrowser plays the user agent side of a subscription.
+type testBrowser struct {
+	private *ecdh.PrivateKey
+	auth    []byte
+}
+
+func newTestBrowser(t *testing.T) *testBrowser {
+	private, err := ecdh.P256().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	auth := make([]byte, 16)
+	_, err = rand.Read(auth)
+	require.NoError(t, err)
+	return &testBrowser{private: private, auth: auth}
+}
+
+func (b *testBrowser) subscription(endpoint string) *Subscription {
+	return &Subscription{
+		Endpoint: endpoint,
+		P256dh:   base64.RawURLEncoding.EncodeToString(b.private.PublicKey().Bytes()),
+		Auth:     base64.RawURLEncoding.EncodeToString(b.auth),
+	}
+}
+
+// decrypt reverses Encrypt the way a browser would.
+func (b *testBrowser) decrypt(t *testing.T, body []byte) []byte {
+	salt := body[:16]
+	rs := binary.BigEndian.Uint32(body[16:20])
+	idLen := int(body[20])
+	asPublicBytes := body[21 : 21+idLen]
+	ciphertext := body[21+idLen:]
+	assert.Equal(t, uint32(recordSize), rs)
+
+	asPublic, err := ecdh.P256().NewPublicKey(asPublicBytes)
+	require.NoError(t, err)
+
+	cek, nonce, err := deriveKeys(b.private, asPublic, asPublicBytes, b.private.PublicKey().Bytes(), b.auth, salt)
+	require.NoError(t, err)
+
+	block, err := aes.NewCipher(cek)
+	require.NoError(t, err)
+	gcm, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	require.NoError(t, err)
+
+	// Strip the padding delimiter
+	require.Equal(t, byte(0x02), plaintext[len(plaintext)-1])
+	return plaintext[:len(plaintext)-1]
+}
+
+func TestSenderDeliversEncryptedPayload(t *testing.T) {
+	keys, err := GenerateVAPIDKeys()
+	require.NoError(t, err)
+	browser := newTestBrowser(t)
+
+	var received []byte
+	var headers http.Header
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		received, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer stub.Close()
+
+	sender := NewSender(keys, "mailto:ops@example.com", stub.Client())
+	err = sender.Send(browser.subscription(stub.URL+"/push/abc"), []byte(`{"title":"hi"}`), &Options{TTL: 60, Urgency: "high"})
+	require.NoError(t, err)
+
+	assert.Equal(t, `{"title":"hi"}`, string(browser.decrypt(t, received)))
+	assert.Equal(t, "aes128gcm", headers.Get("Content-Encoding"))
+	assert.Equal(t, "60", headers.Get("TTL"))
+	assert.Equal(t, "high", headers.Get("Urgency"))
+
+	// The VAPID token is signed by our key and scoped to the push service origin
+	auth := headers.Get("Authorization")
+	require.True(t, strings.HasPrefix(auth, "vapid t="))
+	parts := strings.SplitN(strings.TrimPrefix(auth, "vapid t="), ", k=", 2)
+	require.Len(t, parts, 2)
+	assert.Equal(t, keys.PublicKey(), parts[1])
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(parts[0], claims, func(*jwt.Token) (interface{}, error) {
+		return &keys.private.PublicKey, nil
+	}, jwt.WithValidMethods([]string{"ES256"}))
+	require.NoError(t, err)
+	assert.Equal(t, stub.URL, claims["aud"])
+	assert.Equal(t, "mailto:ops@example.com", claims["sub"])
+}
+
+func TestSenderReportsGoneSubscription(t *testing.T) {
+	keys, err := GenerateVAPIDKeys()
+	require.NoError(t, err)
+	browser := newTestBrowser(t)
+
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer stub.Close()
+
+	err = NewSender(keys, "mailto:ops@example.com", stub.Client()).Send(browser.subscription(stub.URL), []byte("x"), nil)
+
+	pushErr, ok := err.(*PushError)
+	require.True(t, ok)
+	assert.True(t, pushErr.Gone())
+	assert.False(t, pushErr.Retryable())
+}
+
+func TestValidateEndpoint(t *testing.T) {
+	assert.NoError(t, ValidateEndpoint("https://fcm.googleapis.com/fcm/send/abc"))
+	for _, endpoint := range []string{
+		"http://push.example.com/abc",
+		"https://127.0.0.1/abc",
+		"https://localhost:8443/abc",
+		"https://169.254.169.254/latest",
+		"https://10.0.0.5/abc",
+		"https://[::1]/abc",
+		"not a url",
+	} {
+		assert.Equal(t, ErrInvalidEndpoint, ValidateEndpoint(endpoint), endpoint)
+	}
+}
+
+func TestClientRefusesLoopback(t *testing.T) {
+	keys, err := GenerateVAPIDKeys()
+	require.NoError(t, err)
+	browser := newTestBrowser(t)
+
+	called := false
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer stub.Close()
+
+	// The default client checks the resolved address, whatever the URL says
+	err = NewSender(keys, "mailto:ops@example.com", nil).Send(browser.subscription(stub.URL), []byte("x"), nil)
+	assert.ErrorIs(t, err, ErrForbiddenAddress)
+	assert.False(t, called)
+}
+
+func TestParseVAPIDKeysRoundTrip(t *testing.T) {
+	keys, err := GenerateVAPIDKeys()
+	require.NoError(t, err)
+
+	parsed, err := ParseVAPIDKeys(keys.PrivateKey(), keys.PublicKey())
+	require.NoError(t, err)
+	assert.Equal(t, keys.PublicKey(), parsed.PublicKey())
+
+	other, err := GenerateVAPIDKeys()
+	require.NoError(t, err)
+	_, err = ParseVAPIDKeys(keys.PrivateKey(), other.PublicKey())
+	assert.Error(t, err)
+}
+
+func TestEncryptRejectsLargePayload(t *testing.T) {
+	browser := newTestBrowser(t)
+	sub := browser.subscription("https://push.example.com")
+
+	_, err := Encrypt(make([]byte, MaxPayloadSize+1), sub.P256dh, sub.Auth)
+	assert.Equal(t, ErrPayloadTooLarge, err)
+}
+
+// RFC 8291, Appendix A
+func TestEncryptMatchesRFC8291Example(t *testing.T) {
+	decode := func(s string) []byte {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		require.NoError(t, err)
+		return b
+	}
+
+	asPrivate, err := ecdh.P256().NewPrivateKey(decode("yfWPiYE-n46HLnH0KqZOF1fJJU3MYrct3AELtAQ-oRw"))
+	require.NoError(t, err)
+	uaPublic, err := ecdh.P256().NewPublicKey(decode("BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4"))
+	require.NoError(t, err)
+
+	body, err := encrypt(
+		[]byte("When I grow up, I want to be a watermelon"),
+		uaPublic,
+		decode("BTBZMqHH6r4Tts7J_aSIgg"),
+		asPrivate,
+		decode("DGv6ra1nlYgDCS1FRnbzlw"),
+	)
+	require.NoError(t, err)
+
+	expected := "DGv6ra1nlYgDCS1FRnbzlwAAEABBBP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6TlzAC8wEqKK6PBru3jl7A_yl95bQpu6cVPTpK4Mqgkf1CXztLVBSt2Ks3oZwbuwXPXLWyouBWLVWGNWQexSgSxsj_Qulcy4a-fN"
+	assert.Equal(t, expected, base64.RawURLEncoding.EncodeToString(body))
+}